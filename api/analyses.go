@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/pkg/symbol"
+	"github.com/huy125/finscope/store"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type analysisMetricResp struct {
+	Name       string    `json:"name"`
+	Value      *float64  `json:"value"`
+	PeriodEnd  string    `json:"period_end,omitempty"`
+	ReportedAt time.Time `json:"reported_at"`
+}
+
+type analysisResp struct {
+	ID             string               `json:"id"`
+	UserID         string               `json:"user_id,omitempty"`
+	StockID        string               `json:"stock_id"`
+	Symbol         string               `json:"symbol"`
+	Score          float64              `json:"score"`
+	CreatedAt      time.Time            `json:"created_at"`
+	Recommendation *recommendationResp  `json:"recommendation,omitempty"`
+	Metrics        []analysisMetricResp `json:"metrics,omitempty"`
+}
+
+type analysesResp struct {
+	Analyses []analysisResp `json:"analyses"`
+	Limit    int            `json:"limit"`
+	Offset   int            `json:"offset"`
+}
+
+type recommendationHistoryItemResp struct {
+	AnalysisID      string    `json:"analysis_id"`
+	Date            time.Time `json:"date"`
+	Score           float64   `json:"score"`
+	Action          string    `json:"action,omitempty"`
+	ConfidenceLevel float64   `json:"confidence_level"`
+}
+
+type recommendationHistoryResp struct {
+	Symbol  string                          `json:"symbol"`
+	History []recommendationHistoryItemResp `json:"history"`
+	Limit   int                             `json:"limit"`
+	Offset  int                             `json:"offset"`
+}
+
+// ListAnalysesHandler lists the recorded analyses, the most recent first.
+// Analyses can be filtered by symbol, user, date range and recommended action.
+func (s *Server) ListAnalysesHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter, err := parseAnalysisFilter(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Symbol = symbol.Normalize(q.Get("symbol"))
+	filter.Action = store.Action(q.Get("action"))
+
+	if user := q.Get("user"); user != "" {
+		filter.UserID, err = uuid.Parse(user)
+		if err != nil {
+			http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	analyses, err := s.store.ListAnalyses(ctx, filter)
+	if err != nil {
+		s.handleHistoryError(w, err)
+		return
+	}
+
+	resp := analysesResp{
+		Analyses: make([]analysisResp, 0, len(analyses)),
+		Limit:    filter.Limit,
+		Offset:   filter.Offset,
+	}
+	for _, analysis := range analyses {
+		resp.Analyses = append(resp.Analyses, toAnalysisResp(&analysis, nil))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetAnalysisHandler returns an analysis with its recommendation and the metric values it was scored on.
+func (s *Server) GetAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	analysis, err := s.store.FindAnalysis(ctx, id)
+	if err != nil {
+		s.handleHistoryError(w, err)
+		return
+	}
+
+	// The metric values used are the latest ones known when the analysis was recorded.
+	stockMetrics, err := s.store.FindStockMetricsAsOf(ctx, analysis.StockID, analysis.CreatedAt)
+	if err != nil {
+		s.log.Error("Failed to find stock metrics", lctx.Str("symbol", analysis.Symbol), lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(toAnalysisResp(analysis, stockMetrics)); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetRecommendationHistoryHandler returns the timeline of the recommendations of a stock.
+func (s *Server) GetRecommendationHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAnalysisFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	stock, err := s.store.FindStockBySymbol(ctx, r.PathValue("symbol"))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Stock data is not found", http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	filter.Symbol = stock.Symbol
+
+	analyses, err := s.store.ListRecommendationHistory(ctx, filter)
+	if err != nil {
+		s.handleHistoryError(w, err)
+		return
+	}
+
+	resp := recommendationHistoryResp{
+		Symbol:  stock.Symbol,
+		History: make([]recommendationHistoryItemResp, 0, len(analyses)),
+		Limit:   filter.Limit,
+		Offset:  filter.Offset,
+	}
+	for _, analysis := range analyses {
+		item := recommendationHistoryItemResp{
+			AnalysisID: analysis.ID.String(),
+			Date:       analysis.CreatedAt,
+			Score:      analysis.Score,
+		}
+		if analysis.Recommendation != nil {
+			item.Action = string(analysis.Recommendation.Action)
+			item.ConfidenceLevel = analysis.Recommendation.ConfidenceLevel
+		}
+		resp.History = append(resp.History, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// parseAnalysisFilter parses the date range and the pagination of the analysis filter.
+// The date range includes both the from and the to dates.
+func parseAnalysisFilter(q url.Values) (*store.AnalysisFilter, error) {
+	filter := &store.AnalysisFilter{Limit: defaultPageSize}
+
+	if from := q.Get("from"); from != "" {
+		date, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from date %q", from)
+		}
+		filter.From = date
+	}
+
+	if to := q.Get("to"); to != "" {
+		date, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to date %q", to)
+		}
+		filter.To = date.AddDate(0, 0, 1)
+	}
+
+	var err error
+	filter.Limit, filter.Offset, err = parsePage(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// parsePage parses the limit and offset query parameters.
+func parsePage(q url.Values) (limit, offset int, err error) {
+	limit = defaultPageSize
+	if v := q.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxPageSize {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+	}
+
+	if v := q.Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must not be negative")
+		}
+	}
+
+	return limit, offset, nil
+}
+
+func (s *Server) handleHistoryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		http.Error(w, "Analysis not found", http.StatusNotFound)
+	case errors.As(err, &store.ValidationError{}):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		s.log.Error("Failed to read analyses", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func toAnalysisResp(a *store.AnalysisRecommendation, stockMetrics []store.LatestStockMetric) analysisResp {
+	resp := analysisResp{
+		ID:        a.ID.String(),
+		StockID:   a.StockID.String(),
+		Symbol:    a.Symbol,
+		Score:     a.Score,
+		CreatedAt: a.CreatedAt,
+	}
+
+	if a.UserID != uuid.Nil {
+		resp.UserID = a.UserID.String()
+	}
+
+	if a.Recommendation != nil {
+		recommendation := toRecommendationResp(a.Recommendation)
+		resp.Recommendation = &recommendation
+	}
+
+	for _, m := range stockMetrics {
+		metric := analysisMetricResp{
+			Name:       m.MetricName,
+			Value:      m.Value,
+			ReportedAt: m.ReportedAt,
+		}
+		if m.PeriodEnd != nil {
+			metric.PeriodEnd = m.PeriodEnd.Format(time.DateOnly)
+		}
+		resp.Metrics = append(resp.Metrics, metric)
+	}
+
+	return resp
+}
@@ -0,0 +1,370 @@
+package api_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestServer_ListAnalysesHandler(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	analysisID := uuid.New()
+	stockID := uuid.New()
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+
+		query string
+
+		wantFilter *store.AnalysisFilter
+		returnList []store.AnalysisRecommendation
+
+		wantStatus int
+		wantResult []byte
+	}{
+		{
+			name: "lists filtered analyses",
+
+			query: "?symbol=AAPL&user=" + userID.String() + "&from=2024-03-01&to=2024-03-31&action=ActionBuy&limit=10&offset=20",
+
+			wantFilter: &store.AnalysisFilter{
+				Symbol: "AAPL",
+				UserID: userID,
+				From:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				To:     time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+				Action: store.ActionBuy,
+				Limit:  10,
+				Offset: 20,
+			},
+			returnList: []store.AnalysisRecommendation{
+				{
+					Analysis: store.Analysis{
+						Model:   store.Model{ID: analysisID, CreatedAt: createdAt},
+						UserID:  userID,
+						StockID: stockID,
+						Score:   7.5,
+					},
+					Symbol: "AAPL",
+				},
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: []byte(`
+				{
+					"analyses": [
+						{
+							"id": "` + analysisID.String() + `",
+							"user_id": "` + userID.String() + `",
+							"stock_id": "` + stockID.String() + `",
+							"symbol": "AAPL",
+							"score": 7.5,
+							"created_at": "2024-03-01T10:00:00Z"
+						}
+					],
+					"limit": 10,
+					"offset": 20
+				}`,
+			),
+		},
+		{
+			name: "handles invalid user",
+
+			query: "?user=invalid",
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles invalid date",
+
+			query: "?from=yesterday",
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles invalid limit",
+
+			query: "?limit=1000",
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.wantFilter != nil {
+				storeMock.On("ListAnalyses", test.wantFilter).Return(test.returnList, nil)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/analyses"+test.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if rr.Code == http.StatusOK {
+				res, err := io.ReadAll(rr.Body)
+				require.NoError(t, err)
+
+				assert.JSONEq(t, string(test.wantResult), string(res))
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_GetAnalysisHandler(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	analysisID := uuid.New()
+	recommendationID := uuid.New()
+	stockID := uuid.New()
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	periodEnd := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+	value := 15.0
+
+	tests := []struct {
+		name string
+
+		id string
+
+		returnAnalysis *store.AnalysisRecommendation
+		returnErr      error
+		returnMetrics  []store.LatestStockMetric
+
+		wantStatus int
+		wantResult []byte
+	}{
+		{
+			name: "returns analysis with recommendation and metrics",
+
+			id: analysisID.String(),
+
+			returnAnalysis: &store.AnalysisRecommendation{
+				Analysis: store.Analysis{
+					Model:   store.Model{ID: analysisID, CreatedAt: createdAt},
+					UserID:  userID,
+					StockID: stockID,
+					Score:   7,
+				},
+				Symbol: "AAPL",
+				Recommendation: &store.Recommendation{
+					Model:           store.Model{ID: recommendationID},
+					AnalysisID:      analysisID,
+					Action:          store.ActionBuy,
+					ConfidenceLevel: 80,
+				},
+			},
+			returnMetrics: []store.LatestStockMetric{
+				{MetricName: "P/E Ratio", Value: &value, PeriodEnd: &periodEnd, ReportedAt: createdAt},
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: []byte(`
+				{
+					"id": "` + analysisID.String() + `",
+					"user_id": "` + userID.String() + `",
+					"stock_id": "` + stockID.String() + `",
+					"symbol": "AAPL",
+					"score": 7,
+					"created_at": "2024-03-01T10:00:00Z",
+					"recommendation": {
+						"id": "` + recommendationID.String() + `",
+						"analysis_id": "` + analysisID.String() + `",
+						"action": "ActionBuy",
+						"confidence_level": 80,
+						"reason": ""
+					},
+					"metrics": [
+						{
+							"name": "P/E Ratio",
+							"value": 15,
+							"period_end": "2023-12-31",
+							"reported_at": "2024-03-01T10:00:00Z"
+						}
+					]
+				}`,
+			),
+		},
+		{
+			name: "handles analysis not found error",
+
+			id: analysisID.String(),
+
+			returnErr: store.ErrNotFound,
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "handles invalid ID",
+
+			id: "invalid",
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.returnAnalysis != nil || test.returnErr != nil {
+				storeMock.On("FindAnalysis", analysisID).Return(test.returnAnalysis, test.returnErr)
+			}
+			if test.returnAnalysis != nil {
+				storeMock.On("FindStockMetricsAsOf", stockID, createdAt).Return(test.returnMetrics, nil)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/analyses/"+test.id, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if rr.Code == http.StatusOK {
+				res, err := io.ReadAll(rr.Body)
+				require.NoError(t, err)
+
+				assert.JSONEq(t, string(test.wantResult), string(res))
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_GetRecommendationHistoryHandler(t *testing.T) {
+	t.Parallel()
+
+	analysisID := uuid.New()
+	stock := &store.Stock{Model: store.Model{ID: uuid.New()}, Symbol: "AAPL"}
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	cookieMock := api.ServerCookieConfig{
+		Name:     "test_access_token",
+		Path:     "/",
+		HttpOnly: false,
+		Secure:   false,
+	}
+
+	storeMock := &storeMock{}
+	storeMock.On("FindStockBySymbol", "AAPL").Return(stock, nil)
+	storeMock.On("ListRecommendationHistory", &store.AnalysisFilter{
+		Symbol: "AAPL",
+		From:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Limit:  20,
+	}).Return([]store.AnalysisRecommendation{
+		{
+			Analysis: store.Analysis{Model: store.Model{ID: analysisID, CreatedAt: createdAt}, Score: 8.5},
+			Symbol:   "AAPL",
+			Recommendation: &store.Recommendation{
+				Action:          store.ActionStrongBuy,
+				ConfidenceLevel: 90,
+			},
+		},
+	}, nil)
+
+	authMock := &authenticatorMock{}
+	idToken := createIDToken(t)
+	authMock.On("ExtractTokenFromRequest").Return("valid-token")
+	authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+	obsvr := observe.NewFake()
+	srv := api.New(testAPIKey, testFilePath, cookieMock, storeMock, authMock, obsvr)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		"/stocks/AAPL/recommendations/history?from=2024-01-01",
+		nil,
+	)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	srv.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `
+		{
+			"symbol": "AAPL",
+			"history": [
+				{
+					"analysis_id": "`+analysisID.String()+`",
+					"date": "2024-03-01T10:00:00Z",
+					"score": 8.5,
+					"action": "Strong ActionBuy",
+					"confidence_level": 90
+				}
+			],
+			"limit": 20,
+			"offset": 0
+		}`,
+		rr.Body.String(),
+	)
+	storeMock.AssertExpectations(t)
+}
@@ -0,0 +1,299 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/store"
+)
+
+const (
+	// analysisJobType is the queue job type running analysis jobs.
+	analysisJobType = "analysis"
+	// analysisJobTimeout bounds the run of a single analysis job.
+	analysisJobTimeout = 10 * time.Minute
+)
+
+// analysisJobPayload is the payload of the queue job running an analysis job.
+type analysisJobPayload struct {
+	AnalysisJobID uuid.UUID `json:"analysisJobId"`
+}
+
+type analysisJobResultResp struct {
+	Symbol          string  `json:"symbol"`
+	AnalysisID      string  `json:"analysis_id,omitempty"`
+	Score           float64 `json:"score,omitempty"`
+	Action          string  `json:"action,omitempty"`
+	ConfidenceLevel float64 `json:"confidence_level,omitempty"`
+	Error           string  `json:"error,omitempty"`
+}
+
+type jobProgressResp struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+type analysisJobResp struct {
+	ID         string                  `json:"id"`
+	Status     string                  `json:"status"`
+	Symbols    []string                `json:"symbols"`
+	Profile    string                  `json:"profile,omitempty"`
+	Progress   jobProgressResp         `json:"progress"`
+	Results    []analysisJobResultResp `json:"results"`
+	Error      string                  `json:"error,omitempty"`
+	CreatedAt  time.Time               `json:"created_at"`
+	StartedAt  *time.Time              `json:"started_at,omitempty"`
+	FinishedAt *time.Time              `json:"finished_at,omitempty"`
+}
+
+// CreateAnalysisJobHandler queues the analysis of stocks, run in the background by the job worker.
+func (s *Server) CreateAnalysisJobHandler(w http.ResponseWriter, r *http.Request) {
+	var req batchAnalysisReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	symbols, err := req.jobSymbols()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := loadScoringConfig(s.filePath)
+	if err != nil {
+		s.log.Error("Failed to load scoring config", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = cfg.ProfileThresholds(req.Profile); err != nil {
+		http.Error(w, "Scoring profile is not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		s.log.Error("Failed to resolve the current user", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// The job is queued with its record, so that no record is left without a worker to run it.
+	var job *store.AnalysisJob
+	err = s.store.WithTx(ctx, func(ctx context.Context) error {
+		job, err = s.store.CreateAnalysisJob(ctx, &store.CreateAnalysisJob{
+			UserID:  user.ID,
+			Symbols: symbols,
+			Profile: req.Profile,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = s.store.EnqueueJob(ctx, &store.EnqueueJob{
+			Type:    analysisJobType,
+			Payload: analysisJobPayload{AnalysisJobID: job.ID},
+		})
+		return err
+	})
+	if err != nil {
+		s.log.Error("Failed to create analysis job", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/jobs/"+job.ID.String())
+	w.WriteHeader(http.StatusAccepted)
+	if err = json.NewEncoder(w).Encode(toAnalysisJobResp(job)); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetJobHandler reports the status, progress and results of a job of the current user.
+func (s *Server) GetJobHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		s.log.Error("Failed to resolve the current user", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	job, err := s.store.FindAnalysisJob(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Jobs of other users are reported as missing.
+	if job.UserID != user.ID {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(toAnalysisJobResp(job)); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// JobHandlers returns the handlers of the background jobs queued by the server, by job type.
+func (s *Server) JobHandlers() map[string]store.JobHandler {
+	return map[string]store.JobHandler{
+		analysisJobType: s.handleAnalysisJob,
+		refreshJobType:  s.handleRefreshJob,
+	}
+}
+
+// handleAnalysisJob runs the analysis job of a queue job.
+// The analysis job is failed once the queue job has no attempts left.
+func (s *Server) handleAnalysisJob(ctx context.Context, job *store.Job) error {
+	var payload analysisJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("decoding payload: %w", err)
+	}
+
+	analysisJob, err := s.store.StartAnalysisJob(ctx, payload.AnalysisJobID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			// The job already finished or was deleted with its user.
+			return nil
+		}
+
+		return fmt.Errorf("starting analysis job: %w", err)
+	}
+
+	err = s.runAnalysisJob(ctx, analysisJob)
+	if err != nil && ctx.Err() == nil && job.Attempts >= job.MaxAttempts {
+		s.failAnalysisJob(ctx, analysisJob.ID, "Job failed")
+	}
+
+	return err
+}
+
+// runAnalysisJob analyzes the symbols of a job not analyzed yet, recording the results after each symbol
+// so that an interrupted job resumes where it stopped.
+// A returned error leaves the job running, to be resumed by the next attempt.
+func (s *Server) runAnalysisJob(workerCtx context.Context, job *store.AnalysisJob) error {
+	ctx, cancel := context.WithTimeout(workerCtx, analysisJobTimeout)
+	defer cancel()
+
+	cfg, err := loadScoringConfig(s.filePath)
+	if err != nil {
+		s.log.Error("Failed to load scoring config", lctx.Str("job", job.ID.String()), lctx.Error("error", err))
+		s.failAnalysisJob(ctx, job.ID, "Scoring config could not be loaded")
+		return nil
+	}
+
+	thresholds, err := cfg.ProfileThresholds(job.Profile)
+	if err != nil {
+		s.failAnalysisJob(ctx, job.ID, "Scoring profile is not found")
+		return nil
+	}
+
+	user := &store.User{Model: store.Model{ID: job.UserID}}
+	results := slices.Clone(job.Results)
+	for _, symbol := range job.Symbols[min(len(results), len(job.Symbols)):] {
+		switch {
+		case workerCtx.Err() != nil:
+			return workerCtx.Err()
+		case ctx.Err() != nil:
+			s.failAnalysisJob(ctx, job.ID, "Job timed out")
+			return nil
+		}
+
+		jobResult := store.AnalysisJobResult{Symbol: symbol}
+
+		result, err := s.analyzeSymbol(ctx, user, symbol, cfg.Rules, thresholds)
+		if err != nil {
+			jobResult.Error = s.analysisFailure(symbol, err)
+		} else {
+			jobResult.AnalysisID = &result.analysis.ID
+			jobResult.Score = result.analysis.Score
+			jobResult.Action = result.recommendation.Action
+			jobResult.ConfidenceLevel = result.recommendation.ConfidenceLevel
+		}
+		results = append(results, jobResult)
+
+		if err = s.store.UpdateAnalysisJobResults(ctx, job.ID, results); err != nil {
+			return fmt.Errorf("recording results: %w", err)
+		}
+	}
+
+	if err = s.store.FinishAnalysisJob(ctx, job.ID, store.JobStatusSucceeded, ""); err != nil {
+		return fmt.Errorf("finishing analysis job: %w", err)
+	}
+
+	return nil
+}
+
+// failAnalysisJob marks an analysis job as failed, even if the context is done.
+func (s *Server) failAnalysisJob(ctx context.Context, id uuid.UUID, msg string) {
+	finishCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), requestTimeout*time.Second)
+	defer cancel()
+
+	if err := s.store.FinishAnalysisJob(finishCtx, id, store.JobStatusFailed, msg); err != nil {
+		s.log.Error("Failed to finish analysis job", lctx.Str("job", id.String()), lctx.Error("error", err))
+	}
+}
+
+func toAnalysisJobResp(job *store.AnalysisJob) analysisJobResp {
+	resp := analysisJobResp{
+		ID:      job.ID.String(),
+		Status:  string(job.Status),
+		Symbols: job.Symbols,
+		Profile: job.Profile,
+		Progress: jobProgressResp{
+			Completed: len(job.Results),
+			Total:     len(job.Symbols),
+		},
+		Results:    make([]analysisJobResultResp, 0, len(job.Results)),
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+
+	for _, r := range job.Results {
+		result := analysisJobResultResp{
+			Symbol:          r.Symbol,
+			Score:           r.Score,
+			Action:          string(r.Action),
+			ConfidenceLevel: r.ConfidenceLevel,
+			Error:           r.Error,
+		}
+		if r.AnalysisID != nil {
+			result.AnalysisID = r.AnalysisID.String()
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp
+}
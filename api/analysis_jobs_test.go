@@ -0,0 +1,392 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestServer_CreateAnalysisJobHandler(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	jobID := uuid.New()
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	tooMany := make([]string, 51)
+	for i := range tooMany {
+		tooMany[i] = `"S` + strings.Repeat("X", i) + `"`
+	}
+
+	tests := []struct {
+		name string
+
+		sendBody string
+
+		wantJob *store.CreateAnalysisJob
+
+		wantStatus   int
+		wantLocation string
+		wantResult   string
+	}{
+		{
+			name: "queues analysis job",
+
+			sendBody: `{"symbols": ["AAPL", "MSFT", "AAPL"], "profile": "conservative"}`,
+
+			wantJob: &store.CreateAnalysisJob{UserID: userID, Symbols: []string{"AAPL", "MSFT"}, Profile: "conservative"},
+
+			wantStatus:   http.StatusAccepted,
+			wantLocation: "/jobs/" + jobID.String(),
+			wantResult: `
+				{
+					"id": "` + jobID.String() + `",
+					"status": "pending",
+					"symbols": ["AAPL", "MSFT"],
+					"profile": "conservative",
+					"progress": {"completed": 0, "total": 2},
+					"results": [],
+					"created_at": "2024-03-01T10:00:00Z"
+				}`,
+		},
+		{
+			name: "handles missing symbols",
+
+			sendBody: `{"symbols": [" "]}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles too many symbols",
+
+			sendBody: `{"symbols": [` + strings.Join(tooMany, ",") + `]}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles unknown scoring profile",
+
+			sendBody: `{"symbols": ["AAPL"], "profile": "unknown"}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.wantJob != nil {
+				storeMock.On("ProvisionUser", mock.Anything).Return(&store.User{Model: store.Model{ID: userID}}, nil)
+				storeMock.On("CreateAnalysisJob", test.wantJob).Return(&store.AnalysisJob{
+					Model:   store.Model{ID: jobID, CreatedAt: createdAt},
+					UserID:  userID,
+					Symbols: test.wantJob.Symbols,
+					Profile: test.wantJob.Profile,
+					Status:  store.JobStatusPending,
+				}, nil)
+				storeMock.On("EnqueueJob", mock.MatchedBy(func(job *store.EnqueueJob) bool {
+					return job.Type == "analysis"
+				})).Return(&store.Job{Model: store.Model{ID: uuid.New()}}, nil)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(
+				ctx,
+				http.MethodPost,
+				"/stocks/analysis/jobs",
+				bytes.NewBufferString(test.sendBody),
+			)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if rr.Code == http.StatusAccepted {
+				assert.Equal(t, test.wantLocation, rr.Header().Get("Location"))
+				assert.JSONEq(t, test.wantResult, rr.Body.String())
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_GetJobHandler(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	jobID := uuid.New()
+	analysisID := uuid.New()
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	job := &store.AnalysisJob{
+		Model:   store.Model{ID: jobID, CreatedAt: createdAt},
+		UserID:  userID,
+		Symbols: []string{"AAPL", "UNKNOWN", "MSFT"},
+		Status:  store.JobStatusRunning,
+		Results: []store.AnalysisJobResult{
+			{Symbol: "AAPL", AnalysisID: &analysisID, Score: 8, Action: store.ActionBuy, ConfidenceLevel: 75},
+			{Symbol: "UNKNOWN", Error: "Stock data is not found"},
+		},
+		StartedAt: &createdAt,
+	}
+
+	tests := []struct {
+		name string
+
+		id         string
+		returnUser uuid.UUID
+		returnJob  *store.AnalysisJob
+		returnErr  error
+
+		wantStatus int
+		wantResult string
+	}{
+		{
+			name: "returns job progress",
+
+			id:         jobID.String(),
+			returnUser: userID,
+			returnJob:  job,
+
+			wantStatus: http.StatusOK,
+			wantResult: `
+				{
+					"id": "` + jobID.String() + `",
+					"status": "running",
+					"symbols": ["AAPL", "UNKNOWN", "MSFT"],
+					"progress": {"completed": 2, "total": 3},
+					"results": [
+						{
+							"symbol": "AAPL",
+							"analysis_id": "` + analysisID.String() + `",
+							"score": 8,
+							"action": "ActionBuy",
+							"confidence_level": 75
+						},
+						{"symbol": "UNKNOWN", "error": "Stock data is not found"}
+					],
+					"created_at": "2024-03-01T10:00:00Z",
+					"started_at": "2024-03-01T10:00:00Z"
+				}`,
+		},
+		{
+			name: "hides jobs of other users",
+
+			id:         jobID.String(),
+			returnUser: uuid.New(),
+			returnJob:  job,
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "handles job not found error",
+
+			id:         jobID.String(),
+			returnUser: userID,
+			returnErr:  store.ErrNotFound,
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "handles invalid ID",
+
+			id: "invalid",
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.returnUser != uuid.Nil {
+				storeMock.On("ProvisionUser", mock.Anything).Return(&store.User{Model: store.Model{ID: test.returnUser}}, nil)
+				storeMock.On("FindAnalysisJob", jobID).Return(test.returnJob, test.returnErr)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/jobs/"+test.id, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if rr.Code == http.StatusOK {
+				assert.JSONEq(t, test.wantResult, rr.Body.String())
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_AnalysisJobHandler(t *testing.T) {
+	t.Parallel()
+
+	jobID := uuid.New()
+	analysisID := uuid.New()
+	payload := []byte(`{"analysisJobId": "` + jobID.String() + `"}`)
+
+	// The job resumes after the symbols already analyzed.
+	previous := store.AnalysisJobResult{Symbol: "AAPL", AnalysisID: &analysisID, Score: 8, Action: store.ActionBuy}
+	analysisJob := &store.AnalysisJob{
+		Model:   store.Model{ID: jobID},
+		UserID:  uuid.New(),
+		Symbols: []string{"AAPL", "UNKNOWN"},
+		Status:  store.JobStatusRunning,
+		Results: []store.AnalysisJobResult{previous},
+	}
+	wantResults := []store.AnalysisJobResult{
+		previous,
+		{Symbol: "UNKNOWN", Error: "Stock data is not found"},
+	}
+
+	tests := []struct {
+		name string
+
+		attempts         int
+		returnStartErr   error
+		returnUpdateErr  error
+		wantUpdate       bool
+		wantFinishStatus store.JobStatus
+		wantFinishErr    string
+
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "resumes analysis job",
+
+			attempts:         1,
+			wantUpdate:       true,
+			wantFinishStatus: store.JobStatusSucceeded,
+
+			wantErr: require.NoError,
+		},
+		{
+			name: "skips finished analysis job",
+
+			attempts:       1,
+			returnStartErr: store.ErrNotFound,
+
+			wantErr: require.NoError,
+		},
+		{
+			name: "retries failed attempt",
+
+			attempts:        1,
+			returnUpdateErr: errors.New("test error"),
+			wantUpdate:      true,
+
+			wantErr: require.Error,
+		},
+		{
+			name: "fails analysis job on last attempt",
+
+			attempts:         3,
+			returnUpdateErr:  errors.New("test error"),
+			wantUpdate:       true,
+			wantFinishStatus: store.JobStatusFailed,
+			wantFinishErr:    "Job failed",
+
+			wantErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			storeMock := &storeMock{}
+			if test.returnStartErr != nil {
+				storeMock.On("StartAnalysisJob", jobID).Return(nil, test.returnStartErr)
+			} else {
+				storeMock.On("StartAnalysisJob", jobID).Return(analysisJob, nil)
+			}
+			if test.wantUpdate {
+				storeMock.On("FindStockBySymbol", "UNKNOWN").Return(nil, store.ErrNotFound)
+				storeMock.On("UpdateAnalysisJobResults", jobID, wantResults).Return(test.returnUpdateErr)
+			}
+			if test.wantFinishStatus != "" {
+				storeMock.On("FinishAnalysisJob", jobID, test.wantFinishStatus, test.wantFinishErr).Return(nil)
+			}
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, api.ServerCookieConfig{}, storeMock, &authenticatorMock{}, obsvr)
+
+			handler, ok := srv.JobHandlers()["analysis"]
+			require.True(t, ok)
+
+			err := handler(ctx, &store.Job{
+				Model:       store.Model{ID: uuid.New()},
+				Type:        "analysis",
+				Payload:     payload,
+				Status:      store.JobStatusRunning,
+				Attempts:    test.attempts,
+				MaxAttempts: 3,
+			})
+
+			test.wantErr(t, err)
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/pkg/backtest"
+	"github.com/huy125/finscope/store"
+)
+
+const (
+	backtestTimeout = 30
+	stockPageSize   = 500
+	// priceLookbackDays covers weekends and holidays when looking for the close on or before the start date.
+	priceLookbackDays = 7
+)
+
+type backtestReq struct {
+	Profile   string `json:"profile"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	Frequency string `json:"frequency"`
+	Benchmark string `json:"benchmark"`
+}
+
+type backtestPeriodResp struct {
+	Start           string   `json:"start"`
+	End             string   `json:"end"`
+	Holdings        []string `json:"holdings"`
+	Return          float64  `json:"return"`
+	BenchmarkReturn float64  `json:"benchmark_return"`
+	Turnover        float64  `json:"turnover"`
+}
+
+type backtestResp struct {
+	Profile              string               `json:"profile,omitempty"`
+	Benchmark            string               `json:"benchmark,omitempty"`
+	TotalReturn          float64              `json:"total_return"`
+	BenchmarkReturn      float64              `json:"benchmark_return"`
+	ExcessReturn         float64              `json:"excess_return"`
+	MaxDrawdown          float64              `json:"max_drawdown"`
+	BenchmarkMaxDrawdown float64              `json:"benchmark_max_drawdown"`
+	HitRate              float64              `json:"hit_rate"`
+	Turnover             float64              `json:"turnover"`
+	Periods              []backtestPeriodResp `json:"periods"`
+}
+
+// toConfig parses the backtest request into a backtest configuration.
+func (r *backtestReq) toConfig() (backtest.Config, error) {
+	start, err := time.Parse(time.DateOnly, r.Start)
+	if err != nil {
+		return backtest.Config{}, fmt.Errorf("invalid start date %q", r.Start)
+	}
+
+	end, err := time.Parse(time.DateOnly, r.End)
+	if err != nil {
+		return backtest.Config{}, fmt.Errorf("invalid end date %q", r.End)
+	}
+
+	cfg := backtest.Config{
+		Start:     start,
+		End:       end,
+		Frequency: backtest.Frequency(r.Frequency),
+	}
+	if cfg.Frequency == "" {
+		cfg.Frequency = backtest.FrequencyMonthly
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// BacktestHandler replays a scoring profile over the recorded stock metrics and prices,
+// holding an equally weighted portfolio of the Buy and Strong Buy stocks between rebalancing dates.
+func (s *Server) BacktestHandler(w http.ResponseWriter, r *http.Request) {
+	var req backtestReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	btCfg, err := req.toConfig()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := loadScoringConfig(s.filePath)
+	if err != nil {
+		s.log.Error("Failed to load scoring config", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	thresholds, err := cfg.ProfileThresholds(req.Profile)
+	if err != nil {
+		http.Error(w, "Scoring profile is not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), backtestTimeout*time.Second)
+	defer cancel()
+
+	// Inactive stocks are included, avoiding the survivorship bias of testing on today's universe only.
+	stocks, err := s.listAllStocks(ctx, store.StockFilter{})
+	if err != nil {
+		s.log.Error("Failed to list stocks", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	history, err := s.store.FindStockMetricHistory(ctx, btCfg.Start, btCfg.End)
+	if err != nil {
+		s.log.Error("Failed to find stock metric history", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	prices, err := s.store.ListStockPrices(ctx, btCfg.Start.AddDate(0, 0, -priceLookbackDays), btCfg.End)
+	if err != nil {
+		s.log.Error("Failed to list stock prices", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	bars := toBars(stocks, prices)
+	var benchmark []backtest.Bar
+	if req.Benchmark != "" {
+		benchmark = bars[req.Benchmark]
+		if len(benchmark) == 0 {
+			http.Error(w, "No prices recorded for the benchmark", http.StatusBadRequest)
+			return
+		}
+	}
+
+	timeline := &metricTimeline{history: history}
+	selector := func(date time.Time) ([]string, error) {
+		timeline.advance(date)
+		return s.selectBuys(ctx, stocks, timeline, cfg.Rules, thresholds)
+	}
+
+	res, err := backtest.Run(btCfg, bars, benchmark, selector)
+	if err != nil {
+		s.log.Error("Failed to run backtest", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(toBacktestResp(req, res)); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// listAllStocks returns every stock of the universe matching the filter, ignoring its pagination.
+func (s *Server) listAllStocks(ctx context.Context, filter store.StockFilter) ([]store.Stock, error) {
+	var stocks []store.Stock
+	for offset := 0; ; offset += stockPageSize {
+		filter.Limit, filter.Offset = stockPageSize, offset
+
+		page, err := s.store.ListStocks(ctx, &filter)
+		if err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, page...)
+
+		if len(page) < stockPageSize {
+			return stocks, nil
+		}
+	}
+}
+
+// selectBuys scores every stock on the metrics known in the timeline and returns the symbols
+// recommended as Buy or Strong Buy.
+func (s *Server) selectBuys(
+	ctx context.Context,
+	stocks []store.Stock,
+	timeline *metricTimeline,
+	rules map[string]Rule,
+	thresholds Thresholds,
+) ([]string, error) {
+	distributions := timeline.distributions(stocks)
+
+	var selected []string
+	for _, stock := range stocks {
+		stockMetrics := timeline.metrics(stock.ID)
+		if len(stockMetrics) == 0 {
+			continue
+		}
+
+		card, err := s.scoreMetrics(ctx, stockMetrics, rules, snapshotPeers(&stock, distributions))
+		if err != nil {
+			return nil, fmt.Errorf("scoring stock %s: %w", stock.Symbol, err)
+		}
+
+		switch thresholds.action(card.Score()) {
+		case store.ActionStrongBuy, store.ActionBuy:
+			selected = append(selected, stock.Symbol)
+		default:
+		}
+	}
+
+	return selected, nil
+}
+
+// metricTimeline replays the recorded stock metrics in chronological order.
+type metricTimeline struct {
+	history  []store.HistoricalStockMetric
+	next     int
+	snapshot map[uuid.UUID]map[string]store.LatestStockMetric
+}
+
+// advance applies every metric reported up to the given time to the snapshot.
+// A value reported later for an older fiscal period does not replace the value of a more recent period.
+func (t *metricTimeline) advance(until time.Time) {
+	if t.snapshot == nil {
+		t.snapshot = make(map[uuid.UUID]map[string]store.LatestStockMetric)
+	}
+
+	for ; t.next < len(t.history) && !t.history[t.next].ReportedAt.After(until); t.next++ {
+		h := t.history[t.next]
+
+		stockMetrics, ok := t.snapshot[h.StockID]
+		if !ok {
+			stockMetrics = make(map[string]store.LatestStockMetric)
+			t.snapshot[h.StockID] = stockMetrics
+		}
+
+		m := store.LatestStockMetric{
+			MetricName: h.MetricName,
+			Value:      h.Value,
+			PeriodEnd:  h.PeriodEnd,
+			ReportedAt: h.ReportedAt,
+			CreatedAt:  h.ReportedAt,
+			UpdatedAt:  h.ReportedAt,
+		}
+		if known, ok := stockMetrics[h.MetricName]; ok && effectivePeriod(m).Before(effectivePeriod(known)) {
+			continue
+		}
+		stockMetrics[h.MetricName] = m
+	}
+}
+
+// effectivePeriod returns the fiscal period end of a metric, falling back to the day it was reported.
+func effectivePeriod(m store.LatestStockMetric) time.Time {
+	if m.PeriodEnd != nil {
+		return *m.PeriodEnd
+	}
+
+	return m.ReportedAt.Truncate(24 * time.Hour)
+}
+
+// metrics returns the metrics of a stock known at the current point of the timeline.
+func (t *metricTimeline) metrics(stockID uuid.UUID) []store.LatestStockMetric {
+	stockMetrics := make([]store.LatestStockMetric, 0, len(t.snapshot[stockID]))
+	for _, m := range t.snapshot[stockID] {
+		stockMetrics = append(stockMetrics, m)
+	}
+
+	return stockMetrics
+}
+
+// distributions returns the metric distributions of every sector and industry at the current point of the timeline.
+func (t *metricTimeline) distributions(stocks []store.Stock) map[store.PeerGroup]map[string]map[string][]float64 {
+	distributions := make(map[store.PeerGroup]map[string]map[string][]float64)
+	for _, group := range []store.PeerGroup{store.PeerGroupSector, store.PeerGroupIndustry} {
+		groupDistributions := make(map[string]map[string][]float64)
+		for _, stock := range stocks {
+			name := peerGroupName(&stock, group)
+			if name == "" {
+				continue
+			}
+
+			if groupDistributions[name] == nil {
+				groupDistributions[name] = make(map[string][]float64)
+			}
+			for metric, m := range t.snapshot[stock.ID] {
+				if m.Value != nil {
+					groupDistributions[name][metric] = append(groupDistributions[name][metric], *m.Value)
+				}
+			}
+		}
+		distributions[group] = groupDistributions
+	}
+
+	return distributions
+}
+
+// snapshotPeers returns a peer source reading the stock's peer groups from precomputed distributions.
+func snapshotPeers(
+	stock *store.Stock,
+	distributions map[store.PeerGroup]map[string]map[string][]float64,
+) peerSource {
+	return func(_ context.Context, group store.PeerGroup) (map[string][]float64, error) {
+		return distributions[group][peerGroupName(stock, group)], nil
+	}
+}
+
+// toBars groups the stock prices into closing bars per symbol.
+func toBars(stocks []store.Stock, prices []store.StockPrice) map[string][]backtest.Bar {
+	symbols := make(map[uuid.UUID]string, len(stocks))
+	for _, stock := range stocks {
+		symbols[stock.ID] = stock.Symbol
+	}
+
+	bars := make(map[string][]backtest.Bar)
+	for _, p := range prices {
+		symbol, ok := symbols[p.StockID]
+		if !ok {
+			continue
+		}
+		bars[symbol] = append(bars[symbol], backtest.Bar{Date: p.Date, Close: p.Close})
+	}
+
+	return bars
+}
+
+func toBacktestResp(req backtestReq, res *backtest.Result) backtestResp {
+	periods := make([]backtestPeriodResp, 0, len(res.Periods))
+	for _, p := range res.Periods {
+		periods = append(periods, backtestPeriodResp{
+			Start:           p.Start.Format(time.DateOnly),
+			End:             p.End.Format(time.DateOnly),
+			Holdings:        p.Holdings,
+			Return:          p.Return,
+			BenchmarkReturn: p.BenchmarkReturn,
+			Turnover:        p.Turnover,
+		})
+	}
+
+	return backtestResp{
+		Profile:              req.Profile,
+		Benchmark:            req.Benchmark,
+		TotalReturn:          res.TotalReturn,
+		BenchmarkReturn:      res.BenchmarkReturn,
+		ExcessReturn:         res.TotalReturn - res.BenchmarkReturn,
+		MaxDrawdown:          res.MaxDrawdown,
+		BenchmarkMaxDrawdown: res.BenchmarkMaxDrawdown,
+		HitRate:              res.HitRate,
+		Turnover:             res.Turnover,
+		Periods:              periods,
+	}
+}
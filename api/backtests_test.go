@@ -0,0 +1,160 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestServer_BacktestHandler(t *testing.T) {
+	t.Parallel()
+
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	value := func(v float64) *float64 { return &v }
+
+	goodID, badID := uuid.New(), uuid.New()
+	stocks := []store.Stock{
+		{Model: store.Model{ID: goodID}, Symbol: "GOOD"},
+		{Model: store.Model{ID: badID}, Symbol: "BAD"},
+	}
+	history := []store.HistoricalStockMetric{
+		{StockID: goodID, MetricName: "P/E Ratio", Value: value(8), ReportedAt: day(2023, time.December, 1)},
+		{StockID: badID, MetricName: "P/E Ratio", Value: value(40), ReportedAt: day(2023, time.December, 1)},
+		// Recorded after the first rebalancing, so it is only known from the second one.
+		{StockID: badID, MetricName: "P/E Ratio", Value: value(5), ReportedAt: day(2024, time.January, 15)},
+	}
+	prices := []store.StockPrice{
+		{StockID: goodID, Date: day(2024, time.January, 1), Close: 100},
+		{StockID: goodID, Date: day(2024, time.February, 1), Close: 120},
+		{StockID: goodID, Date: day(2024, time.March, 1), Close: 120},
+		{StockID: badID, Date: day(2024, time.January, 1), Close: 10},
+		{StockID: badID, Date: day(2024, time.February, 1), Close: 10},
+		{StockID: badID, Date: day(2024, time.March, 1), Close: 11},
+	}
+
+	tests := []struct {
+		name string
+
+		sendBody string
+		wantLoad bool
+
+		wantStatus      int
+		wantHoldings    [][]string
+		wantTotalReturn float64
+		wantHitRate     float64
+	}{
+		{
+			name: "replays scoring over history",
+
+			sendBody: `{"start": "2024-01-01", "end": "2024-03-01", "frequency": "monthly"}`,
+			wantLoad: true,
+
+			wantStatus:      http.StatusOK,
+			wantHoldings:    [][]string{{"GOOD"}, {"BAD", "GOOD"}},
+			wantTotalReturn: 1.2*1.05 - 1,
+			wantHitRate:     2.0 / 3,
+		},
+		{
+			name: "handles unknown benchmark",
+
+			sendBody: `{"start": "2024-01-01", "end": "2024-03-01", "benchmark": "SPY"}`,
+			wantLoad: true,
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles invalid dates",
+
+			sendBody: `{"start": "2024-03-01", "end": "2024-01-01"}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles invalid frequency",
+
+			sendBody: `{"start": "2024-01-01", "end": "2024-03-01", "frequency": "daily"}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles unknown scoring profile",
+
+			sendBody: `{"start": "2024-01-01", "end": "2024-03-01", "profile": "unknown"}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.wantLoad {
+				storeMock.On("ListStocks", &store.StockFilter{Limit: 500}).Return(stocks, nil)
+				storeMock.On("FindStockMetricHistory", day(2024, time.January, 1), day(2024, time.March, 1)).Return(history, nil)
+				storeMock.On("ListStockPrices", day(2023, time.December, 25), day(2024, time.March, 1)).Return(prices, nil)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/backtests", bytes.NewBufferString(test.sendBody))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if rr.Code == http.StatusOK {
+				var res struct {
+					TotalReturn float64 `json:"total_return"`
+					HitRate     float64 `json:"hit_rate"`
+					Periods     []struct {
+						Holdings []string `json:"holdings"`
+					} `json:"periods"`
+				}
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&res))
+
+				holdings := make([][]string, 0, len(res.Periods))
+				for _, p := range res.Periods {
+					holdings = append(holdings, p.Holdings)
+				}
+				assert.Equal(t, test.wantHoldings, holdings)
+				assert.InDelta(t, test.wantTotalReturn, res.TotalReturn, 1e-9)
+				assert.InDelta(t, test.wantHitRate, res.HitRate, 1e-9)
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/pkg/symbol"
+	"github.com/huy125/finscope/store"
+)
+
+const (
+	// maxBatchSymbols bounds the stocks analyzed in a request, so that the batch completes within the
+	// server write timeout. Larger batches are queued as analysis jobs.
+	maxBatchSymbols = 10
+	// maxJobSymbols bounds the stocks analyzed in a job.
+	maxJobSymbols = 50
+	// batchWorkers bounds the analyses running concurrently, the provider rate limit spacing out their calls.
+	batchWorkers         = 4
+	batchAnalysisTimeout = 45
+)
+
+type batchAnalysisReq struct {
+	Symbols []string `json:"symbols"`
+	Profile string   `json:"profile"`
+}
+
+type batchAnalysisItemResp struct {
+	Symbol         string              `json:"symbol"`
+	Recommendation *recommendationResp `json:"recommendation,omitempty"`
+	Error          string              `json:"error,omitempty"`
+}
+
+type batchAnalysisResp struct {
+	Results []batchAnalysisItemResp `json:"results"`
+}
+
+// symbols returns the requested symbols without blanks and duplicates, in request order.
+func (r *batchAnalysisReq) symbols() ([]string, error) {
+	symbols, err := uniqueSymbols(r.Symbols)
+	if err != nil {
+		return nil, err
+	}
+	if len(symbols) > maxBatchSymbols {
+		return nil, fmt.Errorf(
+			"at most %d symbols can be analyzed at once, queue larger batches with POST /stocks/analysis/jobs",
+			maxBatchSymbols,
+		)
+	}
+
+	return symbols, nil
+}
+
+// jobSymbols returns the requested symbols of an analysis job without blanks and duplicates, in request order.
+func (r *batchAnalysisReq) jobSymbols() ([]string, error) {
+	symbols, err := uniqueSymbols(r.Symbols)
+	if err != nil {
+		return nil, err
+	}
+	if len(symbols) > maxJobSymbols {
+		return nil, fmt.Errorf("at most %d symbols can be analyzed in a job", maxJobSymbols)
+	}
+
+	return symbols, nil
+}
+
+// uniqueSymbols returns the normalized symbols without blanks and duplicates, in the given order.
+// Invalid symbols are kept as is, to be reported as not found.
+func uniqueSymbols(requested []string) ([]string, error) {
+	seen := make(map[string]bool, len(requested))
+	symbols := make([]string, 0, len(requested))
+	for _, sym := range requested {
+		sym = symbol.Normalize(strings.TrimSpace(sym))
+		if sym == "" || seen[sym] {
+			continue
+		}
+		seen[sym] = true
+		symbols = append(symbols, sym)
+	}
+
+	if len(symbols) == 0 {
+		return nil, errors.New("symbols are required")
+	}
+
+	return symbols, nil
+}
+
+// BatchAnalysisHandler analyzes many stocks concurrently, returning a result or an error per symbol.
+func (s *Server) BatchAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	var req batchAnalysisReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	symbols, err := req.symbols()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := loadScoringConfig(s.filePath)
+	if err != nil {
+		s.log.Error("Failed to load scoring config", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	thresholds, err := cfg.ProfileThresholds(req.Profile)
+	if err != nil {
+		http.Error(w, "Scoring profile is not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), batchAnalysisTimeout*time.Second)
+	defer cancel()
+
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		s.log.Error("Failed to resolve the current user", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]batchAnalysisItemResp, len(symbols))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(batchWorkers, len(symbols)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				results[i] = batchAnalysisItemResp{Symbol: symbols[i]}
+
+				result, err := s.analyzeSymbol(ctx, user, symbols[i], cfg.Rules, thresholds)
+				if err != nil {
+					results[i].Error = s.analysisFailure(symbols[i], err)
+					continue
+				}
+
+				recommendation := toAnalysisRecommendationResp(result)
+				results[i].Recommendation = &recommendation
+			}
+		}()
+	}
+
+	for i := range symbols {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(batchAnalysisResp{Results: results}); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// analyzeSymbol finds and analyzes a single stock of a batch.
+func (s *Server) analyzeSymbol(
+	ctx context.Context,
+	user *store.User,
+	symbol string,
+	rules map[string]Rule,
+	thresholds Thresholds,
+) (*analysisResult, error) {
+	stock, err := s.store.FindStockBySymbol(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("finding stock %s: %w", symbol, err)
+	}
+
+	return s.analyzeStock(ctx, user, stock, rules, thresholds)
+}
+
+// analysisFailure logs the failed analysis of a symbol and returns the error reported to the client.
+func (s *Server) analysisFailure(symbol string, err error) string {
+	if errors.Is(err, store.ErrNotFound) {
+		return "Stock data is not found"
+	}
+
+	s.log.Error("Failed to analyze stock", lctx.Str("symbol", symbol), lctx.Error("error", err))
+	return "Analysis failed"
+}
@@ -0,0 +1,142 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestServer_BatchAnalysisHandler(t *testing.T) {
+	t.Parallel()
+
+	tooMany := make([]string, 11)
+	for i := range tooMany {
+		tooMany[i] = `"S` + strings.Repeat("X", i) + `"`
+	}
+
+	tests := []struct {
+		name string
+
+		sendBody string
+
+		wantFindSymbols []string
+
+		wantStatus int
+		wantResult []byte
+	}{
+		{
+			name: "reports per-symbol errors",
+
+			sendBody: `{"symbols": ["UNKNOWN", " OTHER ", "UNKNOWN", ""]}`,
+
+			wantFindSymbols: []string{"UNKNOWN", "OTHER"},
+
+			wantStatus: http.StatusOK,
+			wantResult: []byte(`
+				{
+					"results": [
+						{"symbol": "UNKNOWN", "error": "Stock data is not found"},
+						{"symbol": "OTHER", "error": "Stock data is not found"}
+					]
+				}`,
+			),
+		},
+		{
+			name: "handles missing symbols",
+
+			sendBody: `{"symbols": []}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles too many symbols",
+
+			sendBody: `{"symbols": [` + strings.Join(tooMany, ",") + `]}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles unknown scoring profile",
+
+			sendBody: `{"symbols": ["AAPL"], "profile": "unknown"}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles bad request error",
+
+			sendBody: "invalid request",
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if len(test.wantFindSymbols) > 0 {
+				storeMock.On("ProvisionUser", mock.Anything).Return(&store.User{Model: store.Model{ID: uuid.New()}}, nil)
+			}
+			for _, symbol := range test.wantFindSymbols {
+				storeMock.On("FindStockBySymbol", symbol).Return(nil, store.ErrNotFound).Once()
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(
+				ctx,
+				http.MethodPost,
+				"/stocks/analysis/batch",
+				bytes.NewBufferString(test.sendBody),
+			)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if rr.Code == http.StatusOK {
+				res, err := io.ReadAll(rr.Body)
+				require.NoError(t, err)
+
+				assert.JSONEq(t, string(test.wantResult), string(res))
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
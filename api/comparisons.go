@@ -0,0 +1,239 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/store"
+)
+
+// maxCompareSymbols bounds the stocks compared at once.
+const maxCompareSymbols = 20
+
+type compareReq struct {
+	Symbols []string `json:"symbols"`
+}
+
+// comparedMetricResp holds the latest value of a metric along with its percentile rank, the percentage
+// of the compared values below it, counting equal values as half.
+type comparedMetricResp struct {
+	Value      float64 `json:"value"`
+	Percentile float64 `json:"percentile"`
+}
+
+type comparedStockResp struct {
+	Symbol   string                        `json:"symbol"`
+	Company  string                        `json:"company"`
+	Sector   string                        `json:"sector,omitempty"`
+	Industry string                        `json:"industry,omitempty"`
+	Score    *float64                      `json:"score,omitempty"`
+	Action   string                        `json:"action,omitempty"`
+	ScoredAt *time.Time                    `json:"scored_at,omitempty"`
+	Metrics  map[string]comparedMetricResp `json:"metrics"`
+}
+
+type compareResp struct {
+	Stocks []comparedStockResp `json:"stocks"`
+}
+
+type peersResp struct {
+	Group     store.PeerGroup     `json:"group"`
+	PeerGroup string              `json:"peer_group"`
+	Stock     comparedStockResp   `json:"stock"`
+	Peers     []comparedStockResp `json:"peers"`
+	Limit     int                 `json:"limit"`
+	Offset    int                 `json:"offset"`
+}
+
+// CompareStocksHandler compares stocks side by side, with their latest score, recommended action and metric
+// values. The percentile ranks of the metrics are relative to the compared stocks.
+func (s *Server) CompareStocksHandler(w http.ResponseWriter, r *http.Request) {
+	var req compareReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	symbols, err := uniqueSymbols(req.Symbols)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch {
+	case len(symbols) < 2:
+		http.Error(w, "at least 2 symbols are required to compare", http.StatusBadRequest)
+		return
+	case len(symbols) > maxCompareSymbols:
+		http.Error(w, fmt.Sprintf("at most %d symbols can be compared at once", maxCompareSymbols), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	snapshots, err := s.store.CompareStocks(ctx, symbols)
+	if err != nil {
+		s.handleStockError(w, err)
+		return
+	}
+
+	bySymbol := make(map[string]store.StockSnapshot, len(snapshots))
+	for _, snapshot := range snapshots {
+		bySymbol[snapshot.Symbol] = snapshot
+	}
+
+	var missing []string
+	for _, sym := range symbols {
+		if _, ok := bySymbol[sym]; !ok {
+			missing = append(missing, sym)
+		}
+	}
+	if len(missing) > 0 {
+		http.Error(w, "Stocks not found: "+strings.Join(missing, ", "), http.StatusNotFound)
+		return
+	}
+
+	distributions := metricValues(snapshots)
+
+	resp := compareResp{Stocks: make([]comparedStockResp, 0, len(symbols))}
+	for _, sym := range symbols {
+		snapshot := bySymbol[sym]
+		resp.Stocks = append(resp.Stocks, toComparedStockResp(&snapshot, distributions))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetStockPeersHandler compares a stock side by side with the active stocks of its industry, or of its sector
+// with group=sector. The peers are ordered by decreasing latest score, the unscored ones last.
+// The percentile ranks of the metrics are relative to the whole peer group, as for percentile scoring rules.
+func (s *Server) GetStockPeersHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	group := store.PeerGroup(q.Get("group"))
+	if group == "" {
+		group = store.PeerGroupIndustry
+	}
+	if group != store.PeerGroupSector && group != store.PeerGroupIndustry {
+		http.Error(w, "Peer group must be sector or industry", http.StatusBadRequest)
+		return
+	}
+
+	limit, offset, err := parsePage(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	stock, err := s.store.FindStockBySymbol(ctx, r.PathValue("symbol"))
+	if err != nil {
+		s.handleStockError(w, err)
+		return
+	}
+
+	peerGroup := stock.Industry
+	if group == store.PeerGroupSector {
+		peerGroup = stock.Sector
+	}
+	if peerGroup == "" {
+		http.Error(w, fmt.Sprintf("Stock %s is unknown", group), http.StatusNotFound)
+		return
+	}
+
+	snapshots, err := s.store.CompareStocks(ctx, []string{stock.Symbol})
+	if err != nil {
+		s.handleStockError(w, err)
+		return
+	}
+	if len(snapshots) == 0 {
+		s.handleStockError(w, store.ErrNotFound)
+		return
+	}
+
+	peers, err := s.store.ListPeers(ctx, &store.PeerFilter{
+		Group:   group,
+		Name:    peerGroup,
+		StockID: stock.ID,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		s.handleStockError(w, err)
+		return
+	}
+
+	metricDistributions, err := s.store.FindMetricDistributions(ctx, group, peerGroup, time.Now())
+	if err != nil {
+		s.log.Error("Failed to find metric distributions", lctx.Str("symbol", stock.Symbol), lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	distributions := make(map[string][]float64, len(metricDistributions))
+	for _, d := range metricDistributions {
+		distributions[d.MetricName] = d.Values
+	}
+
+	resp := peersResp{
+		Group:     group,
+		PeerGroup: peerGroup,
+		Stock:     toComparedStockResp(&snapshots[0], distributions),
+		Peers:     make([]comparedStockResp, 0, len(peers)),
+		Limit:     limit,
+		Offset:    offset,
+	}
+	for _, peer := range peers {
+		resp.Peers = append(resp.Peers, toComparedStockResp(&peer, distributions))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// metricValues returns the metric values of the snapshots, by metric name.
+func metricValues(snapshots []store.StockSnapshot) map[string][]float64 {
+	values := map[string][]float64{}
+	for _, snapshot := range snapshots {
+		for name, value := range snapshot.Metrics {
+			values[name] = append(values[name], value)
+		}
+	}
+
+	return values
+}
+
+func toComparedStockResp(snapshot *store.StockSnapshot, distributions map[string][]float64) comparedStockResp {
+	resp := comparedStockResp{
+		Symbol:   snapshot.Symbol,
+		Company:  snapshot.Company,
+		Sector:   snapshot.Sector,
+		Industry: snapshot.Industry,
+		Score:    snapshot.Score,
+		Action:   string(snapshot.Action),
+		ScoredAt: snapshot.ScoredAt,
+		Metrics:  make(map[string]comparedMetricResp, len(snapshot.Metrics)),
+	}
+	for name, value := range snapshot.Metrics {
+		resp.Metrics[name] = comparedMetricResp{
+			Value:      value,
+			Percentile: percentileRank(value, distributions[name]),
+		}
+	}
+
+	return resp
+}
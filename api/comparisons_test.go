@@ -0,0 +1,312 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestServer_CompareStocksHandler(t *testing.T) {
+	t.Parallel()
+
+	scoredAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	score := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name string
+
+		body  string
+		setup func(m *storeMock)
+
+		wantStatus int
+		wantResult string
+	}{
+		{
+			name: "compares stocks in request order",
+
+			body: `{"symbols": ["msft", "AAPL", "MSFT"]}`,
+			setup: func(m *storeMock) {
+				m.On("CompareStocks", []string{"MSFT", "AAPL"}).Return([]store.StockSnapshot{
+					{
+						Stock:    store.Stock{Symbol: "AAPL", Company: "Apple Inc", Sector: "Technology"},
+						Score:    score(7.5),
+						ScoredAt: &scoredAt,
+						Action:   store.ActionBuy,
+						Metrics:  map[string]float64{"pe": 30, "roe": 1.5},
+					},
+					{
+						Stock:   store.Stock{Symbol: "MSFT", Company: "Microsoft Corp", Sector: "Technology"},
+						Metrics: map[string]float64{"pe": 35},
+					},
+				}, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: `{
+				"stocks": [
+					{
+						"symbol": "MSFT",
+						"company": "Microsoft Corp",
+						"sector": "Technology",
+						"metrics": {"pe": {"value": 35, "percentile": 75}}
+					},
+					{
+						"symbol": "AAPL",
+						"company": "Apple Inc",
+						"sector": "Technology",
+						"score": 7.5,
+						"action": "ActionBuy",
+						"scored_at": "2024-03-01T10:00:00Z",
+						"metrics": {
+							"pe": {"value": 30, "percentile": 25},
+							"roe": {"value": 1.5, "percentile": 50}
+						}
+					}
+				]
+			}`,
+		},
+		{
+			name: "handles unknown symbols",
+
+			body: `{"symbols": ["AAPL", "NOPE"]}`,
+			setup: func(m *storeMock) {
+				m.On("CompareStocks", []string{"AAPL", "NOPE"}).Return([]store.StockSnapshot{
+					{Stock: store.Stock{Symbol: "AAPL"}},
+				}, nil)
+			},
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "handles a single symbol",
+
+			body: `{"symbols": ["AAPL", "aapl"]}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles invalid payload",
+
+			body: `{"symbols": "AAPL"}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles store error",
+
+			body: `{"symbols": ["AAPL", "MSFT"]}`,
+			setup: func(m *storeMock) {
+				m.On("CompareStocks", []string{"AAPL", "MSFT"}).
+					Return([]store.StockSnapshot(nil), errors.New("test error"))
+			},
+
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.setup != nil {
+				test.setup(storeMock)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/compare", strings.NewReader(test.body))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if test.wantResult != "" {
+				assert.JSONEq(t, test.wantResult, rr.Body.String())
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_GetStockPeersHandler(t *testing.T) {
+	t.Parallel()
+
+	score := func(v float64) *float64 { return &v }
+	msft := store.Stock{
+		Model:    store.Model{ID: uuid.UUID{1}},
+		Symbol:   "MSFT",
+		Company:  "Microsoft Corp",
+		Sector:   "Technology",
+		Industry: "Software",
+	}
+
+	tests := []struct {
+		name string
+
+		path  string
+		setup func(m *storeMock)
+
+		wantStatus int
+		wantResult string
+	}{
+		{
+			name: "compares a stock with its industry peers",
+
+			path: "/stocks/MSFT/peers?limit=1&offset=1",
+			setup: func(m *storeMock) {
+				m.On("FindStockBySymbol", "MSFT").Return(&msft, nil)
+				m.On("CompareStocks", []string{"MSFT"}).Return([]store.StockSnapshot{
+					{Stock: msft, Score: score(7), Action: store.ActionBuy, Metrics: map[string]float64{"pe": 35}},
+				}, nil)
+				m.On("ListPeers", &store.PeerFilter{
+					Group:   store.PeerGroupIndustry,
+					Name:    "Software",
+					StockID: msft.ID,
+					Limit:   1,
+					Offset:  1,
+				}).Return([]store.StockSnapshot{
+					{Stock: store.Stock{Model: store.Model{ID: uuid.UUID{4}}, Symbol: "CRM"}, Score: score(5)},
+				}, nil)
+				m.On("FindMetricDistributions", store.PeerGroupIndustry, "Software", mock.Anything).
+					Return([]store.MetricDistribution{{MetricName: "pe", Values: []float64{20, 35}}}, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: `{
+				"group": "industry",
+				"peer_group": "Software",
+				"stock": {
+					"symbol": "MSFT",
+					"company": "Microsoft Corp",
+					"sector": "Technology",
+					"industry": "Software",
+					"score": 7,
+					"action": "ActionBuy",
+					"metrics": {"pe": {"value": 35, "percentile": 75}}
+				},
+				"peers": [
+					{"symbol": "CRM", "company": "", "score": 5, "metrics": {}}
+				],
+				"limit": 1,
+				"offset": 1
+			}`,
+		},
+		{
+			name: "handles store error",
+
+			path: "/stocks/MSFT/peers?group=sector",
+			setup: func(m *storeMock) {
+				m.On("FindStockBySymbol", "MSFT").Return(&msft, nil)
+				m.On("CompareStocks", []string{"MSFT"}).Return([]store.StockSnapshot{{Stock: msft}}, nil)
+				m.On("ListPeers", mock.Anything).Return([]store.StockSnapshot{}, errors.New("test error"))
+			},
+
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "handles stock without sector",
+
+			path: "/stocks/AAPL/peers?group=sector",
+			setup: func(m *storeMock) {
+				m.On("FindStockBySymbol", "AAPL").Return(&store.Stock{Symbol: "AAPL"}, nil)
+			},
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "handles unknown stock",
+
+			path: "/stocks/NOPE/peers",
+			setup: func(m *storeMock) {
+				m.On("FindStockBySymbol", "NOPE").Return(nil, store.ErrNotFound)
+			},
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "handles invalid group",
+
+			path: "/stocks/MSFT/peers?group=exchange",
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.setup != nil {
+				test.setup(storeMock)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, test.path, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if test.wantResult != "" {
+				assert.JSONEq(t, test.wantResult, rr.Body.String())
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// freshMetricAge is the age up to which a metric value is considered fully fresh.
+	freshMetricAge = 24 * time.Hour
+	// staleMetricAge is the age from which a metric value no longer contributes to freshness.
+	staleMetricAge = 90 * 24 * time.Hour
+)
+
+// confidence represents the components of the confidence level of a recommendation, each between 0 and 1.
+//
+// Completeness is the share of the rule weights backed by an actual metric value.
+// Freshness is the weighted freshness of the metric values, decaying linearly from one day to 90 days old.
+// Agreement measures how much the metric sub-scores point in the same direction.
+// Margin measures how far the score is from the nearest action threshold.
+type confidence struct {
+	Completeness float64
+	Freshness    float64
+	Agreement    float64
+	Margin       float64
+}
+
+type confidenceResp struct {
+	Completeness float64 `json:"completeness"`
+	Freshness    float64 `json:"freshness"`
+	Agreement    float64 `json:"agreement"`
+	Margin       float64 `json:"margin"`
+}
+
+// Level returns the confidence level as a percentage.
+// Completeness and freshness scale the quality of the evidence,
+// while agreement and margin measure how decisive the score is.
+func (c confidence) Level() float64 {
+	const maxPercentage = 100
+
+	return maxPercentage * c.Completeness * c.Freshness * (c.Agreement + c.Margin) / 2
+}
+
+// calculateConfidence computes the confidence components of a scorecard.
+func calculateConfidence(card *scorecard, thresholds Thresholds, now time.Time) confidence {
+	return confidence{
+		Completeness: card.Completeness(),
+		Freshness:    freshness(card.scores, now),
+		Agreement:    agreement(card.scores),
+		Margin:       margin(card.Score(), thresholds),
+	}
+}
+
+// freshness returns the weighted average freshness of the metric values.
+func freshness(scores []ruleScore, now time.Time) float64 {
+	var sum, weights float64
+	for _, s := range scores {
+		age := now.Sub(s.recordedAt)
+
+		f := 1.0
+		if age > freshMetricAge {
+			f = 1 - float64(age-freshMetricAge)/float64(staleMetricAge-freshMetricAge)
+		}
+
+		sum += max(f, 0) * s.weight
+		weights += s.weight
+	}
+
+	if weights <= 0 {
+		return 0
+	}
+
+	return sum / weights
+}
+
+// agreement returns one minus the normalized weighted standard deviation of the sub-scores.
+// As the sub-scores lie between 0 and 1, their standard deviation is at most 0.5.
+func agreement(scores []ruleScore) float64 {
+	var mean, weights float64
+	for _, s := range scores {
+		mean += s.normalized * s.weight
+		weights += s.weight
+	}
+
+	if weights <= 0 {
+		return 0
+	}
+	mean /= weights
+
+	var variance float64
+	for _, s := range scores {
+		variance += (s.normalized - mean) * (s.normalized - mean) * s.weight
+	}
+	variance /= weights
+
+	const maxStdDeviation = 0.5
+
+	return max(1-math.Sqrt(variance)/maxStdDeviation, 0)
+}
+
+// margin returns the distance of the score from the nearest threshold,
+// relative to half of the narrowest action band.
+func margin(score float64, t Thresholds) float64 {
+	bounds := []float64{t.StrongBuy, t.Buy, t.Hold, t.Sell}
+
+	distance := math.Inf(1)
+	narrowest := math.Inf(1)
+	for i, b := range bounds {
+		distance = min(distance, math.Abs(score-b))
+		if i > 0 {
+			narrowest = min(narrowest, bounds[i-1]-b)
+		}
+	}
+
+	return min(distance/(narrowest/2), 1)
+}
+
+func toConfidenceResp(c confidence) confidenceResp {
+	return confidenceResp{
+		Completeness: c.Completeness,
+		Freshness:    c.Freshness,
+		Agreement:    c.Agreement,
+		Margin:       c.Margin,
+	}
+}
@@ -0,0 +1,280 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfidence_Level(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+
+		confidence confidence
+
+		want float64
+	}{
+		{
+			name: "full confidence",
+
+			confidence: confidence{Completeness: 1, Freshness: 1, Agreement: 1, Margin: 1},
+
+			want: 100,
+		},
+		{
+			name: "scales decisiveness by evidence quality",
+
+			confidence: confidence{Completeness: 0.5, Freshness: 0.5, Agreement: 1, Margin: 1},
+
+			want: 25,
+		},
+		{
+			name: "averages agreement and margin",
+
+			confidence: confidence{Completeness: 1, Freshness: 1, Agreement: 1, Margin: 0},
+
+			want: 50,
+		},
+		{
+			name: "no confidence without evidence",
+
+			confidence: confidence{Completeness: 0, Freshness: 1, Agreement: 1, Margin: 1},
+
+			want: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.InDelta(t, test.want, test.confidence.Level(), 1e-9)
+		})
+	}
+}
+
+func TestFreshness(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name string
+
+		scores []ruleScore
+
+		want float64
+	}{
+		{
+			name: "fresh value",
+
+			scores: []ruleScore{{weight: 1, recordedAt: now}},
+
+			want: 1,
+		},
+		{
+			name: "value one day old",
+
+			scores: []ruleScore{{weight: 1, recordedAt: now.Add(-day)}},
+
+			want: 1,
+		},
+		{
+			name: "value halfway to stale",
+
+			scores: []ruleScore{{weight: 1, recordedAt: now.Add(-day - 89*day/2)}},
+
+			want: 0.5,
+		},
+		{
+			name: "value 90 days old",
+
+			scores: []ruleScore{{weight: 1, recordedAt: now.Add(-90 * day)}},
+
+			want: 0,
+		},
+		{
+			name: "value older than 90 days",
+
+			scores: []ruleScore{{weight: 1, recordedAt: now.Add(-365 * day)}},
+
+			want: 0,
+		},
+		{
+			name: "weights values",
+
+			scores: []ruleScore{
+				{weight: 0.75, recordedAt: now},
+				{weight: 0.25, recordedAt: now.Add(-90 * day)},
+			},
+
+			want: 0.75,
+		},
+		{
+			name: "no values",
+
+			want: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.InDelta(t, test.want, freshness(test.scores, now), 1e-9)
+		})
+	}
+}
+
+func TestAgreement(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+
+		scores []ruleScore
+
+		want float64
+	}{
+		{
+			name: "equal sub-scores",
+
+			scores: []ruleScore{{weight: 0.5, normalized: 0.7}, {weight: 0.5, normalized: 0.7}},
+
+			want: 1,
+		},
+		{
+			name: "opposite sub-scores",
+
+			scores: []ruleScore{{weight: 0.5, normalized: 0}, {weight: 0.5, normalized: 1}},
+
+			want: 0,
+		},
+		{
+			name: "diverging sub-scores",
+
+			scores: []ruleScore{{weight: 0.5, normalized: 0.5}, {weight: 0.5, normalized: 1}},
+
+			want: 0.5,
+		},
+		{
+			name: "single sub-score",
+
+			scores: []ruleScore{{weight: 1, normalized: 0.3}},
+
+			want: 1,
+		},
+		{
+			name: "no sub-scores",
+
+			want: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.InDelta(t, test.want, agreement(test.scores), 1e-9)
+		})
+	}
+}
+
+func TestMargin(t *testing.T) {
+	t.Parallel()
+
+	thresholds := Thresholds{StrongBuy: 8, Buy: 6, Hold: 4, Sell: 2}
+
+	tests := []struct {
+		name string
+
+		score      float64
+		thresholds Thresholds
+
+		want float64
+	}{
+		{
+			name: "score on a threshold",
+
+			score:      6,
+			thresholds: thresholds,
+
+			want: 0,
+		},
+		{
+			name: "score just above a threshold",
+
+			score:      6.5,
+			thresholds: thresholds,
+
+			want: 0.5,
+		},
+		{
+			name: "score in the middle of a band",
+
+			score:      7,
+			thresholds: thresholds,
+
+			want: 1,
+		},
+		{
+			name: "score far above the strong buy threshold",
+
+			score:      10,
+			thresholds: thresholds,
+
+			want: 1,
+		},
+		{
+			name: "score below the sell threshold",
+
+			score:      1.5,
+			thresholds: thresholds,
+
+			want: 0.5,
+		},
+		{
+			name: "relative to the narrowest band",
+
+			score:      7.875,
+			thresholds: Thresholds{StrongBuy: 9, Buy: 7.5, Hold: 5, Sell: 3},
+
+			want: 0.5,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.InDelta(t, test.want, margin(test.score, test.thresholds), 1e-9)
+		})
+	}
+}
+
+func TestCalculateConfidence(t *testing.T) {
+	t.Parallel()
+
+	value := func(v float64) *float64 { return &v }
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	rule := Rule{
+		ScoringRanges: []ScoringRange{{Min: value(5), Score: 10}, {Max: value(5), Score: 5}},
+		Weight:        0.5,
+	}
+
+	card := &scorecard{}
+	card.add("EPS", rule, applyFactors(6, rule), now)
+	card.add("P/E Ratio", rule, applyFactors(1, rule), now.Add(-90*24*time.Hour))
+
+	got := calculateConfidence(card, Thresholds{StrongBuy: 8, Buy: 6, Hold: 4, Sell: 2}, now)
+
+	assert.InDelta(t, 1, got.Completeness, 1e-9)
+	assert.InDelta(t, 0.5, got.Freshness, 1e-9)
+	assert.InDelta(t, 0.5, got.Agreement, 1e-9)
+	assert.InDelta(t, 0.5, got.Margin, 1e-9)
+	assert.InDelta(t, 25, got.Level(), 1e-9)
+}
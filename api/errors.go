@@ -6,3 +6,9 @@ import (
 
 // ErrNotFound represents a not found error in the API.
 var ErrNotFound = errors.New("not found")
+
+// ErrProfileNotFound represents an unknown scoring profile.
+var ErrProfileNotFound = errors.New("scoring profile not found")
+
+// ErrMissingClaims represents a request without authenticated user claims.
+var ErrMissingClaims = errors.New("missing user claims")
@@ -23,10 +23,12 @@ type Authenticator interface {
 
 // Claims represents the user profile claims from the ID token.
 type Claims struct {
-	Email   string `json:"email"`
-	Name    string `json:"name"`
-	Picture string `json:"picture"`
-	Subject string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+	Issuer        string `json:"iss"`
+	Subject       string `json:"sub"`
 }
 
 // RequireAuth is a helper function to protect individual routes.
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/store"
+)
+
+// recordStockPrices saves the daily price bars of a known stock.
+// Failures are logged as the prices are only kept for historical analyses.
+func (s *Server) recordStockPrices(ctx context.Context, symbol string, data *TimeSeriesDaily) {
+	stock, err := s.store.FindStockBySymbol(ctx, symbol)
+	if err != nil {
+		s.log.Debug("skipping price recording", lctx.Str("symbol", symbol), lctx.Error("error", err))
+		return
+	}
+
+	prices, err := toStockPrices(stock.ID, data)
+	if err != nil {
+		s.log.Error("failed to parse stock prices", lctx.Str("symbol", symbol), lctx.Error("error", err))
+		return
+	}
+
+	if err = s.store.SaveStockPrices(ctx, prices); err != nil {
+		s.log.Error("failed to save stock prices", lctx.Str("symbol", symbol), lctx.Error("error", err))
+	}
+}
+
+// toStockPrices converts a daily time series into stock price bars.
+func toStockPrices(stockID uuid.UUID, data *TimeSeriesDaily) ([]store.StockPrice, error) {
+	prices := make([]store.StockPrice, 0, len(data.TimeSeries))
+	for day, bar := range data.TimeSeries {
+		date, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			return nil, fmt.Errorf("parsing date %q: %w", day, err)
+		}
+
+		price := store.StockPrice{StockID: stockID, Date: date}
+		fields := []struct {
+			key string
+			dst *float64
+		}{
+			{key: "1. open", dst: &price.Open},
+			{key: "2. high", dst: &price.High},
+			{key: "3. low", dst: &price.Low},
+			{key: "4. close", dst: &price.Close},
+		}
+		for _, f := range fields {
+			if *f.dst, err = strconv.ParseFloat(bar[f.key], 64); err != nil {
+				return nil, fmt.Errorf("parsing %s on %s: %w", f.key, day, err)
+			}
+		}
+
+		if price.Volume, err = strconv.ParseInt(bar["5. volume"], 10, 64); err != nil {
+			return nil, fmt.Errorf("parsing volume on %s: %w", day, err)
+		}
+
+		prices = append(prices, price)
+	}
+
+	return prices, nil
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/store"
+)
+
+type rankingResp struct {
+	Rank     int       `json:"rank"`
+	Symbol   string    `json:"symbol"`
+	Company  string    `json:"company"`
+	Sector   string    `json:"sector,omitempty"`
+	Industry string    `json:"industry,omitempty"`
+	Score    float64   `json:"score"`
+	Action   string    `json:"action"`
+	ScoredAt time.Time `json:"scored_at"`
+	// The score deltas are left out for the stocks not yet analyzed a week or a month ago.
+	WeekDelta  *float64 `json:"delta_1w,omitempty"`
+	MonthDelta *float64 `json:"delta_1m,omitempty"`
+}
+
+type rankingsResp struct {
+	Rankings []rankingResp `json:"rankings"`
+	Profile  string        `json:"profile,omitempty"`
+	Limit    int           `json:"limit"`
+	Offset   int           `json:"offset"`
+}
+
+// ListRankingsHandler ranks the active stocks by their latest analysis score, the highest first, optionally
+// within a sector, along with their score deltas versus one week and one month ago.
+// The score does not depend on the scoring profile, whose thresholds set the recommended action of each stock.
+func (s *Server) ListRankingsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit, offset, err := parsePage(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := loadScoringConfig(s.filePath)
+	if err != nil {
+		s.log.Error("Failed to load scoring config", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	profile := q.Get("profile")
+	thresholds, err := cfg.ProfileThresholds(profile)
+	if err != nil {
+		http.Error(w, "Scoring profile is not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	rankings, err := s.store.ListRankings(ctx, &store.RankingFilter{
+		Sector: strings.TrimSpace(q.Get("sector")),
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		s.handleRankingError(w, err)
+		return
+	}
+
+	resp := rankingsResp{
+		Rankings: make([]rankingResp, 0, len(rankings)),
+		Profile:  profile,
+		Limit:    limit,
+		Offset:   offset,
+	}
+	for _, ranking := range rankings {
+		resp.Rankings = append(resp.Rankings, rankingResp{
+			Rank:       ranking.Rank,
+			Symbol:     ranking.Symbol,
+			Company:    ranking.Company,
+			Sector:     ranking.Sector,
+			Industry:   ranking.Industry,
+			Score:      ranking.Score,
+			Action:     string(thresholds.action(ranking.Score)),
+			ScoredAt:   ranking.ScoredAt,
+			WeekDelta:  scoreDelta(ranking.Score, ranking.WeekAgoScore),
+			MonthDelta: scoreDelta(ranking.Score, ranking.MonthAgoScore),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// scoreDelta returns the change of a score since a past score, nil if there is no past score.
+func scoreDelta(score float64, past *float64) *float64 {
+	if past == nil {
+		return nil
+	}
+
+	delta := score - *past
+	return &delta
+}
+
+func (s *Server) handleRankingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.As(err, &store.ValidationError{}):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		s.log.Error("Failed to rank stocks", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,183 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestServer_ListRankingsHandler(t *testing.T) {
+	t.Parallel()
+
+	scoredAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	pastScore := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name string
+
+		query string
+		setup func(m *storeMock)
+
+		wantStatus int
+		wantResult string
+	}{
+		{
+			name: "ranks stocks with profile actions and deltas",
+
+			query: "?sector=Technology&profile=conservative&limit=2&offset=2",
+			setup: func(m *storeMock) {
+				m.On("ListRankings", &store.RankingFilter{Sector: "Technology", Limit: 2, Offset: 2}).
+					Return([]store.StockRanking{
+						{
+							Stock:         store.Stock{Symbol: "MSFT", Company: "Microsoft Corp", Sector: "Technology"},
+							Rank:          3,
+							Score:         7.25,
+							ScoredAt:      scoredAt,
+							WeekAgoScore:  pastScore(6.75),
+							MonthAgoScore: pastScore(7.5),
+						},
+						{
+							Stock:    store.Stock{Symbol: "ORCL", Company: "Oracle Corp", Sector: "Technology"},
+							Rank:     4,
+							Score:    4.5,
+							ScoredAt: scoredAt,
+						},
+					}, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: `{
+				"rankings": [
+					{
+						"rank": 3,
+						"symbol": "MSFT",
+						"company": "Microsoft Corp",
+						"sector": "Technology",
+						"score": 7.25,
+						"action": "ActionHold",
+						"scored_at": "2024-03-01T10:00:00Z",
+						"delta_1w": 0.5,
+						"delta_1m": -0.25
+					},
+					{
+						"rank": 4,
+						"symbol": "ORCL",
+						"company": "Oracle Corp",
+						"sector": "Technology",
+						"score": 4.5,
+						"action": "ActionSell",
+						"scored_at": "2024-03-01T10:00:00Z"
+					}
+				],
+				"profile": "conservative",
+				"limit": 2,
+				"offset": 2
+			}`,
+		},
+		{
+			name: "ranks stocks with default thresholds",
+
+			query: "",
+			setup: func(m *storeMock) {
+				m.On("ListRankings", &store.RankingFilter{Limit: 20}).Return([]store.StockRanking{
+					{Stock: store.Stock{Symbol: "MSFT", Company: "Microsoft Corp"}, Rank: 1, Score: 7.25, ScoredAt: scoredAt},
+				}, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: `{
+				"rankings": [
+					{
+						"rank": 1,
+						"symbol": "MSFT",
+						"company": "Microsoft Corp",
+						"score": 7.25,
+						"action": "ActionBuy",
+						"scored_at": "2024-03-01T10:00:00Z"
+					}
+				],
+				"limit": 20,
+				"offset": 0
+			}`,
+		},
+		{
+			name: "handles unknown profile",
+
+			query: "?profile=unknown",
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles invalid limit",
+
+			query: "?limit=0",
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles store error",
+
+			query: "",
+			setup: func(m *storeMock) {
+				m.On("ListRankings", &store.RankingFilter{Limit: 20}).
+					Return([]store.StockRanking(nil), errors.New("test error"))
+			},
+
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.setup != nil {
+				test.setup(storeMock)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/rankings"+test.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if test.wantResult != "" {
+				assert.JSONEq(t, test.wantResult, rr.Body.String())
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/store"
+)
+
+const (
+	// refreshJobType is the queue job type refreshing the stock universe.
+	refreshJobType = "refresh"
+	// refreshTimeout bounds an attempt of a refresh, the provider rate limit spacing out its calls.
+	// A timed out refresh is resumed right away by a new attempt.
+	refreshTimeout = 4 * time.Hour
+)
+
+// handleRefreshJob runs the scheduled refresh of a queue job.
+// The run is failed once the queue job has no attempts left.
+func (s *Server) handleRefreshJob(ctx context.Context, job *store.Job) error {
+	var payload store.ScheduledRunPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("decoding payload: %w", err)
+	}
+
+	run, err := s.store.StartScheduledRun(ctx, payload.RunID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			// The run already finished.
+			return nil
+		}
+
+		return fmt.Errorf("starting scheduled run: %w", err)
+	}
+
+	err = s.refreshStocks(ctx, run)
+	if err != nil && ctx.Err() == nil && job.Attempts >= job.MaxAttempts {
+		s.finishScheduledRun(ctx, run.ID, store.JobStatusFailed, "Refresh failed")
+	}
+
+	return err
+}
+
+// refreshStocks refreshes the prices and the metrics of every active stock and records a fresh analysis
+// with the default scoring profile. The stocks are refreshed in symbol order, recording the progress
+// after each stock so that an interrupted run resumes after the last refreshed one.
+// A returned error leaves the run running, to be resumed by the next attempt. A run timing out or
+// exhausting the daily provider budget is left partial, resumed by a job queued for later.
+func (s *Server) refreshStocks(workerCtx context.Context, run *store.ScheduledRun) error {
+	ctx, cancel := context.WithTimeout(workerCtx, refreshTimeout)
+	defer cancel()
+
+	log := s.log.With(lctx.Str("run", run.ID.String()))
+
+	cfg, err := loadScoringConfig(s.filePath)
+	if err != nil {
+		log.Error("Failed to load scoring config", lctx.Error("error", err))
+		s.finishScheduledRun(ctx, run.ID, store.JobStatusFailed, "Scoring config could not be loaded")
+		return nil
+	}
+
+	thresholds, err := cfg.ProfileThresholds("")
+	if err != nil {
+		s.finishScheduledRun(ctx, run.ID, store.JobStatusFailed, "Scoring profile is not found")
+		return nil
+	}
+
+	active := true
+	stocks, err := s.listAllStocks(ctx, store.StockFilter{Active: &active, After: run.LastSymbol})
+	if err != nil {
+		return fmt.Errorf("listing stocks: %w", err)
+	}
+
+	succeeded, failed := run.Succeeded, run.Failed
+	total := succeeded + failed + len(stocks)
+	for _, stock := range stocks {
+		switch {
+		case workerCtx.Err() != nil:
+			return workerCtx.Err()
+		case ctx.Err() != nil:
+			return s.pauseScheduledRun(ctx, run, "Refresh timed out", time.Now())
+		}
+
+		if err = s.refreshStock(ctx, &stock, cfg.Rules, thresholds); err != nil {
+			switch {
+			case workerCtx.Err() != nil:
+				return workerCtx.Err()
+			case ctx.Err() != nil:
+				return s.pauseScheduledRun(ctx, run, "Refresh timed out", time.Now())
+			case errors.Is(err, store.ErrQuotaExceeded):
+				// The provider budget is counted per UTC day.
+				resumeAt := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+				return s.pauseScheduledRun(ctx, run, "Daily provider budget exhausted", resumeAt)
+			}
+
+			log.Error("Failed to refresh stock", lctx.Str("symbol", stock.Symbol), lctx.Error("error", err))
+			failed++
+		} else {
+			succeeded++
+		}
+
+		if err = s.store.UpdateScheduledRunProgress(ctx, run.ID, total, succeeded, failed, stock.Symbol); err != nil {
+			return fmt.Errorf("recording progress: %w", err)
+		}
+	}
+
+	// The saved screens are updated before the run finishes, so that an interrupted update is resumed with the run.
+	if succeeded > 0 {
+		if err = s.updateSavedScreens(ctx, run.ID); err != nil {
+			return fmt.Errorf("updating saved screens: %w", err)
+		}
+	}
+
+	status, msg := store.JobStatusSucceeded, ""
+	switch {
+	case failed > 0 && succeeded == 0:
+		status, msg = store.JobStatusFailed, "No stock could be refreshed"
+	case failed > 0:
+		msg = fmt.Sprintf("%d of %d stocks could not be refreshed", failed, total)
+	}
+
+	if err = s.store.FinishScheduledRun(ctx, run.ID, status, msg); err != nil {
+		return fmt.Errorf("finishing scheduled run: %w", err)
+	}
+
+	return nil
+}
+
+// refreshStock records the latest daily prices of a stock, then analyzes it on behalf of no user.
+func (s *Server) refreshStock(
+	ctx context.Context,
+	stock *store.Stock,
+	rules map[string]Rule,
+	thresholds Thresholds,
+) error {
+	prices, err := s.fetchStockData(ctx, stock.Symbol)
+	if err != nil {
+		return fmt.Errorf("fetching prices: %w", err)
+	}
+	s.recordStockPrices(ctx, stock.Symbol, prices)
+
+	_, err = s.analyzeStock(ctx, nil, stock, rules, thresholds)
+	return err
+}
+
+// pauseScheduledRun leaves a scheduled run partial, to be resumed at the given time, even if the context is done.
+func (s *Server) pauseScheduledRun(ctx context.Context, run *store.ScheduledRun, msg string, resumeAt time.Time) error {
+	pauseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), requestTimeout*time.Second)
+	defer cancel()
+
+	if err := s.store.PauseScheduledRun(pauseCtx, run, msg, resumeAt); err != nil {
+		return fmt.Errorf("pausing scheduled run: %w", err)
+	}
+
+	return nil
+}
+
+// finishScheduledRun marks a scheduled run as finished, even if the context is done.
+func (s *Server) finishScheduledRun(ctx context.Context, id uuid.UUID, status store.JobStatus, msg string) {
+	finishCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), requestTimeout*time.Second)
+	defer cancel()
+
+	if err := s.store.FinishScheduledRun(finishCtx, id, status, msg); err != nil {
+		s.log.Error("Failed to finish scheduled run", lctx.Str("run", id.String()), lctx.Error("error", err))
+	}
+}
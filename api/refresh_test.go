@@ -0,0 +1,201 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RefreshJobHandler(t *testing.T) {
+	t.Parallel()
+
+	runID := uuid.New()
+	active := true
+	payload := []byte(`{"runId": "` + runID.String() + `"}`)
+	stocks := []store.Stock{
+		{Model: store.Model{ID: uuid.New()}, Symbol: "AAPL"},
+		{Model: store.Model{ID: uuid.New()}, Symbol: "MSFT"},
+	}
+
+	tests := []struct {
+		name string
+
+		opts            []api.Option
+		attempts        int
+		returnRun       *store.ScheduledRun
+		returnStartErr  error
+		returnStocks    []store.Stock
+		returnStocksErr error
+		setup           func(m *storeMock)
+
+		wantFinishStatus store.JobStatus
+		wantFinishErr    string
+
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "finishes resumed run",
+
+			attempts: 2,
+			returnRun: &store.ScheduledRun{
+				Model:      store.Model{ID: runID},
+				Total:      2,
+				Succeeded:  1,
+				Failed:     1,
+				LastSymbol: "MSFT",
+			},
+			returnStocks: []store.Stock{},
+			setup: func(m *storeMock) {
+				screenID := uuid.New()
+				m.On("ListSavedScreens", uuid.Nil).Return([]store.SavedScreen{
+					{Model: store.Model{ID: screenID}, Query: "min_score=60"},
+				}, nil)
+				m.On("ListMetrics", 50, 0).Return([]store.Metric{}, nil)
+				m.On("ScreenStocks", mock.Anything).Return([]store.ScreenedStock{{Stock: stocks[0]}}, nil)
+				m.On("UpdateSavedScreenStocks", screenID, &runID, []store.Stock{stocks[0]}).Return(&store.ScreenChange{
+					ScreenID: screenID,
+					Entered:  []string{"AAPL"},
+				}, nil)
+			},
+
+			wantFinishStatus: store.JobStatusSucceeded,
+			wantFinishErr:    "1 of 2 stocks could not be refreshed",
+
+			wantErr: require.NoError,
+		},
+		{
+			name: "leaves run running when saved screens cannot be updated",
+
+			attempts:     1,
+			returnRun:    &store.ScheduledRun{Model: store.Model{ID: runID}, Total: 1, Succeeded: 1, LastSymbol: "AAPL"},
+			returnStocks: []store.Stock{},
+			setup: func(m *storeMock) {
+				m.On("ListSavedScreens", uuid.Nil).Return([]store.SavedScreen(nil), errors.New("test error"))
+			},
+
+			wantErr: require.Error,
+		},
+		{
+			name: "finishes run of empty universe",
+
+			attempts:     1,
+			returnRun:    &store.ScheduledRun{Model: store.Model{ID: runID}},
+			returnStocks: []store.Stock{},
+
+			wantFinishStatus: store.JobStatusSucceeded,
+
+			wantErr: require.NoError,
+		},
+		{
+			name: "leaves run partial when the daily provider budget is exhausted",
+
+			opts:         []api.Option{api.WithProviderDailyLimit(25)},
+			attempts:     1,
+			returnRun:    &store.ScheduledRun{Model: store.Model{ID: runID}, JobType: "refresh"},
+			returnStocks: stocks,
+			setup: func(m *storeMock) {
+				m.On("ReserveProviderCall", &store.ReserveProviderCall{
+					Provider:   "alphavantage",
+					DailyLimit: 25,
+					Wait:       true,
+				}).Return(time.Time{}, store.ErrQuotaExceeded)
+				m.On("PauseScheduledRun",
+					&store.ScheduledRun{Model: store.Model{ID: runID}, JobType: "refresh"},
+					"Daily provider budget exhausted",
+					mock.MatchedBy(func(at time.Time) bool {
+						return at.After(time.Now()) && at.Equal(at.Truncate(24*time.Hour))
+					}),
+				).Return(nil)
+			},
+
+			wantErr: require.NoError,
+		},
+		{
+			name: "skips finished run",
+
+			attempts:       1,
+			returnStartErr: store.ErrNotFound,
+
+			wantErr: require.NoError,
+		},
+		{
+			name: "retries failed attempt",
+
+			attempts:        1,
+			returnRun:       &store.ScheduledRun{Model: store.Model{ID: runID}},
+			returnStocksErr: errors.New("test error"),
+
+			wantErr: require.Error,
+		},
+		{
+			name: "fails run on last attempt",
+
+			attempts:        3,
+			returnRun:       &store.ScheduledRun{Model: store.Model{ID: runID}},
+			returnStocksErr: errors.New("test error"),
+
+			wantFinishStatus: store.JobStatusFailed,
+			wantFinishErr:    "Refresh failed",
+
+			wantErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			storeMock := &storeMock{}
+			if test.returnStartErr != nil {
+				storeMock.On("StartScheduledRun", runID).Return(nil, test.returnStartErr)
+			} else {
+				storeMock.On("StartScheduledRun", runID).Return(test.returnRun, nil)
+				storeMock.On("ListStocks", &store.StockFilter{Active: &active, After: test.returnRun.LastSymbol, Limit: 500}).
+					Return(test.returnStocks, test.returnStocksErr)
+			}
+			if test.setup != nil {
+				test.setup(storeMock)
+			}
+			if test.wantFinishStatus != "" {
+				storeMock.On("FinishScheduledRun", runID, test.wantFinishStatus, test.wantFinishErr).Return(nil)
+			}
+
+			obsvr := observe.NewFake()
+			srv := api.New(
+				testAPIKey,
+				testScoringFilePath,
+				api.ServerCookieConfig{},
+				storeMock,
+				&authenticatorMock{},
+				obsvr,
+				test.opts...,
+			)
+
+			handler, ok := srv.JobHandlers()["refresh"]
+			require.True(t, ok)
+
+			err := handler(ctx, &store.Job{
+				Model:       store.Model{ID: uuid.New()},
+				Type:        "refresh",
+				Payload:     payload,
+				Status:      store.JobStatusRunning,
+				Attempts:    test.attempts,
+				MaxAttempts: 3,
+			})
+
+			test.wantErr(t, err)
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/store"
+)
+
+type savedScreenReq struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+type savedScreenResp struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Query         string    `json:"query"`
+	SeenAt        time.Time `json:"seen_at"`
+	UnreadChanges int       `json:"unread_changes"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type savedScreensResp struct {
+	Screens []savedScreenResp `json:"screens"`
+}
+
+type screenChangeResp struct {
+	ID        string    `json:"id"`
+	RunID     string    `json:"run_id,omitempty"`
+	Entered   []string  `json:"entered"`
+	Exited    []string  `json:"exited"`
+	Unread    bool      `json:"unread"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type screenChangesResp struct {
+	Changes []screenChangeResp `json:"changes"`
+	Limit   int                `json:"limit"`
+	Offset  int                `json:"offset"`
+}
+
+// CreateSavedScreenHandler saves a screener query of the current user under a name, e.g.
+// {"name": "Value", "query": "filter=P/E Ratio<15&sort=-score"}. The stocks entering and leaving its result
+// after each scheduled refresh are recorded as its changes. The screens count the changes unread by the user,
+// who lists them with GET /screens/{id}/changes and marks them read with POST /screens/{id}/changes/seen.
+func (s *Server) CreateSavedScreenHandler(w http.ResponseWriter, r *http.Request) {
+	var req savedScreenReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	q, err := url.ParseQuery(req.Query)
+	if err != nil {
+		http.Error(w, "Invalid screener query", http.StatusBadRequest)
+		return
+	}
+	// The whole result of the screen is tracked, whatever the page.
+	q.Del("cursor")
+	q.Del("limit")
+	q.Del("offset")
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		s.log.Error("Failed to resolve the current user", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	screen, err := s.parseStockScreen(ctx, q)
+	if err != nil {
+		s.handleSavedScreenError(w, err)
+		return
+	}
+
+	stocks, err := s.screenAllStocks(ctx, screen)
+	if err != nil {
+		s.handleSavedScreenError(w, err)
+		return
+	}
+
+	saved, err := s.store.CreateSavedScreen(ctx, &store.CreateSavedScreen{
+		UserID: user.ID,
+		Name:   req.Name,
+		Query:  q.Encode(),
+		Stocks: stocks,
+	})
+	if err != nil {
+		s.handleSavedScreenError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(toSavedScreenResp(saved)); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ListSavedScreensHandler lists the saved screens of the current user, ordered by name,
+// along with the number of their unread changes.
+func (s *Server) ListSavedScreensHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		s.log.Error("Failed to resolve the current user", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	screens, err := s.store.ListSavedScreens(ctx, user.ID)
+	if err != nil {
+		s.handleSavedScreenError(w, err)
+		return
+	}
+
+	resp := savedScreensResp{Screens: make([]savedScreenResp, 0, len(screens))}
+	for _, screen := range screens {
+		resp.Screens = append(resp.Screens, toSavedScreenResp(&screen))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// DeleteSavedScreenHandler deletes a saved screen of the current user along with its changes.
+func (s *Server) DeleteSavedScreenHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	if _, err = s.findUserSavedScreen(ctx, id); err != nil {
+		s.handleSavedScreenError(w, err)
+		return
+	}
+
+	if err = s.store.DeleteSavedScreen(ctx, id); err != nil {
+		s.handleSavedScreenError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListSavedScreenChangesHandler lists the stocks entering and leaving a saved screen of the current user
+// after each scheduled refresh, the most recent first. The changes recorded after the screen was last seen are unread.
+func (s *Server) ListSavedScreenChangesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	limit, offset, err := parsePage(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	saved, err := s.findUserSavedScreen(ctx, id)
+	if err != nil {
+		s.handleSavedScreenError(w, err)
+		return
+	}
+
+	changes, err := s.store.ListSavedScreenChanges(ctx, id, limit, offset)
+	if err != nil {
+		s.handleSavedScreenError(w, err)
+		return
+	}
+
+	resp := screenChangesResp{
+		Changes: make([]screenChangeResp, 0, len(changes)),
+		Limit:   limit,
+		Offset:  offset,
+	}
+	for _, change := range changes {
+		item := screenChangeResp{
+			ID:        change.ID.String(),
+			Entered:   change.Entered,
+			Exited:    change.Exited,
+			Unread:    change.CreatedAt.After(saved.SeenAt),
+			CreatedAt: change.CreatedAt,
+		}
+		if change.RunID != nil {
+			item.RunID = change.RunID.String()
+		}
+		resp.Changes = append(resp.Changes, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// MarkSavedScreenSeenHandler marks the changes of a saved screen of the current user recorded so far as read.
+func (s *Server) MarkSavedScreenSeenHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	if _, err = s.findUserSavedScreen(ctx, id); err != nil {
+		s.handleSavedScreenError(w, err)
+		return
+	}
+
+	screen, err := s.store.MarkSavedScreenSeen(ctx, id)
+	if err != nil {
+		s.handleSavedScreenError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(toSavedScreenResp(screen)); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// findUserSavedScreen returns a saved screen of the current user.
+// Screens of other users are reported as missing.
+func (s *Server) findUserSavedScreen(ctx context.Context, id uuid.UUID) (*store.SavedScreen, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("resolving the current user: %w", err)
+	}
+
+	screen, err := s.store.FindSavedScreen(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if screen.UserID != user.ID {
+		return nil, store.ErrNotFound
+	}
+
+	return screen, nil
+}
+
+// updateSavedScreens screens the stocks of every saved screen after a refresh,
+// recording the stocks entering and leaving them. Failing screens are logged and skipped.
+func (s *Server) updateSavedScreens(ctx context.Context, runID uuid.UUID) error {
+	log := s.log.With(lctx.Str("run", runID.String()))
+
+	screens, err := s.store.ListSavedScreens(ctx, uuid.Nil)
+	if err != nil {
+		return fmt.Errorf("listing saved screens: %w", err)
+	}
+
+	for _, saved := range screens {
+		if err = s.updateSavedScreen(ctx, &saved, runID); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			log.Error("Failed to update saved screen", lctx.Str("screen", saved.ID.String()), lctx.Error("error", err))
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) updateSavedScreen(ctx context.Context, saved *store.SavedScreen, runID uuid.UUID) error {
+	q, err := url.ParseQuery(saved.Query)
+	if err != nil {
+		return fmt.Errorf("parsing query: %w", err)
+	}
+
+	screen, err := s.parseStockScreen(ctx, q)
+	if err != nil {
+		return fmt.Errorf("parsing screen: %w", err)
+	}
+
+	stocks, err := s.screenAllStocks(ctx, screen)
+	if err != nil {
+		return fmt.Errorf("screening stocks: %w", err)
+	}
+
+	change, err := s.store.UpdateSavedScreenStocks(ctx, saved.ID, &runID, stocks)
+	if err != nil {
+		return fmt.Errorf("updating stocks: %w", err)
+	}
+
+	if change.Changed() {
+		s.log.Info("Saved screen changed",
+			lctx.Str("run", runID.String()),
+			lctx.Str("screen", saved.ID.String()),
+			lctx.Str("user", saved.UserID.String()),
+			lctx.Int("entered", len(change.Entered)),
+			lctx.Int("exited", len(change.Exited)),
+		)
+	}
+
+	return nil
+}
+
+func (s *Server) handleSavedScreenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		http.Error(w, "Saved screen not found", http.StatusNotFound)
+	case errors.Is(err, store.ErrAlreadyExists):
+		http.Error(w, "Saved screen already exists", http.StatusConflict)
+	case errors.As(err, &store.ValidationError{}):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		s.log.Error("Failed to manage saved screens", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func toSavedScreenResp(screen *store.SavedScreen) savedScreenResp {
+	return savedScreenResp{
+		ID:            screen.ID.String(),
+		Name:          screen.Name,
+		Query:         screen.Query,
+		SeenAt:        screen.SeenAt,
+		UnreadChanges: screen.UnreadChanges,
+		CreatedAt:     screen.CreatedAt,
+		UpdatedAt:     screen.UpdatedAt,
+	}
+}
@@ -0,0 +1,415 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestServer_CreateSavedScreenHandler(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	screenID := uuid.New()
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	metrics := []store.Metric{{Name: "P/E Ratio"}}
+	stock := store.Stock{Model: store.Model{ID: uuid.New()}, Symbol: "KO"}
+
+	tests := []struct {
+		name string
+
+		sendBody string
+		setup    func(m *storeMock)
+
+		wantStatus int
+		wantResult string
+	}{
+		{
+			name: "saves screen with its current stocks",
+
+			sendBody: `{"name": "Value", "query": "filter=p%2Fe+ratio%3C15&cursor=abc&limit=5"}`,
+			setup: func(m *storeMock) {
+				m.On("ListMetrics", 50, 0).Return(metrics, nil)
+				m.On("ScreenStocks", &store.StockScreen{
+					Conditions: []store.ScreenCondition{{Metric: "P/E Ratio", Operator: store.OpLess, Value: 15}},
+					Sort:       "symbol",
+					Limit:      100,
+				}).Return([]store.ScreenedStock{{Stock: stock}}, nil)
+				m.On("CreateSavedScreen", &store.CreateSavedScreen{
+					UserID: userID,
+					Name:   "Value",
+					Query:  "filter=p%2Fe+ratio%3C15",
+					Stocks: []store.Stock{stock},
+				}).Return(&store.SavedScreen{
+					Model:  store.Model{ID: screenID, CreatedAt: createdAt, UpdatedAt: createdAt},
+					UserID: userID,
+					Name:   "Value",
+					Query:  "filter=p%2Fe+ratio%3C15",
+					SeenAt: createdAt,
+				}, nil)
+			},
+
+			wantStatus: http.StatusCreated,
+			wantResult: `{
+				"id": "` + screenID.String() + `",
+				"name": "Value",
+				"query": "filter=p%2Fe+ratio%3C15",
+				"seen_at": "2024-03-01T10:00:00Z",
+				"unread_changes": 0,
+				"created_at": "2024-03-01T10:00:00Z",
+				"updated_at": "2024-03-01T10:00:00Z"
+			}`,
+		},
+		{
+			name: "handles existing screen name",
+
+			sendBody: `{"name": "Value", "query": ""}`,
+			setup: func(m *storeMock) {
+				m.On("ListMetrics", 50, 0).Return(metrics, nil)
+				m.On("ScreenStocks", mock.Anything).Return([]store.ScreenedStock{}, nil)
+				m.On("CreateSavedScreen", mock.Anything).Return(nil, store.ErrAlreadyExists)
+			},
+
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "handles invalid screen",
+
+			sendBody: `{"name": "Value", "query": "filter=Beta%3C1"}`,
+			setup: func(m *storeMock) {
+				m.On("ListMetrics", 50, 0).Return(metrics, nil)
+			},
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles invalid payload",
+
+			sendBody: `{"name": 1}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.setup != nil {
+				storeMock.On("ProvisionUser", mock.Anything).Return(&store.User{Model: store.Model{ID: userID}}, nil)
+				test.setup(storeMock)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/screens", bytes.NewBufferString(test.sendBody))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if test.wantResult != "" {
+				assert.JSONEq(t, test.wantResult, rr.Body.String())
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_ListSavedScreenChangesHandler(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	screenID := uuid.New()
+	runID := uuid.New()
+	seenChangeID := uuid.New()
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	seenAt := time.Date(2024, 2, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+
+		screenUserID    uuid.UUID
+		returnScreenErr error
+
+		wantStatus int
+		wantResult string
+	}{
+		{
+			name: "lists screen changes",
+
+			screenUserID: userID,
+
+			wantStatus: http.StatusOK,
+			wantResult: `{
+				"changes": [
+					{
+						"id": "` + screenID.String() + `",
+						"run_id": "` + runID.String() + `",
+						"entered": ["KO"],
+						"exited": ["PEP"],
+						"unread": true,
+						"created_at": "2024-03-01T10:00:00Z"
+					},
+					{
+						"id": "` + seenChangeID.String() + `",
+						"entered": [],
+						"exited": ["KO"],
+						"unread": false,
+						"created_at": "2024-02-01T10:00:00Z"
+					}
+				],
+				"limit": 20,
+				"offset": 0
+			}`,
+		},
+		{
+			name: "hides screen of other user",
+
+			screenUserID: uuid.New(),
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "handles missing screen",
+
+			returnScreenErr: store.ErrNotFound,
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "handles store error",
+
+			returnScreenErr: errors.New("test error"),
+
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			storeMock.On("ProvisionUser", mock.Anything).Return(&store.User{Model: store.Model{ID: userID}}, nil)
+			if test.returnScreenErr != nil {
+				storeMock.On("FindSavedScreen", screenID).Return(nil, test.returnScreenErr)
+			} else {
+				storeMock.On("FindSavedScreen", screenID).Return(&store.SavedScreen{
+					Model:  store.Model{ID: screenID},
+					UserID: test.screenUserID,
+					SeenAt: seenAt,
+				}, nil)
+			}
+			if test.screenUserID == userID {
+				storeMock.On("ListSavedScreenChanges", screenID, 20, 0).Return([]store.ScreenChange{
+					{
+						ID:        screenID,
+						ScreenID:  screenID,
+						RunID:     &runID,
+						Entered:   []string{"KO"},
+						Exited:    []string{"PEP"},
+						CreatedAt: createdAt,
+					},
+					{
+						ID:        seenChangeID,
+						ScreenID:  screenID,
+						Entered:   []string{},
+						Exited:    []string{"KO"},
+						CreatedAt: time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC),
+					},
+				}, nil)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			url := "/screens/" + screenID.String() + "/changes"
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if test.wantResult != "" {
+				assert.JSONEq(t, test.wantResult, rr.Body.String())
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_MarkSavedScreenSeenHandler(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	screenID := uuid.New()
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	seenAt := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+
+		screenUserID    uuid.UUID
+		returnScreenErr error
+		returnSeenErr   error
+
+		wantStatus int
+		wantResult string
+	}{
+		{
+			name: "marks screen changes as read",
+
+			screenUserID: userID,
+
+			wantStatus: http.StatusOK,
+			wantResult: `{
+				"id": "` + screenID.String() + `",
+				"name": "Value",
+				"query": "",
+				"seen_at": "2024-03-02T10:00:00Z",
+				"unread_changes": 0,
+				"created_at": "2024-03-01T10:00:00Z",
+				"updated_at": "2024-03-02T10:00:00Z"
+			}`,
+		},
+		{
+			name: "hides screen of other user",
+
+			screenUserID: uuid.New(),
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "handles missing screen",
+
+			returnScreenErr: store.ErrNotFound,
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "handles store error",
+
+			screenUserID:  userID,
+			returnSeenErr: errors.New("test error"),
+
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			storeMock.On("ProvisionUser", mock.Anything).Return(&store.User{Model: store.Model{ID: userID}}, nil)
+			if test.returnScreenErr != nil {
+				storeMock.On("FindSavedScreen", screenID).Return(nil, test.returnScreenErr)
+			} else {
+				storeMock.On("FindSavedScreen", screenID).Return(&store.SavedScreen{
+					Model:  store.Model{ID: screenID},
+					UserID: test.screenUserID,
+				}, nil)
+			}
+			if test.screenUserID == userID {
+				if test.returnSeenErr != nil {
+					storeMock.On("MarkSavedScreenSeen", screenID).Return(nil, test.returnSeenErr)
+				} else {
+					storeMock.On("MarkSavedScreenSeen", screenID).Return(&store.SavedScreen{
+						Model:  store.Model{ID: screenID, CreatedAt: createdAt, UpdatedAt: seenAt},
+						UserID: userID,
+						Name:   "Value",
+						SeenAt: seenAt,
+					}, nil)
+				}
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			url := "/screens/" + screenID.String() + "/changes/seen"
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if test.wantResult != "" {
+				assert.JSONEq(t, test.wantResult, rr.Body.String())
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
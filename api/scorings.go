@@ -2,7 +2,34 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"time"
+
+	"github.com/huy125/finscope/store"
+)
+
+// RuleType represents how a metric value is turned into a score.
+type RuleType string
+
+const (
+	// RuleTypeRange scores the raw metric value against absolute ranges.
+	RuleTypeRange RuleType = "range"
+	// RuleTypePercentile scores the percentile rank of the metric value among the stock's peers.
+	RuleTypePercentile RuleType = "percentile"
+)
+
+// MissingPolicy represents how a rule is scored when its metric value is missing.
+type MissingPolicy string
+
+const (
+	// MissingPolicySkip leaves the rule out and renormalizes the weights of the remaining rules.
+	MissingPolicySkip MissingPolicy = "skip"
+	// MissingPolicyNeutral scores the rule halfway between its lowest and highest range scores.
+	MissingPolicyNeutral MissingPolicy = "neutral"
+	// MissingPolicyPenalty scores the rule zero while keeping its weight.
+	MissingPolicyPenalty MissingPolicy = "penalty"
 )
 
 // ScoringRange represents the lowest and highest values for a range, as well as its computed score.
@@ -15,14 +42,161 @@ type ScoringRange struct {
 // Rule represents the threshold range and weight of each metric.
 // The threshold ranges of a metric is a set of predefined value intervals used to evaluate the metric performance by
 // assigning it a score.
+// For percentile rules, the ranges are applied to the percentile rank (0-100) of the metric value within the
+// configured peer group instead of the raw value.
 type Rule struct {
-	ScoringRanges []ScoringRange `json:"ranges"`
-	Weight        float64        `json:"weight"`
+	Type          RuleType        `json:"type"`
+	ScoringRanges []ScoringRange  `json:"ranges"`
+	Weight        float64         `json:"weight"`
+	PeerGroup     store.PeerGroup `json:"peerGroup"`
+	LowerIsBetter bool            `json:"lowerIsBetter"`
+	Missing       MissingPolicy   `json:"missing"`
+}
+
+// Thresholds represents the lowest score required for each recommendation action.
+// A score below the sell threshold results in a strong sell.
+type Thresholds struct {
+	StrongBuy float64 `json:"strongBuy"`
+	Buy       float64 `json:"buy"`
+	Hold      float64 `json:"hold"`
+	Sell      float64 `json:"sell"`
+}
+
+// ScoringProfile represents a named set of settings overriding the defaults of the scoring config.
+type ScoringProfile struct {
+	Thresholds Thresholds `json:"thresholds"`
 }
 
 // Config represents the scoring rules config.
 type Config struct {
-	Rules map[string]Rule `json:"rules"`
+	Rules      map[string]Rule           `json:"rules"`
+	Thresholds Thresholds                `json:"thresholds"`
+	Profiles   map[string]ScoringProfile `json:"profiles"`
+}
+
+// ProfileThresholds returns the action thresholds of the given profile.
+// An empty profile name returns the default thresholds.
+func (c *Config) ProfileThresholds(profile string) (Thresholds, error) {
+	if profile == "" {
+		return c.Thresholds, nil
+	}
+
+	p, ok := c.Profiles[profile]
+	if !ok {
+		return Thresholds{}, ErrProfileNotFound
+	}
+
+	return p.Thresholds, nil
+}
+
+// scoreRange returns the lowest and highest scores achievable with the rules.
+func (c *Config) scoreRange() (lowest, highest float64) {
+	for _, rule := range c.Rules {
+		ruleLowest, ruleHighest := rule.scoreRange()
+		lowest += ruleLowest
+		highest += ruleHighest
+	}
+
+	return lowest, highest
+}
+
+func (c *Config) validate() error {
+	for name, rule := range c.Rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("invalid rule %q: %w", name, err)
+		}
+	}
+
+	lowest, highest := c.scoreRange()
+	if err := c.Thresholds.validate(lowest, highest); err != nil {
+		return fmt.Errorf("invalid thresholds: %w", err)
+	}
+
+	for name, p := range c.Profiles {
+		if err := p.Thresholds.validate(lowest, highest); err != nil {
+			return fmt.Errorf("invalid thresholds of profile %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// validate checks that every action is reachable within the achievable score range.
+func (t Thresholds) validate(lowest, highest float64) error {
+	if t.StrongBuy <= t.Buy || t.Buy <= t.Hold || t.Hold <= t.Sell {
+		return errors.New("thresholds must be strictly decreasing from strong buy to sell")
+	}
+
+	if t.StrongBuy > highest {
+		return fmt.Errorf("strong buy threshold %g exceeds the highest achievable score %g", t.StrongBuy, highest)
+	}
+
+	if t.Sell <= lowest {
+		return fmt.Errorf("sell threshold %g does not exceed the lowest achievable score %g", t.Sell, lowest)
+	}
+
+	return nil
+}
+
+// action returns the recommendation action of a score.
+func (t Thresholds) action(score float64) store.Action {
+	switch {
+	case score >= t.StrongBuy:
+		return store.ActionStrongBuy
+	case score >= t.Buy:
+		return store.ActionBuy
+	case score >= t.Hold:
+		return store.ActionHold
+	case score >= t.Sell:
+		return store.ActionSell
+	default:
+		return store.ActionStrongSell
+	}
+}
+
+func (r Rule) validate() error {
+	switch r.Type {
+	case "", RuleTypeRange:
+	case RuleTypePercentile:
+		if r.PeerGroup != store.PeerGroupSector && r.PeerGroup != store.PeerGroupIndustry {
+			return fmt.Errorf("percentile rule requires peer group %q or %q", store.PeerGroupSector, store.PeerGroupIndustry)
+		}
+	default:
+		return fmt.Errorf("unknown rule type %q", r.Type)
+	}
+
+	switch r.Missing {
+	case "", MissingPolicySkip, MissingPolicyNeutral, MissingPolicyPenalty:
+	default:
+		return fmt.Errorf("unknown missing policy %q", r.Missing)
+	}
+
+	if len(r.ScoringRanges) == 0 {
+		return errors.New("at least one range is required")
+	}
+
+	return nil
+}
+
+// scoreRange returns the lowest and highest weighted scores achievable with the rule.
+func (r Rule) scoreRange() (lowest, highest float64) {
+	// A value outside every range scores zero.
+	for _, sr := range r.ScoringRanges {
+		lowest = min(lowest, sr.Score)
+		highest = max(highest, sr.Score)
+	}
+
+	return lowest * r.Weight, highest * r.Weight
+}
+
+// bestScore returns the highest score of the rule ranges.
+func (r Rule) bestScore() float64 {
+	var best float64
+	for _, sr := range r.ScoringRanges {
+		best = max(best, sr.Score)
+	}
+
+	return best
 }
 
 // applyFactors calculates the weighted score for a rule.
@@ -42,7 +216,142 @@ func applyFactors(value float64, rule Rule) float64 {
 	return 0
 }
 
-func loadScoringRules(filename string) (map[string]Rule, error) {
+// applyMissingFactors calculates the weighted score of a rule whose metric value is missing.
+// Rules using the skip policy are not scored and must be excluded by the caller.
+func applyMissingFactors(rule Rule) float64 {
+	if rule.Missing != MissingPolicyNeutral {
+		return 0
+	}
+
+	lowest, highest := rule.ScoringRanges[0].Score, rule.ScoringRanges[0].Score
+	for _, r := range rule.ScoringRanges[1:] {
+		lowest = min(lowest, r.Score)
+		highest = max(highest, r.Score)
+	}
+
+	return (lowest + highest) / 2 * rule.Weight
+}
+
+// applyPercentileFactors calculates the weighted score of a value relative to its peer values.
+func applyPercentileFactors(value float64, peers []float64, rule Rule) float64 {
+	rank := percentileRank(value, peers)
+	if rule.LowerIsBetter {
+		rank = 100 - rank
+	}
+
+	return applyFactors(rank, rule)
+}
+
+// percentileRank returns the percentage of values strictly below the given value,
+// counting values equal to it as half.
+func percentileRank(value float64, values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	var below, equal int
+	for _, v := range values {
+		switch {
+		case v < value:
+			below++
+		case v == value:
+			equal++
+		}
+	}
+
+	return (float64(below) + float64(equal)/2) / float64(len(values)) * 100
+}
+
+// ruleScore represents the score of a rule whose metric value is present.
+type ruleScore struct {
+	metric     string
+	weight     float64
+	score      float64
+	normalized float64
+	recordedAt time.Time
+}
+
+// scorecard accumulates the weighted scores of the rules applied to a stock.
+type scorecard struct {
+	score         float64
+	totalWeight   float64
+	presentWeight float64
+	skippedWeight float64
+	lowest        float64
+	highest       float64
+	scores        []ruleScore
+	missing       []string
+}
+
+// add records the weighted score of a rule whose metric value is present.
+func (c *scorecard) add(name string, rule Rule, score float64, recordedAt time.Time) {
+	c.score += score
+	c.totalWeight += rule.Weight
+	c.presentWeight += rule.Weight
+	c.addRange(rule)
+
+	var normalized float64
+	if best := rule.bestScore() * rule.Weight; best > 0 {
+		normalized = score / best
+	}
+
+	c.scores = append(c.scores, ruleScore{
+		metric:     name,
+		weight:     rule.Weight,
+		score:      score,
+		normalized: normalized,
+		recordedAt: recordedAt,
+	})
+}
+
+// addMissing records a rule whose metric value is missing according to its missing policy.
+func (c *scorecard) addMissing(name string, rule Rule) {
+	c.totalWeight += rule.Weight
+	c.missing = append(c.missing, name)
+	c.addRange(rule)
+
+	if rule.Missing == "" || rule.Missing == MissingPolicySkip {
+		c.skippedWeight += rule.Weight
+		return
+	}
+
+	c.score += applyMissingFactors(rule)
+}
+
+// addRange widens the achievable score range with the range of a rule.
+func (c *scorecard) addRange(rule Rule) {
+	lowest, highest := rule.scoreRange()
+	c.lowest += lowest
+	c.highest += highest
+}
+
+// Score returns the total score, with the weights of skipped rules redistributed over the scored rules.
+// The redistributed score is clamped to the score range achievable with every rule, as the scored rules
+// may reach higher scores per weight than the skipped ones.
+func (c *scorecard) Score() float64 {
+	scoredWeight := c.totalWeight - c.skippedWeight
+	if scoredWeight <= 0 {
+		return 0
+	}
+
+	return min(max(c.score*c.totalWeight/scoredWeight, c.lowest), c.highest)
+}
+
+// Completeness returns the share of the rule weights backed by an actual metric value, between 0 and 1.
+func (c *scorecard) Completeness() float64 {
+	if c.totalWeight <= 0 {
+		return 0
+	}
+
+	return c.presentWeight / c.totalWeight
+}
+
+// Missing returns the names of the metrics that had no value.
+func (c *scorecard) Missing() []string {
+	return c.missing
+}
+
+func loadScoringConfig(filename string) (*Config, error) {
 	file, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
@@ -53,5 +362,9 @@ func loadScoringRules(filename string) (map[string]Rule, error) {
 		return nil, err
 	}
 
-	return config.Rules, nil
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
 }
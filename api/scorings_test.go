@@ -0,0 +1,296 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentileRank(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+
+		value  float64
+		values []float64
+
+		want float64
+	}{
+		{
+			name: "ranks value among distinct values",
+
+			value:  30,
+			values: []float64{10, 20, 30, 40},
+
+			want: 62.5,
+		},
+		{
+			name: "counts ties as half",
+
+			value:  20,
+			values: []float64{10, 20, 20, 40},
+
+			want: 50,
+		},
+		{
+			name: "ranks value above every value",
+
+			value:  50,
+			values: []float64{10, 20, 30, 40},
+
+			want: 100,
+		},
+		{
+			name: "ranks value below every value",
+
+			value:  5,
+			values: []float64{10, 20, 30, 40},
+
+			want: 0,
+		},
+		{
+			name: "ranks single peer",
+
+			value:  10,
+			values: []float64{10},
+
+			want: 50,
+		},
+		{
+			name: "handles empty distribution",
+
+			value: 10,
+
+			want: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := percentileRank(test.value, test.values)
+
+			assert.InDelta(t, test.want, got, 1e-9)
+		})
+	}
+}
+
+func TestApplyPercentileFactors(t *testing.T) {
+	t.Parallel()
+
+	value := func(v float64) *float64 { return &v }
+	ranges := []ScoringRange{
+		{Min: value(75), Score: 10},
+		{Min: value(50), Max: value(75), Score: 6},
+		{Max: value(50), Score: 2},
+	}
+	peers := []float64{10, 20, 30, 40}
+
+	tests := []struct {
+		name string
+
+		value float64
+		peers []float64
+		rule  Rule
+
+		want float64
+	}{
+		{
+			name: "scores high rank",
+
+			value: 40,
+			peers: peers,
+			rule:  Rule{Type: RuleTypePercentile, ScoringRanges: ranges, Weight: 0.5},
+
+			want: 5,
+		},
+		{
+			name: "scores low rank",
+
+			value: 10,
+			peers: peers,
+			rule:  Rule{Type: RuleTypePercentile, ScoringRanges: ranges, Weight: 0.5},
+
+			want: 1,
+		},
+		{
+			name: "inverts rank when lower is better",
+
+			value: 10,
+			peers: peers,
+			rule:  Rule{Type: RuleTypePercentile, ScoringRanges: ranges, Weight: 0.5, LowerIsBetter: true},
+
+			want: 5,
+		},
+		{
+			name: "inverts high rank when lower is better",
+
+			value: 40,
+			peers: peers,
+			rule:  Rule{Type: RuleTypePercentile, ScoringRanges: ranges, Weight: 0.5, LowerIsBetter: true},
+
+			want: 1,
+		},
+		{
+			name: "scores single peer halfway",
+
+			value: 10,
+			peers: []float64{10},
+			rule: Rule{
+				Type:          RuleTypePercentile,
+				ScoringRanges: ranges,
+				Weight:        1,
+				PeerGroup:     store.PeerGroupSector,
+			},
+
+			want: 6,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := applyPercentileFactors(test.value, test.peers, test.rule)
+
+			assert.InDelta(t, test.want, got, 1e-9)
+		})
+	}
+}
+
+func TestScorecard(t *testing.T) {
+	t.Parallel()
+
+	value := func(v float64) *float64 { return &v }
+	now := time.Now()
+	eps := Rule{
+		ScoringRanges: []ScoringRange{{Min: value(5), Score: 10}, {Max: value(5), Score: 4}},
+		Weight:        0.5,
+	}
+	growth := func(policy MissingPolicy) Rule {
+		return Rule{
+			ScoringRanges: []ScoringRange{{Min: value(0.2), Score: 8}, {Max: value(0.2), Score: 2}},
+			Weight:        0.5,
+			Missing:       policy,
+		}
+	}
+
+	tests := []struct {
+		name string
+
+		setup func(c *scorecard)
+
+		wantScore        float64
+		wantCompleteness float64
+		wantMissing      []string
+	}{
+		{
+			name: "sums scores of present metrics",
+
+			setup: func(c *scorecard) {
+				c.add("EPS", eps, applyFactors(6, eps), now)
+				c.add("Revenue Growth", growth(""), applyFactors(0.1, growth("")), now)
+			},
+
+			wantScore:        6,
+			wantCompleteness: 1,
+		},
+		{
+			name: "renormalizes weights of skipped metrics",
+
+			setup: func(c *scorecard) {
+				c.addMissing("EPS", eps)
+				c.add("Revenue Growth", growth(""), applyFactors(0.3, growth("")), now)
+			},
+
+			wantScore:        8,
+			wantCompleteness: 0.5,
+			wantMissing:      []string{"EPS"},
+		},
+		{
+			name: "clamps renormalized score to the highest achievable score",
+
+			setup: func(c *scorecard) {
+				c.add("EPS", eps, applyFactors(6, eps), now)
+				c.addMissing("Revenue Growth", growth(MissingPolicySkip))
+			},
+
+			wantScore:        9,
+			wantCompleteness: 0.5,
+			wantMissing:      []string{"Revenue Growth"},
+		},
+		{
+			name: "skips missing metrics by default",
+
+			setup: func(c *scorecard) {
+				c.add("EPS", eps, applyFactors(4, eps), now)
+				c.addMissing("Revenue Growth", growth(""))
+			},
+
+			wantScore:        4,
+			wantCompleteness: 0.5,
+			wantMissing:      []string{"Revenue Growth"},
+		},
+		{
+			name: "scores neutral metrics halfway between their range scores",
+
+			setup: func(c *scorecard) {
+				c.add("EPS", eps, applyFactors(6, eps), now)
+				c.addMissing("Revenue Growth", growth(MissingPolicyNeutral))
+			},
+
+			wantScore:        7.5,
+			wantCompleteness: 0.5,
+			wantMissing:      []string{"Revenue Growth"},
+		},
+		{
+			name: "scores penalized metrics zero with their weight",
+
+			setup: func(c *scorecard) {
+				c.add("EPS", eps, applyFactors(6, eps), now)
+				c.addMissing("Revenue Growth", growth(MissingPolicyPenalty))
+			},
+
+			wantScore:        5,
+			wantCompleteness: 0.5,
+			wantMissing:      []string{"Revenue Growth"},
+		},
+		{
+			name: "scores zero when every metric is skipped",
+
+			setup: func(c *scorecard) {
+				c.addMissing("EPS", eps)
+				c.addMissing("Revenue Growth", growth(MissingPolicySkip))
+			},
+
+			wantScore:        0,
+			wantCompleteness: 0,
+			wantMissing:      []string{"EPS", "Revenue Growth"},
+		},
+		{
+			name: "handles empty scorecard",
+
+			setup: func(*scorecard) {},
+
+			wantScore:        0,
+			wantCompleteness: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			card := &scorecard{}
+			test.setup(card)
+
+			assert.InDelta(t, test.wantScore, card.Score(), 1e-9)
+			assert.InDelta(t, test.wantCompleteness, card.Completeness(), 1e-9)
+			assert.Equal(t, test.wantMissing, card.Missing())
+		})
+	}
+}
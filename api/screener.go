@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/store"
+)
+
+// screenConjunction separates the conditions of a screen filter, case insensitively.
+var screenConjunction = regexp.MustCompile(`(?i)\s+AND\s+`)
+
+type screenedStockResp struct {
+	Symbol   string             `json:"symbol"`
+	Company  string             `json:"company"`
+	Exchange string             `json:"exchange,omitempty"`
+	Sector   string             `json:"sector,omitempty"`
+	Industry string             `json:"industry,omitempty"`
+	Score    *float64           `json:"score,omitempty"`
+	Action   string             `json:"action,omitempty"`
+	Metrics  map[string]float64 `json:"metrics"`
+}
+
+type screenerResp struct {
+	Stocks     []screenedStockResp `json:"stocks"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// screenCursor is the JSON form of a store.ScreenCursor, encoded in base64 in the cursor query parameter.
+type screenCursor struct {
+	Value  float64 `json:"v"`
+	Symbol string  `json:"s"`
+}
+
+// ScreenStocksHandler screens the active stocks on their latest metric values, latest score,
+// recommended action and sector, e.g. filter="P/E Ratio<15 AND Dividend Yield>0.03".
+// Stocks are sorted by symbol, score or metric, prefixed with "-" for the descending order,
+// and paginated with the returned cursor.
+func (s *Server) ScreenStocksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	screen, err := s.parseStockScreen(ctx, r.URL.Query())
+	if err != nil {
+		s.handleScreenError(w, err)
+		return
+	}
+
+	// One more stock than the page is screened to tell if there is a next page.
+	limit := screen.Limit
+	screen.Limit++
+
+	stocks, err := s.store.ScreenStocks(ctx, screen)
+	if err != nil {
+		s.handleScreenError(w, err)
+		return
+	}
+
+	resp := screenerResp{Stocks: make([]screenedStockResp, 0, min(len(stocks), limit))}
+	for i, stock := range stocks {
+		if i == limit {
+			resp.NextCursor = encodeScreenCursor(screen.Sort, &stocks[i-1])
+			break
+		}
+
+		resp.Stocks = append(resp.Stocks, screenedStockResp{
+			Symbol:   stock.Symbol,
+			Company:  stock.Company,
+			Exchange: stock.Exchange,
+			Sector:   stock.Sector,
+			Industry: stock.Industry,
+			Score:    stock.Score,
+			Action:   string(stock.Action),
+			Metrics:  stock.Metrics,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// parseStockScreen parses the screen of the query parameters.
+// Metric names are matched case insensitively against the known metrics.
+func (s *Server) parseStockScreen(ctx context.Context, q url.Values) (*store.StockScreen, error) {
+	const maxNumMetric = 50
+	metrics, err := s.store.ListMetrics(ctx, maxNumMetric, 0)
+	if err != nil {
+		return nil, fmt.Errorf("listing metrics: %w", err)
+	}
+
+	names := make(map[string]string, len(metrics))
+	for _, m := range metrics {
+		names[strings.ToLower(m.Name)] = m.Name
+	}
+
+	screen := &store.StockScreen{
+		Action: store.Action(q.Get("action")),
+		Sector: strings.TrimSpace(q.Get("sector")),
+		Sort:   store.ScreenSortSymbol,
+	}
+
+	if screen.Conditions, err = parseScreenFilter(q.Get("filter"), names); err != nil {
+		return nil, err
+	}
+
+	scores := []struct {
+		key   string
+		score **float64
+	}{
+		{key: "min_score", score: &screen.MinScore},
+		{key: "max_score", score: &screen.MaxScore},
+	}
+	for _, p := range scores {
+		v := q.Get(p.key)
+		if v == "" {
+			continue
+		}
+
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, store.ValidationError{Err: p.key + " must be a number"}
+		}
+		*p.score = &f
+	}
+
+	if sort := strings.TrimSpace(q.Get("sort")); sort != "" {
+		sort, screen.Desc = strings.CutPrefix(sort, "-")
+		switch sort = strings.ToLower(sort); sort {
+		case store.ScreenSortSymbol, store.ScreenSortScore:
+			screen.Sort = sort
+		default:
+			name, ok := names[sort]
+			if !ok {
+				return nil, store.ValidationError{Err: fmt.Sprintf("unknown sort %q", sort)}
+			}
+			screen.Sort = name
+		}
+	}
+
+	if cursor := q.Get("cursor"); cursor != "" {
+		if screen.After, err = decodeScreenCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	if screen.Limit, _, err = parsePage(q); err != nil {
+		return nil, store.ValidationError{Err: err.Error()}
+	}
+
+	return screen, nil
+}
+
+// parseScreenFilter parses the conditions of a screen filter, joined by AND, each comparing a metric with a value,
+// e.g. "P/E Ratio<15 AND Dividend Yield>0.03". The names map the lower case metric names to the metric names.
+func parseScreenFilter(filter string, names map[string]string) ([]store.ScreenCondition, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil
+	}
+
+	var conds []store.ScreenCondition
+	for _, expr := range screenConjunction.Split(strings.TrimSpace(filter), -1) {
+		i := strings.IndexAny(expr, "<>=!")
+		if i < 0 {
+			return nil, store.ValidationError{Err: fmt.Sprintf("condition %q has no operator", expr)}
+		}
+
+		var op store.ScreenOperator
+		for _, o := range store.ScreenOperators {
+			if strings.HasPrefix(expr[i:], string(o)) {
+				op = o
+				break
+			}
+		}
+		if op == "" {
+			return nil, store.ValidationError{Err: fmt.Sprintf("condition %q has an invalid operator", expr)}
+		}
+
+		name, ok := names[strings.ToLower(strings.TrimSpace(expr[:i]))]
+		if !ok {
+			return nil, store.ValidationError{Err: fmt.Sprintf("unknown metric %q", strings.TrimSpace(expr[:i]))}
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(expr[i+len(op):]), 64)
+		if err != nil {
+			return nil, store.ValidationError{Err: fmt.Sprintf("condition %q has an invalid value", expr)}
+		}
+
+		conds = append(conds, store.ScreenCondition{Metric: name, Operator: op, Value: value})
+	}
+
+	return conds, nil
+}
+
+// encodeScreenCursor returns the cursor of the page following a screened stock.
+func encodeScreenCursor(sort string, stock *store.ScreenedStock) string {
+	after := screenCursorAfter(sort, stock)
+
+	// Encoding a struct of a number and a string cannot fail.
+	b, _ := json.Marshal(screenCursor{Value: after.Value, Symbol: after.Symbol})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// screenCursorAfter returns the position of a screened stock, the next page starting after it.
+func screenCursorAfter(sort string, stock *store.ScreenedStock) *store.ScreenCursor {
+	cursor := &store.ScreenCursor{Symbol: stock.Symbol}
+	switch sort {
+	case store.ScreenSortSymbol:
+	case store.ScreenSortScore:
+		cursor.Value = *stock.Score
+	default:
+		cursor.Value = stock.Metrics[sort]
+	}
+
+	return cursor
+}
+
+// screenAllStocks returns every stock matching a screen, screening them page by page.
+func (s *Server) screenAllStocks(ctx context.Context, screen *store.StockScreen) ([]store.Stock, error) {
+	screen.After = nil
+	screen.Limit = maxPageSize
+
+	var stocks []store.Stock
+	for {
+		page, err := s.store.ScreenStocks(ctx, screen)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, stock := range page {
+			stocks = append(stocks, stock.Stock)
+		}
+		if len(page) < screen.Limit {
+			return stocks, nil
+		}
+
+		screen.After = screenCursorAfter(screen.Sort, &page[len(page)-1])
+	}
+}
+
+func decodeScreenCursor(s string) (*store.ScreenCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, store.ValidationError{Err: "cursor is invalid"}
+	}
+
+	var cursor screenCursor
+	if err = json.Unmarshal(b, &cursor); err != nil || cursor.Symbol == "" {
+		return nil, store.ValidationError{Err: "cursor is invalid"}
+	}
+
+	return &store.ScreenCursor{Value: cursor.Value, Symbol: cursor.Symbol}, nil
+}
+
+func (s *Server) handleScreenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.As(err, &store.ValidationError{}):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		s.log.Error("Failed to screen stocks", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,199 @@
+package api_test
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestServer_ScreenStocksHandler(t *testing.T) {
+	t.Parallel()
+
+	metrics := []store.Metric{{Name: "P/E Ratio"}, {Name: "Dividend Yield"}, {Name: "EPS"}}
+	score := func(v float64) *float64 { return &v }
+	cursor := base64.RawURLEncoding.EncodeToString([]byte(`{"v":0.04,"s":"KO"}`))
+
+	tests := []struct {
+		name string
+
+		query url.Values
+		setup func(m *storeMock)
+
+		wantStatus int
+		wantResult string
+	}{
+		{
+			name: "screens stocks on metrics and score",
+
+			query: url.Values{
+				"filter":    {"p/e ratio<15 and Dividend Yield >= 0.03"},
+				"min_score": {"60"},
+				"action":    {"ActionBuy"},
+				"sector":    {"Consumer Defensive"},
+				"sort":      {"-Dividend Yield"},
+				"limit":     {"1"},
+			},
+			setup: func(m *storeMock) {
+				m.On("ListMetrics", 50, 0).Return(metrics, nil)
+				m.On("ScreenStocks", &store.StockScreen{
+					Conditions: []store.ScreenCondition{
+						{Metric: "P/E Ratio", Operator: store.OpLess, Value: 15},
+						{Metric: "Dividend Yield", Operator: store.OpGreaterEqual, Value: 0.03},
+					},
+					MinScore: score(60),
+					Action:   store.ActionBuy,
+					Sector:   "Consumer Defensive",
+					Sort:     "Dividend Yield",
+					Desc:     true,
+					Limit:    2,
+				}).Return([]store.ScreenedStock{
+					{
+						Stock:   store.Stock{Symbol: "KO", Company: "The Coca-Cola Company", Sector: "Consumer Defensive"},
+						Score:   score(72.5),
+						Action:  store.ActionBuy,
+						Metrics: map[string]float64{"P/E Ratio": 14.2, "Dividend Yield": 0.04},
+					},
+					{
+						Stock:   store.Stock{Symbol: "PEP", Company: "PepsiCo Inc", Sector: "Consumer Defensive"},
+						Score:   score(65),
+						Action:  store.ActionBuy,
+						Metrics: map[string]float64{"P/E Ratio": 13.1, "Dividend Yield": 0.035},
+					},
+				}, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: `{
+				"stocks": [
+					{
+						"symbol": "KO",
+						"company": "The Coca-Cola Company",
+						"sector": "Consumer Defensive",
+						"score": 72.5,
+						"action": "ActionBuy",
+						"metrics": {"P/E Ratio": 14.2, "Dividend Yield": 0.04}
+					}
+				],
+				"next_cursor": "` + cursor + `"
+			}`,
+		},
+		{
+			name: "screens the next page",
+
+			query: url.Values{"sort": {"-dividend yield"}, "cursor": {cursor}},
+			setup: func(m *storeMock) {
+				m.On("ListMetrics", 50, 0).Return(metrics, nil)
+				m.On("ScreenStocks", &store.StockScreen{
+					Sort:  "Dividend Yield",
+					Desc:  true,
+					After: &store.ScreenCursor{Value: 0.04, Symbol: "KO"},
+					Limit: 21,
+				}).Return([]store.ScreenedStock{}, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: `{"stocks": []}`,
+		},
+		{
+			name: "handles unknown metric",
+
+			query: url.Values{"filter": {"Beta<1"}},
+			setup: func(m *storeMock) {
+				m.On("ListMetrics", 50, 0).Return(metrics, nil)
+			},
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles invalid condition",
+
+			query: url.Values{"filter": {"EPS>"}},
+			setup: func(m *storeMock) {
+				m.On("ListMetrics", 50, 0).Return(metrics, nil)
+			},
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles invalid cursor",
+
+			query: url.Values{"cursor": {"not a cursor"}},
+			setup: func(m *storeMock) {
+				m.On("ListMetrics", 50, 0).Return(metrics, nil)
+			},
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles invalid action",
+
+			query: url.Values{"action": {"Buy now"}},
+			setup: func(m *storeMock) {
+				m.On("ListMetrics", 50, 0).Return(metrics, nil)
+				m.On("ScreenStocks", mock.Anything).Return([]store.ScreenedStock(nil), store.ValidationError{
+					Err: "action is invalid",
+				})
+			},
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			test.setup(storeMock)
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/screener?"+test.query.Encode(), nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if test.wantResult != "" {
+				res, err := io.ReadAll(rr.Body)
+				require.NoError(t, err)
+
+				assert.JSONEq(t, test.wantResult, string(res))
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/google/uuid"
@@ -10,21 +11,81 @@ import (
 	"github.com/hamba/logger/v2"
 	lctx "github.com/hamba/logger/v2/ctx"
 	"github.com/huy125/finscope/api/middleware"
+	"github.com/huy125/finscope/pkg/ratelimit"
 	"github.com/huy125/finscope/store"
 	"golang.org/x/oauth2"
 )
 
 // Store defines the interface for interacting with the application's persistent storage.
 type Store interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
 	CreateUser(ctx context.Context, user *store.CreateUser) (*store.User, error)
 	ListUsers(ctx context.Context, limit, offset int) ([]store.User, error)
 	FindUser(ctx context.Context, id uuid.UUID) (*store.User, error)
 	UpdateUser(ctx context.Context, user *store.UpdateUser) (*store.User, error)
+	ProvisionUser(ctx context.Context, user *store.ProvisionUser) (*store.User, error)
+	ListUserIdentities(ctx context.Context, userID uuid.UUID) ([]store.Identity, error)
+	ListStocks(ctx context.Context, filter *store.StockFilter) ([]store.Stock, error)
+	SearchStocks(ctx context.Context, search *store.StockSearch) ([]store.StockMatch, error)
+	ScreenStocks(ctx context.Context, screen *store.StockScreen) ([]store.ScreenedStock, error)
+	CreateSavedScreen(ctx context.Context, screen *store.CreateSavedScreen) (*store.SavedScreen, error)
+	FindSavedScreen(ctx context.Context, id uuid.UUID) (*store.SavedScreen, error)
+	ListSavedScreens(ctx context.Context, userID uuid.UUID) ([]store.SavedScreen, error)
+	MarkSavedScreenSeen(ctx context.Context, id uuid.UUID) (*store.SavedScreen, error)
+	DeleteSavedScreen(ctx context.Context, id uuid.UUID) error
+	UpdateSavedScreenStocks(
+		ctx context.Context,
+		screenID uuid.UUID,
+		runID *uuid.UUID,
+		stocks []store.Stock,
+	) (*store.ScreenChange, error)
+	ListSavedScreenChanges(ctx context.Context, screenID uuid.UUID, limit, offset int) ([]store.ScreenChange, error)
+	CreateWatchlist(ctx context.Context, watchlist *store.CreateWatchlist) (*store.Watchlist, error)
+	FindWatchlist(ctx context.Context, id uuid.UUID) (*store.Watchlist, error)
+	ListWatchlists(ctx context.Context, userID uuid.UUID) ([]store.Watchlist, error)
+	RenameWatchlist(ctx context.Context, watchlist *store.RenameWatchlist) (*store.Watchlist, error)
+	DeleteWatchlist(ctx context.Context, id uuid.UUID) error
+	AddWatchlistItem(ctx context.Context, watchlistID, stockID uuid.UUID) error
+	RemoveWatchlistItem(ctx context.Context, watchlistID, stockID uuid.UUID) error
+	ListWatchlistItems(ctx context.Context, watchlistID uuid.UUID) ([]store.WatchlistItem, error)
+	ListRankings(ctx context.Context, filter *store.RankingFilter) ([]store.StockRanking, error)
+	CompareStocks(ctx context.Context, symbols []string) ([]store.StockSnapshot, error)
+	ListPeers(ctx context.Context, filter *store.PeerFilter) ([]store.StockSnapshot, error)
+	CreateStock(ctx context.Context, stock *store.CreateStock) (*store.Stock, error)
+	UpdateStock(ctx context.Context, stock *store.UpdateStock) (*store.Stock, error)
+	DeactivateStock(ctx context.Context, symbol string) error
 	FindStockBySymbol(ctx context.Context, symbol string) (*store.Stock, error)
+	UpdateStockClassification(ctx context.Context, stockID uuid.UUID, sector, industry string) (*store.Stock, error)
+	FindMetricDistributions(
+		ctx context.Context,
+		group store.PeerGroup,
+		name string,
+		asOf time.Time,
+	) ([]store.MetricDistribution, error)
 	ListMetrics(ctx context.Context, limit, offset int) ([]store.Metric, error)
-	CreateStockMetric(ctx context.Context, stockID, metricID uuid.UUID, value float64) (*store.StockMetric, error)
+	CreateStockMetric(ctx context.Context, m *store.CreateStockMetric) (*store.StockMetric, error)
 	FindLatestStockMetrics(ctx context.Context, stockID uuid.UUID) ([]store.LatestStockMetric, error)
+	FindStockMetricsAsOf(ctx context.Context, stockID uuid.UUID, asOf time.Time) ([]store.LatestStockMetric, error)
+	FindStockMetricHistory(ctx context.Context, from, until time.Time) ([]store.HistoricalStockMetric, error)
+	SaveStockPrices(ctx context.Context, prices []store.StockPrice) error
+	ListStockPrices(ctx context.Context, from, to time.Time) ([]store.StockPrice, error)
 	CreateAnalysis(ctx context.Context, userID, stockID uuid.UUID, score float64) (*store.Analysis, error)
+	FindAnalysis(ctx context.Context, id uuid.UUID) (*store.AnalysisRecommendation, error)
+	ListAnalyses(ctx context.Context, filter *store.AnalysisFilter) ([]store.AnalysisRecommendation, error)
+	ListRecommendationHistory(ctx context.Context, filter *store.AnalysisFilter) ([]store.AnalysisRecommendation, error)
+	CreateAnalysisJob(ctx context.Context, job *store.CreateAnalysisJob) (*store.AnalysisJob, error)
+	FindAnalysisJob(ctx context.Context, id uuid.UUID) (*store.AnalysisJob, error)
+	StartAnalysisJob(ctx context.Context, id uuid.UUID) (*store.AnalysisJob, error)
+	UpdateAnalysisJobResults(ctx context.Context, id uuid.UUID, results []store.AnalysisJobResult) error
+	FinishAnalysisJob(ctx context.Context, id uuid.UUID, status store.JobStatus, errMsg string) error
+
+	EnqueueJob(ctx context.Context, job *store.EnqueueJob) (*store.Job, error)
+
+	StartScheduledRun(ctx context.Context, id uuid.UUID) (*store.ScheduledRun, error)
+	UpdateScheduledRunProgress(ctx context.Context, id uuid.UUID, total, succeeded, failed int, lastSymbol string) error
+	FinishScheduledRun(ctx context.Context, id uuid.UUID, status store.JobStatus, errMsg string) error
+	PauseScheduledRun(ctx context.Context, run *store.ScheduledRun, errMsg string, resumeAt time.Time) error
+	ReserveProviderCall(ctx context.Context, call *store.ReserveProviderCall) (time.Time, error)
 	CreateRecommendation(
 		ctx context.Context,
 		analysisID uuid.UUID,
@@ -48,6 +109,9 @@ type Authenticator interface {
 	GetClientOrigin() string
 }
 
+// HandlerTimeout is the longest time a handler takes to respond, the server write timeout must exceed it.
+const HandlerTimeout = max(backtestTimeout, batchAnalysisTimeout, onboardingTimeout+analysisTimeout) * time.Second
+
 // Server is the API server.
 type Server struct {
 	h http.Handler
@@ -59,9 +123,49 @@ type Server struct {
 	store         Store
 	authenticator Authenticator
 
+	// providerURL is the query endpoint of the stock data provider.
+	providerURL string
+	// providerInterval spaces out the calls to the stock data provider of every server and worker.
+	providerInterval time.Duration
+	// providerDailyLimit bounds the calls to the stock data provider of every server and worker per UTC day.
+	providerDailyLimit int
+	// searchLimiter bounds the share of the provider calls made by the typeahead searches.
+	searchLimiter *ratelimit.Limiter
+	// searches keeps the provider matches of the recent typeahead searches.
+	searches *searchCache
+
 	log *logger.Logger
 }
 
+// Option configures the API server.
+type Option func(*Server)
+
+// WithProviderRateLimit limits the calls to the stock data provider to the given number per minute.
+// The limit is shared through the store by every server and worker.
+func WithProviderRateLimit(perMinute int) Option {
+	return func(s *Server) {
+		if perMinute > 0 {
+			s.providerInterval = time.Minute / time.Duration(perMinute)
+			s.searchLimiter = ratelimit.New(max(perMinute/searchRateShare, 1))
+		}
+	}
+}
+
+// WithProviderDailyLimit limits the calls to the stock data provider to the given number per UTC day.
+// The limit is shared through the store by every server and worker.
+func WithProviderDailyLimit(perDay int) Option {
+	return func(s *Server) {
+		s.providerDailyLimit = perDay
+	}
+}
+
+// WithProviderURL sets the query endpoint of the stock data provider, Alpha Vantage by default.
+func WithProviderURL(u string) Option {
+	return func(s *Server) {
+		s.providerURL = u
+	}
+}
+
 // ServerCookieConfig holds server cookie specific configurations.
 type ServerCookieConfig struct {
 	Name     string `json:"name"`
@@ -77,6 +181,7 @@ func New(
 	store Store,
 	auth Authenticator,
 	obsrv *observe.Observer,
+	opts ...Option,
 ) *Server {
 	s := &Server{
 		apiKey:        apiKey,
@@ -84,10 +189,16 @@ func New(
 		cookieCfg:     cookieCfg,
 		store:         store,
 		authenticator: auth,
+		providerURL:   defaultProviderURL,
+		searches:      newSearchCache(),
 
 		log: obsrv.Log.With(lctx.Str("component", "api")),
 	}
 
+	for _, opt := range opts {
+		opt(s)
+	}
+
 	s.h = s.routes()
 
 	return s
@@ -108,8 +219,56 @@ func (s *Server) routes() http.Handler {
 
 	mux.HandleFunc("GET /", s.HelloServerHandler)
 
-	mux.HandleFunc("GET /stocks", middleware.RequireAuth(s.GetStockBySymbolHandler, s.authenticator))
+	mux.HandleFunc("GET /stocks", middleware.RequireAuth(s.ListStocksHandler, s.authenticator))
+	mux.HandleFunc("POST /stocks", middleware.RequireAuth(s.CreateStockHandler, s.authenticator))
+	mux.HandleFunc("GET /stocks/search", middleware.RequireAuth(s.SearchStocksHandler, s.authenticator))
+	mux.HandleFunc("GET /screener", middleware.RequireAuth(s.ScreenStocksHandler, s.authenticator))
+	mux.HandleFunc("GET /rankings", middleware.RequireAuth(s.ListRankingsHandler, s.authenticator))
+	mux.HandleFunc("POST /compare", middleware.RequireAuth(s.CompareStocksHandler, s.authenticator))
+	mux.HandleFunc("GET /screens", middleware.RequireAuth(s.ListSavedScreensHandler, s.authenticator))
+	mux.HandleFunc("POST /screens", middleware.RequireAuth(s.CreateSavedScreenHandler, s.authenticator))
+	mux.HandleFunc("DELETE /screens/{id}", middleware.RequireAuth(s.DeleteSavedScreenHandler, s.authenticator))
+	mux.HandleFunc(
+		"GET /screens/{id}/changes",
+		middleware.RequireAuth(s.ListSavedScreenChangesHandler, s.authenticator),
+	)
+	mux.HandleFunc(
+		"POST /screens/{id}/changes/seen",
+		middleware.RequireAuth(s.MarkSavedScreenSeenHandler, s.authenticator),
+	)
+	mux.HandleFunc("GET /watchlists", middleware.RequireAuth(s.ListWatchlistsHandler, s.authenticator))
+	mux.HandleFunc("POST /watchlists", middleware.RequireAuth(s.CreateWatchlistHandler, s.authenticator))
+	mux.HandleFunc("GET /watchlists/{id}", middleware.RequireAuth(s.GetWatchlistHandler, s.authenticator))
+	mux.HandleFunc("PUT /watchlists/{id}", middleware.RequireAuth(s.RenameWatchlistHandler, s.authenticator))
+	mux.HandleFunc("DELETE /watchlists/{id}", middleware.RequireAuth(s.DeleteWatchlistHandler, s.authenticator))
+	mux.HandleFunc(
+		"POST /watchlists/{id}/items",
+		middleware.RequireAuth(s.AddWatchlistItemHandler, s.authenticator),
+	)
+	mux.HandleFunc(
+		"DELETE /watchlists/{id}/items/{symbol}",
+		middleware.RequireAuth(s.RemoveWatchlistItemHandler, s.authenticator),
+	)
+	mux.HandleFunc("GET /stocks/{symbol}", middleware.RequireAuth(s.GetStockHandler, s.authenticator))
+	mux.HandleFunc("PUT /stocks/{symbol}", middleware.RequireAuth(s.UpdateStockHandler, s.authenticator))
+	mux.HandleFunc("DELETE /stocks/{symbol}", middleware.RequireAuth(s.DeactivateStockHandler, s.authenticator))
 	mux.HandleFunc("GET /stocks/analysis", middleware.RequireAuth(s.GetStockAnalysisBySymbolHandler, s.authenticator))
+	mux.HandleFunc("POST /stocks/analysis/batch", middleware.RequireAuth(s.BatchAnalysisHandler, s.authenticator))
+	mux.HandleFunc("POST /stocks/analysis/jobs", middleware.RequireAuth(s.CreateAnalysisJobHandler, s.authenticator))
+	mux.HandleFunc("GET /jobs/{id}", middleware.RequireAuth(s.GetJobHandler, s.authenticator))
+
+	mux.HandleFunc(
+		"GET /stocks/{symbol}/recommendations/history",
+		middleware.RequireAuth(s.GetRecommendationHistoryHandler, s.authenticator),
+	)
+	mux.HandleFunc("GET /stocks/{symbol}/prices", middleware.RequireAuth(s.GetStockPricesHandler, s.authenticator))
+	mux.HandleFunc("GET /stocks/{symbol}/peers", middleware.RequireAuth(s.GetStockPeersHandler, s.authenticator))
+
+	mux.HandleFunc("GET /analyses", middleware.RequireAuth(s.ListAnalysesHandler, s.authenticator))
+	mux.HandleFunc("GET /analyses/{id}", middleware.RequireAuth(s.GetAnalysisHandler, s.authenticator))
+
+	mux.HandleFunc("POST /scoring/simulate", middleware.RequireAuth(s.SimulateScoringHandler, s.authenticator))
+	mux.HandleFunc("POST /backtests", middleware.RequireAuth(s.BacktestHandler, s.authenticator))
 
 	mux.HandleFunc("POST /users", middleware.RequireAuth(s.CreateUserHandler, s.authenticator))
 	mux.HandleFunc("PUT /users/{id}", middleware.RequireAuth(s.UpdateUserHandler, s.authenticator))
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"time"
+
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/store"
+)
+
+type simulationReq struct {
+	Symbol    string             `json:"symbol"`
+	Sector    string             `json:"sector"`
+	Industry  string             `json:"industry"`
+	Profile   string             `json:"profile"`
+	AsOf      string             `json:"as_of"`
+	Metrics   map[string]float64 `json:"metrics"`
+	Overrides map[string]float64 `json:"overrides"`
+	Weights   map[string]float64 `json:"weights"`
+}
+
+type simulationResp struct {
+	Symbol          string         `json:"symbol,omitempty"`
+	Score           float64        `json:"score"`
+	Action          string         `json:"action"`
+	ConfidenceLevel float64        `json:"confidence_level"`
+	Confidence      confidenceResp `json:"confidence"`
+	Missing         []string       `json:"missing"`
+}
+
+// validate checks the simulation request against the scoring rules.
+func (r *simulationReq) validate(rules map[string]Rule) error {
+	if r.Symbol == "" && len(r.Metrics) == 0 {
+		return errors.New("symbol or metrics is required")
+	}
+
+	if r.AsOf != "" {
+		if _, err := time.Parse(time.DateOnly, r.AsOf); err != nil {
+			return fmt.Errorf("invalid as of date %q", r.AsOf)
+		}
+	}
+
+	for _, values := range []map[string]float64{r.Metrics, r.Overrides, r.Weights} {
+		for name := range values {
+			if _, ok := rules[name]; !ok {
+				return fmt.Errorf("metric %q has no scoring rule", name)
+			}
+		}
+	}
+
+	for name, weight := range r.Weights {
+		if weight < 0 {
+			return fmt.Errorf("weight of metric %q must not be negative", name)
+		}
+	}
+
+	return nil
+}
+
+// SimulateScoringHandler scores a stock with hypothetical metric values and rule weights
+// without recording any analysis or recommendation.
+func (s *Server) SimulateScoringHandler(w http.ResponseWriter, r *http.Request) {
+	var req simulationReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := loadScoringConfig(s.filePath)
+	if err != nil {
+		s.log.Error("Failed to load scoring config", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err = req.validate(cfg.Rules); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	thresholds, err := cfg.ProfileThresholds(req.Profile)
+	if err != nil {
+		http.Error(w, "Scoring profile is not found", http.StatusBadRequest)
+		return
+	}
+
+	rules := maps.Clone(cfg.Rules)
+	for name, weight := range req.Weights {
+		rule := rules[name]
+		rule.Weight = weight
+		rules[name] = rule
+	}
+
+	// Scoring as of a date only uses the values reported until the end of that day.
+	asOf := time.Now()
+	if req.AsOf != "" {
+		date, _ := time.Parse(time.DateOnly, req.AsOf)
+		asOf = date.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	stock := &store.Stock{Sector: req.Sector, Industry: req.Industry}
+	var stockMetrics []store.LatestStockMetric
+	if req.Symbol != "" {
+		stock, err = s.store.FindStockBySymbol(ctx, req.Symbol)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, "Stock data is not found", http.StatusNotFound)
+				return
+			}
+
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		stockMetrics, err = s.store.FindStockMetricsAsOf(ctx, stock.ID, asOf)
+		if err != nil {
+			s.log.Error("Failed to find stock metrics", lctx.Str("symbol", stock.Symbol), lctx.Error("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	stockMetrics = mergeMetricValues(stockMetrics, req.Metrics, asOf)
+	stockMetrics = mergeMetricValues(stockMetrics, req.Overrides, asOf)
+
+	card, err := s.scoreMetrics(ctx, stockMetrics, rules, s.storePeers(stock, asOf))
+	if err != nil {
+		s.log.Error("Failed to simulate scoring", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	score := card.Score()
+	confidence := calculateConfidence(card, thresholds, asOf)
+	resp := simulationResp{
+		Symbol:          stock.Symbol,
+		Score:           score,
+		Action:          string(thresholds.action(score)),
+		ConfidenceLevel: confidence.Level(),
+		Confidence:      toConfidenceResp(confidence),
+		Missing:         card.Missing(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// mergeMetricValues replaces the stock metric values with the given ones, reported at the given time.
+func mergeMetricValues(
+	stockMetrics []store.LatestStockMetric,
+	values map[string]float64,
+	reportedAt time.Time,
+) []store.LatestStockMetric {
+	merged := make([]store.LatestStockMetric, 0, len(stockMetrics)+len(values))
+	for _, stockMetric := range stockMetrics {
+		if _, ok := values[stockMetric.MetricName]; ok {
+			continue
+		}
+		merged = append(merged, stockMetric)
+	}
+
+	for name, value := range values {
+		merged = append(merged, store.LatestStockMetric{
+			MetricName: name,
+			Value:      &value,
+			ReportedAt: reportedAt,
+			CreatedAt:  reportedAt,
+			UpdatedAt:  reportedAt,
+		})
+	}
+
+	return merged
+}
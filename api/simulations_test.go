@@ -0,0 +1,321 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestServer_SimulateScoringHandler(t *testing.T) {
+	t.Parallel()
+
+	stockID := uuid.New()
+	now := time.Now()
+	value := func(v float64) *float64 { return &v }
+	stockMetrics := []store.LatestStockMetric{
+		{MetricName: "P/E Ratio", Value: value(8), ReportedAt: now, CreatedAt: now, UpdatedAt: now},
+		{MetricName: "EPS", Value: value(6), ReportedAt: now, CreatedAt: now, UpdatedAt: now},
+		{MetricName: "Revenue Growth", Value: value(0.25), ReportedAt: now, CreatedAt: now, UpdatedAt: now},
+		{MetricName: "Debt/Equity Ratio", Value: value(0.3), ReportedAt: now, CreatedAt: now, UpdatedAt: now},
+		{MetricName: "Dividend Yield", Value: value(0.06), ReportedAt: now, CreatedAt: now, UpdatedAt: now},
+		{MetricName: "Market Cap", Value: value(200000000000), ReportedAt: now, CreatedAt: now, UpdatedAt: now},
+	}
+
+	tests := []struct {
+		name string
+
+		sendBody string
+
+		wantSymbol string
+		wantStock  *store.Stock
+
+		wantStatus          int
+		wantScore           float64
+		wantAction          string
+		wantCompleteness    float64
+		wantConfidenceLevel float64
+		wantMissing         []string
+	}{
+		{
+			name: "simulates raw metric values",
+
+			sendBody: `{"metrics": {
+				"P/E Ratio": 8,
+				"EPS": 6,
+				"Revenue Growth": 0.25,
+				"Debt/Equity Ratio": 0.3,
+				"Dividend Yield": 0.06,
+				"Market Cap": 200000000000
+			}}`,
+
+			wantStatus:          http.StatusOK,
+			wantScore:           10,
+			wantAction:          string(store.ActionStrongBuy),
+			wantCompleteness:    1,
+			wantConfidenceLevel: 100,
+		},
+		{
+			name: "renormalizes weights of missing metrics",
+
+			sendBody: `{"metrics": {"P/E Ratio": 15}}`,
+
+			wantStatus:          http.StatusOK,
+			wantScore:           7,
+			wantAction:          string(store.ActionBuy),
+			wantCompleteness:    0.16,
+			wantConfidenceLevel: 16,
+			wantMissing:         []string{"Debt/Equity Ratio", "Dividend Yield", "EPS", "Market Cap", "Revenue Growth"},
+		},
+		{
+			name: "simulates overrides of stored metric values",
+
+			sendBody: `{"symbol": "AAPL", "overrides": {"P/E Ratio": 35}, "weights": {"P/E Ratio": 0}}`,
+
+			wantSymbol: "AAPL",
+			wantStock: &store.Stock{
+				Model:  store.Model{ID: stockID},
+				Symbol: "AAPL",
+			},
+
+			wantStatus:          http.StatusOK,
+			wantScore:           8.4,
+			wantAction:          string(store.ActionStrongBuy),
+			wantCompleteness:    1,
+			wantConfidenceLevel: 70,
+		},
+		{
+			name: "handles unknown metric",
+
+			sendBody: `{"metrics": {"Unknown": 1}}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles missing symbol and metrics",
+
+			sendBody: `{"overrides": {"P/E Ratio": 15}}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles invalid as of date",
+
+			sendBody: `{"symbol": "AAPL", "as_of": "yesterday"}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles unknown scoring profile",
+
+			sendBody: `{"metrics": {"P/E Ratio": 15}, "profile": "unknown"}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles bad request error",
+
+			sendBody: "invalid request",
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.wantSymbol != "" {
+				storeMock.On("FindStockBySymbol", test.wantSymbol).Return(test.wantStock, nil)
+				storeMock.On("FindStockMetricsAsOf", test.wantStock.ID, mock.Anything).Return(stockMetrics, nil)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(
+				ctx,
+				http.MethodPost,
+				"/scoring/simulate",
+				bytes.NewBufferString(test.sendBody),
+			)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if rr.Code == http.StatusOK {
+				var res struct {
+					Symbol          string  `json:"symbol"`
+					Score           float64 `json:"score"`
+					Action          string  `json:"action"`
+					ConfidenceLevel float64 `json:"confidence_level"`
+					Confidence      struct {
+						Completeness float64 `json:"completeness"`
+					} `json:"confidence"`
+					Missing []string `json:"missing"`
+				}
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&res))
+
+				assert.Equal(t, test.wantSymbol, res.Symbol)
+				assert.InDelta(t, test.wantScore, res.Score, 1e-9)
+				assert.Equal(t, test.wantAction, res.Action)
+				assert.InDelta(t, test.wantCompleteness, res.Confidence.Completeness, 1e-9)
+				assert.InDelta(t, test.wantConfidenceLevel, res.ConfidenceLevel, 1e-9)
+				assert.Equal(t, test.wantMissing, res.Missing)
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_SimulateScoringHandlerWithPercentileRules(t *testing.T) {
+	t.Parallel()
+
+	distributions := []store.MetricDistribution{{MetricName: "P/E Ratio", Values: []float64{10, 20, 30, 40}}}
+
+	tests := []struct {
+		name string
+
+		sendBody           string
+		returnDistribution []store.MetricDistribution
+
+		wantScore   float64
+		wantAction  string
+		wantMissing []string
+	}{
+		{
+			name: "scores lowest value among peers best when lower is better",
+
+			sendBody:           `{"sector": "Technology", "metrics": {"P/E Ratio": 10, "EPS": 6}}`,
+			returnDistribution: distributions,
+
+			wantScore:  10,
+			wantAction: string(store.ActionStrongBuy),
+		},
+		{
+			name: "scores high value among peers worst when lower is better",
+
+			sendBody:           `{"sector": "Technology", "metrics": {"P/E Ratio": 35, "EPS": 6}}`,
+			returnDistribution: distributions,
+
+			wantScore:  6,
+			wantAction: string(store.ActionBuy),
+		},
+		{
+			name: "skips metric without peer values",
+
+			sendBody:           `{"sector": "Technology", "metrics": {"P/E Ratio": 10, "EPS": 4}}`,
+			returnDistribution: []store.MetricDistribution{},
+
+			wantScore:   4,
+			wantAction:  string(store.ActionHold),
+			wantMissing: []string{"P/E Ratio"},
+		},
+		{
+			name: "skips metric of stock without sector",
+
+			sendBody: `{"metrics": {"P/E Ratio": 10, "EPS": 6}}`,
+
+			wantScore:   10,
+			wantAction:  string(store.ActionStrongBuy),
+			wantMissing: []string{"P/E Ratio"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.returnDistribution != nil {
+				storeMock.On("FindMetricDistributions", store.PeerGroupSector, "Technology", mock.Anything).
+					Return(test.returnDistribution, nil)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(
+				testAPIKey,
+				"testdata/percentile_scoring_rule_config.json",
+				cookieMock,
+				storeMock,
+				authMock,
+				obsvr,
+			)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(
+				ctx,
+				http.MethodPost,
+				"/scoring/simulate",
+				bytes.NewBufferString(test.sendBody),
+			)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var res struct {
+				Score   float64  `json:"score"`
+				Action  string   `json:"action"`
+				Missing []string `json:"missing"`
+			}
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&res))
+
+			assert.InDelta(t, test.wantScore, res.Score, 1e-9)
+			assert.Equal(t, test.wantAction, res.Action)
+			assert.Equal(t, test.wantMissing, res.Missing)
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
@@ -3,34 +3,130 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"time"
+
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/pkg/symbol"
+	"github.com/huy125/finscope/store"
+)
+
+const (
+	// defaultProviderURL is the query endpoint of the Alpha Vantage API.
+	defaultProviderURL = "https://www.alphavantage.co/query"
+	// providerName identifies the stock data provider in the quotas shared by the servers and workers.
+	providerName = "alphavantage"
 )
 
-func (s *Server) fetchStockData(ctx context.Context, symbol string) (*TimeSeriesDaily, error) {
-	return fetchDataFromAlphaVantage[TimeSeriesDaily](ctx, "TIME_SERIES_DAILY", symbol, s.apiKey)
+func (s *Server) fetchStockData(ctx context.Context, sym string) (*TimeSeriesDaily, error) {
+	return fetchProviderData[TimeSeriesDaily](ctx, s, "TIME_SERIES_DAILY", url.Values{"symbol": {providerSymbol(sym)}})
+}
+
+func (s *Server) fetchStockOverview(ctx context.Context, sym string) (*OverviewMetadata, error) {
+	return fetchProviderData[OverviewMetadata](ctx, s, "OVERVIEW", url.Values{"symbol": {providerSymbol(sym)}})
 }
 
-func (s *Server) fetchStockOverview(ctx context.Context, symbol string) (*OverviewMetadata, error) {
-	return fetchDataFromAlphaVantage[OverviewMetadata](ctx, "OVERVIEW", symbol, s.apiKey)
+func (s *Server) fetchBalanceSheet(ctx context.Context, sym string) (*BalanceSheetMetadata, error) {
+	return fetchProviderData[BalanceSheetMetadata](ctx, s, "BALANCE_SHEET", url.Values{"symbol": {providerSymbol(sym)}})
 }
 
-func (s *Server) fetchBalanceSheet(ctx context.Context, symbol string) (*BalanceSheetMetadata, error) {
-	return fetchDataFromAlphaVantage[BalanceSheetMetadata](ctx, "BALANCE_SHEET", symbol, s.apiKey)
+func (s *Server) searchSymbols(ctx context.Context, keywords string) (*SymbolSearchResult, error) {
+	return fetchProviderData[SymbolSearchResult](ctx, s, "SYMBOL_SEARCH", url.Values{"keywords": {keywords}})
 }
 
-func fetchDataFromAlphaVantage[T any](ctx context.Context, function, symbol, apiKey string) (*T, error) {
-	baseURL := &url.URL{
-		Scheme: "https",
-		Host:   "www.alphavantage.co",
-		Path:   "/query",
+// providerSymbol returns a symbol as known to the stock data provider, e.g. "SHOP.TRT" for "SHOP.TO".
+// Symbols that cannot be parsed are left unchanged.
+func providerSymbol(s string) string {
+	sym, err := symbol.Parse(s)
+	if err != nil {
+		return s
+	}
+
+	return sym.AlphaVantage()
+}
+
+// waitProvider waits for the next call to the stock data provider allowed by the limits shared with the other
+// servers and workers. A call given up because of the context still consumes its slot.
+// It returns store.ErrQuotaExceeded once the daily limit is reached.
+func (s *Server) waitProvider(ctx context.Context) error {
+	if s.providerInterval <= 0 && s.providerDailyLimit <= 0 {
+		return ctx.Err()
+	}
+
+	slot, err := s.store.ReserveProviderCall(ctx, &store.ReserveProviderCall{
+		Provider:   providerName,
+		Interval:   s.providerInterval,
+		DailyLimit: s.providerDailyLimit,
+		Wait:       true,
+	})
+	if err != nil {
+		return err
+	}
+
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// tryProvider takes a call to the stock data provider if the shared limits allow one right away,
+// and reports whether the call is allowed.
+func (s *Server) tryProvider(ctx context.Context) bool {
+	if s.providerInterval <= 0 && s.providerDailyLimit <= 0 {
+		return true
+	}
+
+	_, err := s.store.ReserveProviderCall(ctx, &store.ReserveProviderCall{
+		Provider:   providerName,
+		Interval:   s.providerInterval,
+		DailyLimit: s.providerDailyLimit,
+	})
+	if err != nil && !errors.Is(err, store.ErrQuotaExceeded) {
+		s.log.Error("Failed to reserve provider call", lctx.Error("error", err))
+	}
+
+	return err == nil
+}
+
+// fetchProviderData calls a function of the stock data provider, within the limits of the provider calls.
+func fetchProviderData[T any](ctx context.Context, s *Server, function string, params url.Values) (*T, error) {
+	if err := s.waitProvider(ctx); err != nil {
+		return nil, err
+	}
+
+	return fetchDataFromAlphaVantage[T](ctx, s.providerURL, function, params, s.apiKey)
+}
+
+func fetchDataFromAlphaVantage[T any](
+	ctx context.Context,
+	providerURL, function string,
+	params url.Values,
+	apiKey string,
+) (*T, error) {
+	baseURL, err := url.Parse(providerURL)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing external api url: %w", err)
 	}
 
 	q := baseURL.Query()
 	q.Set("function", function)
-	q.Set("symbol", symbol)
+	for k, v := range params {
+		q[k] = v
+	}
 	q.Set("apikey", apiKey)
 	baseURL.RawQuery = q.Encode()
 
@@ -0,0 +1,353 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/pkg/symbol"
+	"github.com/huy125/finscope/store"
+)
+
+// Sources of the stock search matches.
+const (
+	sourceLocal    = "local"
+	sourceProvider = "provider"
+)
+
+const (
+	// minProviderSearchLength is the query length from which the provider is searched,
+	// shorter queries being typed on their way to a longer one.
+	minProviderSearchLength = 3
+	// searchRateShare is the inverse of the share of the provider rate limit left to the typeahead searches.
+	searchRateShare = 5
+	// searchCacheTTL is the time the provider matches of a query are kept.
+	searchCacheTTL = 10 * time.Minute
+	// maxCachedSearches bounds the queries kept in the cache.
+	maxCachedSearches = 1000
+)
+
+// SymbolSearchResult represents the symbols of the provider best matching a keyword search.
+type SymbolSearchResult struct {
+	BestMatches []SymbolMatch `json:"bestMatches"`
+}
+
+// SymbolMatch represents a symbol of the provider matching a keyword search.
+type SymbolMatch struct {
+	Symbol     string `json:"1. symbol"`
+	Name       string `json:"2. name"`
+	Type       string `json:"3. type"`
+	Region     string `json:"4. region"`
+	Currency   string `json:"8. currency"`
+	MatchScore string `json:"9. matchScore"`
+}
+
+type stockMatchResp struct {
+	Symbol    string        `json:"symbol"`
+	Company   string        `json:"company"`
+	Exchange  string        `json:"exchange,omitempty"`
+	Region    string        `json:"region,omitempty"`
+	Currency  string        `json:"currency,omitempty"`
+	Source    string        `json:"source"`
+	Rank      float64       `json:"rank"`
+	Highlight highlightResp `json:"highlight"`
+}
+
+// highlightResp holds the matched fields, HTML escaped, the matched words being wrapped in <mark> tags.
+type highlightResp struct {
+	Symbol  string `json:"symbol"`
+	Company string `json:"company"`
+}
+
+type stockSearchResp struct {
+	Matches []stockMatchResp `json:"matches"`
+}
+
+// SearchStocksHandler searches stocks by symbol or company name for typeahead, tolerating typos.
+// The stocks of the universe come first, by decreasing rank, followed by the symbols of the provider
+// not yet in the universe. The provider matches are left out for short queries, when the provider
+// cannot be called right away or if it cannot be reached.
+func (s *Server) SearchStocksHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	query := strings.TrimSpace(q.Get("q"))
+	if query == "" {
+		http.Error(w, "Query parameter 'q' is required", http.StatusBadRequest)
+		return
+	}
+
+	limit, _, err := parsePage(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	stocks, err := s.store.SearchStocks(ctx, &store.StockSearch{Query: query, Limit: limit})
+	if err != nil {
+		s.handleStockError(w, err)
+		return
+	}
+
+	terms := searchTerms(query)
+	resp := stockSearchResp{Matches: make([]stockMatchResp, 0, limit)}
+	seen := make(map[string]bool, len(stocks))
+	for _, stock := range stocks {
+		seen[stock.Symbol] = true
+		resp.Matches = append(resp.Matches, stockMatchResp{
+			Symbol:   stock.Symbol,
+			Company:  stock.Company,
+			Exchange: stock.Exchange,
+			Currency: stock.Currency,
+			Source:   sourceLocal,
+			Rank:     stock.Rank,
+			Highlight: highlightResp{
+				Symbol:  highlight(stock.Symbol, terms),
+				Company: highlight(stock.Company, terms),
+			},
+		})
+	}
+
+	if len(resp.Matches) < limit && utf8.RuneCountInString(query) >= minProviderSearchLength {
+		result := s.searchTypeaheadSymbols(ctx, query)
+		for _, match := range result.BestMatches {
+			if len(resp.Matches) == limit {
+				break
+			}
+
+			sym := symbol.Normalize(match.Symbol)
+			if seen[sym] {
+				continue
+			}
+
+			// The provider reports no rank for an unparsable match score.
+			rank, _ := strconv.ParseFloat(match.MatchScore, 64)
+
+			seen[sym] = true
+			resp.Matches = append(resp.Matches, stockMatchResp{
+				Symbol:   sym,
+				Company:  match.Name,
+				Region:   match.Region,
+				Currency: match.Currency,
+				Source:   sourceProvider,
+				Rank:     rank,
+				Highlight: highlightResp{
+					Symbol:  highlight(sym, terms),
+					Company: highlight(match.Name, terms),
+				},
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// searchTypeaheadSymbols returns the provider symbols matching a typeahead query, from the cache if recently
+// searched. The provider is only called if its budget allows a call right away, so that the typeahead never
+// waits behind the analyses. No match is returned otherwise.
+func (s *Server) searchTypeaheadSymbols(ctx context.Context, query string) *SymbolSearchResult {
+	key := strings.ToLower(query)
+	if result, ok := s.searches.get(key); ok {
+		return result
+	}
+
+	if !s.searchLimiter.TryWait() {
+		return &SymbolSearchResult{}
+	}
+	if !s.tryProvider(ctx) {
+		// The search share is only spent on calls actually made.
+		s.searchLimiter.Refund()
+		return &SymbolSearchResult{}
+	}
+
+	result, err := fetchDataFromAlphaVantage[SymbolSearchResult](
+		ctx,
+		s.providerURL,
+		"SYMBOL_SEARCH",
+		url.Values{"keywords": {query}},
+		s.apiKey,
+	)
+	if err != nil {
+		s.log.Error("Failed to search provider symbols", lctx.Str("query", query), lctx.Error("error", err))
+		return &SymbolSearchResult{}
+	}
+
+	s.searches.put(key, result)
+
+	return result
+}
+
+// searchCache keeps the provider matches of the recent typeahead searches by query.
+type searchCache struct {
+	mu      sync.Mutex
+	entries map[string]searchCacheEntry
+}
+
+type searchCacheEntry struct {
+	result    *SymbolSearchResult
+	expiresAt time.Time
+}
+
+func newSearchCache() *searchCache {
+	return &searchCache{entries: make(map[string]searchCacheEntry)}
+}
+
+// get returns the provider matches of a query, unless they expired.
+func (c *searchCache) get(query string) (*SymbolSearchResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[query]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+
+	return entry.result, true
+}
+
+// put keeps the provider matches of a query, evicting the expired entries when the cache is full.
+func (c *searchCache) put(query string, result *SymbolSearchResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= maxCachedSearches {
+		for q, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, q)
+			}
+		}
+	}
+	if len(c.entries) >= maxCachedSearches {
+		clear(c.entries)
+	}
+
+	c.entries[query] = searchCacheEntry{result: result, expiresAt: now.Add(searchCacheTTL)}
+}
+
+// searchTerms returns the words of a search query, e.g. "coca" and "cola" for "Coca-Cola".
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// highlight escapes a text as HTML, wrapping in <mark> tags the words starting with any of the terms,
+// case insensitively.
+func highlight(text string, terms []string) string {
+	marked := make([]bool, len(text))
+	for i, r := range text {
+		if i > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(text[:i])
+			if unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+				continue
+			}
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			continue
+		}
+
+		for _, term := range terms {
+			end := i + len(term)
+			if end <= len(text) && strings.EqualFold(text[i:end], term) {
+				for j := i; j < end; j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		j := i
+		for j < len(text) && marked[j] == marked[i] {
+			j++
+		}
+
+		if marked[i] {
+			b.WriteString("<mark>" + html.EscapeString(text[i:j]) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(text[i:j]))
+		}
+		i = j
+	}
+
+	return b.String()
+}
+
+// findOrOnboardStock finds a stock of the universe by its symbol.
+// A symbol unknown to the universe is looked up with the provider and, if valid, added to the universe
+// with its company, exchange and currency. It returns store.ErrNotFound if the provider does not know the symbol.
+func (s *Server) findOrOnboardStock(ctx context.Context, sym symbol.Symbol) (*store.Stock, error) {
+	stock, err := s.store.FindStockBySymbol(ctx, sym.String())
+	if !errors.Is(err, store.ErrNotFound) {
+		return stock, err
+	}
+
+	// The ticker matches every share class and exchange of the symbol, whatever their form at the provider.
+	result, err := s.searchSymbols(ctx, sym.Ticker())
+	if err != nil {
+		return nil, fmt.Errorf("searching symbol: %w", err)
+	}
+
+	var match *SymbolMatch
+	for _, m := range result.BestMatches {
+		if symbol.Normalize(m.Symbol) == sym.String() {
+			match = &m
+			break
+		}
+	}
+	if match == nil {
+		return nil, store.ErrNotFound
+	}
+
+	createStock := store.CreateStock{
+		Symbol:   sym.String(),
+		Company:  strings.TrimSpace(match.Name),
+		Currency: strings.ToUpper(match.Currency),
+	}
+
+	// The overview is only known for some markets, the search match is enough to onboard the stock.
+	overview, err := s.fetchStockOverview(ctx, createStock.Symbol)
+	switch {
+	case err != nil:
+		s.log.Warn("Failed to fetch stock overview", lctx.Str("symbol", createStock.Symbol), lctx.Error("error", err))
+	case overview.Symbol != "":
+		createStock.Exchange = strings.ToUpper(overview.Exchange)
+		createStock.Sector = overview.Sector
+		createStock.Industry = overview.Industry
+		if createStock.Currency == "" {
+			createStock.Currency = strings.ToUpper(overview.Currency)
+		}
+	}
+
+	stock, err = s.store.CreateStock(ctx, &createStock)
+	if errors.Is(err, store.ErrAlreadyExists) {
+		// The stock was onboarded by a concurrent request.
+		return s.store.FindStockBySymbol(ctx, createStock.Symbol)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating stock: %w", err)
+	}
+
+	s.log.Info("Stock onboarded", lctx.Str("symbol", stock.Symbol), lctx.Str("company", stock.Company))
+
+	return stock, nil
+}
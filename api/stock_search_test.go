@@ -0,0 +1,319 @@
+package api_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestServer_SearchStocksHandler(t *testing.T) {
+	t.Parallel()
+
+	searchResp := `{"bestMatches": [
+		{
+			"1. symbol": "KO",
+			"2. name": "Coca-Cola Co",
+			"4. region": "United States",
+			"8. currency": "USD",
+			"9. matchScore": "0.8000"
+		},
+		{
+			"1. symbol": "COKE",
+			"2. name": "Coca-Cola Consolidated Inc",
+			"4. region": "United States",
+			"8. currency": "USD",
+			"9. matchScore": "0.6154"
+		}
+	]}`
+
+	tests := []struct {
+		name string
+
+		query    string
+		provider map[string]string
+		setup    func(m *storeMock)
+
+		wantStatus int
+		wantResult string
+	}{
+		{
+			name: "merges local and provider matches",
+
+			query:    "?q=coca-cola",
+			provider: map[string]string{"SYMBOL_SEARCH": searchResp},
+			setup: func(m *storeMock) {
+				m.On("SearchStocks", &store.StockSearch{Query: "coca-cola", Limit: 20}).Return([]store.StockMatch{
+					{
+						Stock: store.Stock{Symbol: "KO", Company: "The Coca-Cola Company", Exchange: "NYSE", Currency: "USD"},
+						Rank:  0.9,
+					},
+				}, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: `{"matches": [
+				{
+					"symbol": "KO",
+					"company": "The Coca-Cola Company",
+					"exchange": "NYSE",
+					"currency": "USD",
+					"source": "local",
+					"rank": 0.9,
+					"highlight": {"symbol": "KO", "company": "The <mark>Coca</mark>-<mark>Cola</mark> Company"}
+				},
+				{
+					"symbol": "COKE",
+					"company": "Coca-Cola Consolidated Inc",
+					"region": "United States",
+					"currency": "USD",
+					"source": "provider",
+					"rank": 0.6154,
+					"highlight": {"symbol": "COKE", "company": "<mark>Coca</mark>-<mark>Cola</mark> Consolidated Inc"}
+				}
+			]}`,
+		},
+		{
+			name: "highlights symbol and escapes html",
+
+			query: "?q=at%26t&limit=1",
+			setup: func(m *storeMock) {
+				m.On("SearchStocks", &store.StockSearch{Query: "at&t", Limit: 1}).Return([]store.StockMatch{
+					{Stock: store.Stock{Symbol: "T", Company: "AT&T Inc"}, Rank: 1},
+				}, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: `{"matches": [
+				{
+					"symbol": "T",
+					"company": "AT&T Inc",
+					"source": "local",
+					"rank": 1,
+					"highlight": {"symbol": "<mark>T</mark>", "company": "<mark>AT</mark>&amp;<mark>T</mark> Inc"}
+				}
+			]}`,
+		},
+		{
+			name: "handles unreachable provider",
+
+			query:    "?q=sap",
+			provider: map[string]string{"SYMBOL_SEARCH": `not json`},
+			setup: func(m *storeMock) {
+				m.On("SearchStocks", &store.StockSearch{Query: "sap", Limit: 20}).Return([]store.StockMatch{}, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: `{"matches": []}`,
+		},
+		{
+			name: "skips provider for short query",
+
+			query:    "?q=co",
+			provider: map[string]string{"SYMBOL_SEARCH": searchResp},
+			setup: func(m *storeMock) {
+				m.On("SearchStocks", &store.StockSearch{Query: "co", Limit: 20}).Return([]store.StockMatch{}, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: `{"matches": []}`,
+		},
+		{
+			name: "handles missing query",
+
+			query: "?q=%20",
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.setup != nil {
+				test.setup(storeMock)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			provider := newProviderServer(t, test.provider)
+
+			obsvr := observe.NewFake()
+			srv := api.New(
+				testAPIKey,
+				testScoringFilePath,
+				cookieMock,
+				storeMock,
+				authMock,
+				obsvr,
+				api.WithProviderURL(provider.URL),
+			)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/stocks/search"+test.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if test.wantResult != "" {
+				res, err := io.ReadAll(rr.Body)
+				require.NoError(t, err)
+
+				assert.JSONEq(t, test.wantResult, string(res))
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_SearchStocksHandlerLimitsProviderCalls(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"bestMatches": [{"1. symbol": "KO", "2. name": "Coca-Cola Co", "9. matchScore": "0.8"}]}`))
+	}))
+	t.Cleanup(provider.Close)
+
+	storeMock := &storeMock{}
+	storeMock.On("SearchStocks", mock.Anything).Return([]store.StockMatch{}, nil)
+	storeMock.On("ReserveProviderCall", &store.ReserveProviderCall{Provider: "alphavantage", Interval: time.Minute}).
+		Return(time.Now(), nil).
+		Once()
+
+	authMock := &authenticatorMock{}
+	idToken := createIDToken(t)
+	authMock.On("ExtractTokenFromRequest").Return("valid-token")
+	authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+	obsvr := observe.NewFake()
+	srv := api.New(
+		testAPIKey,
+		testScoringFilePath,
+		api.ServerCookieConfig{Name: "test_access_token", Path: "/"},
+		storeMock,
+		authMock,
+		obsvr,
+		api.WithProviderURL(provider.URL),
+		api.WithProviderRateLimit(1),
+	)
+
+	search := func(query string) string {
+		ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/stocks/search?q="+query, nil)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		return rr.Body.String()
+	}
+
+	start := time.Now()
+	first := search("coca")
+	cached := search("COCA")
+	skipped := search("pepsi")
+
+	assert.Contains(t, first, `"symbol":"KO"`)
+	assert.JSONEq(t, first, cached)
+	assert.JSONEq(t, `{"matches": []}`, skipped)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestServer_SearchStocksHandlerKeepsSearchShareWhenProviderIsBusy(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"bestMatches": [{"1. symbol": "KO", "2. name": "Coca-Cola Co", "9. matchScore": "0.8"}]}`))
+	}))
+	t.Cleanup(provider.Close)
+
+	storeMock := &storeMock{}
+	storeMock.On("SearchStocks", mock.Anything).Return([]store.StockMatch{}, nil)
+	storeMock.On("ReserveProviderCall", &store.ReserveProviderCall{Provider: "alphavantage", Interval: time.Minute}).
+		Return(time.Time{}, store.ErrQuotaExceeded).
+		Once()
+	storeMock.On("ReserveProviderCall", &store.ReserveProviderCall{Provider: "alphavantage", Interval: time.Minute}).
+		Return(time.Now(), nil).
+		Once()
+
+	authMock := &authenticatorMock{}
+	idToken := createIDToken(t)
+	authMock.On("ExtractTokenFromRequest").Return("valid-token")
+	authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+	obsvr := observe.NewFake()
+	srv := api.New(
+		testAPIKey,
+		testScoringFilePath,
+		api.ServerCookieConfig{Name: "test_access_token", Path: "/"},
+		storeMock,
+		authMock,
+		obsvr,
+		api.WithProviderURL(provider.URL),
+		api.WithProviderRateLimit(1),
+	)
+
+	search := func(query string) string {
+		ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/stocks/search?q="+query, nil)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		return rr.Body.String()
+	}
+
+	busy := search("coca")
+	retried := search("coca")
+
+	assert.JSONEq(t, `{"matches": []}`, busy)
+	assert.Contains(t, retried, `"symbol":"KO"`)
+	assert.Equal(t, int32(1), calls.Load())
+	storeMock.AssertExpectations(t)
+}
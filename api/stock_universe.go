@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/pkg/symbol"
+	"github.com/huy125/finscope/store"
+)
+
+type stockReq struct {
+	Symbol   string `json:"symbol"`
+	Company  string `json:"company"`
+	Exchange string `json:"exchange"`
+	Currency string `json:"currency"`
+	Sector   string `json:"sector"`
+	Industry string `json:"industry"`
+	Active   *bool  `json:"active"`
+}
+
+type stockResp struct {
+	ID        string    `json:"id"`
+	Symbol    string    `json:"symbol"`
+	Company   string    `json:"company"`
+	Exchange  string    `json:"exchange"`
+	Currency  string    `json:"currency"`
+	Sector    string    `json:"sector"`
+	Industry  string    `json:"industry"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type stocksResp struct {
+	Stocks []stockResp `json:"stocks"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+// toCreateStock returns the normalized stock details of the request.
+func (r *stockReq) toCreateStock() store.CreateStock {
+	return store.CreateStock{
+		Symbol:   symbol.Normalize(r.Symbol),
+		Company:  strings.TrimSpace(r.Company),
+		Exchange: strings.ToUpper(strings.TrimSpace(r.Exchange)),
+		Currency: strings.ToUpper(strings.TrimSpace(r.Currency)),
+		Sector:   strings.TrimSpace(r.Sector),
+		Industry: strings.TrimSpace(r.Industry),
+	}
+}
+
+// ListStocksHandler lists the stocks of the universe, ordered by symbol.
+// Stocks can be searched by symbol prefix or company name and filtered by sector, exchange and active flag.
+func (s *Server) ListStocksHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Has("symbol") {
+		// The daily prices formerly served by symbol moved to their own route.
+		http.Error(w,
+			"Query parameter 'symbol' is no longer supported, use GET /stocks/{symbol}/prices",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	limit, offset, err := parsePage(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := &store.StockFilter{
+		Query:    strings.TrimSpace(q.Get("q")),
+		Sector:   q.Get("sector"),
+		Exchange: strings.ToUpper(q.Get("exchange")),
+		Limit:    limit,
+		Offset:   offset,
+	}
+	if v := q.Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "active must be a boolean", http.StatusBadRequest)
+			return
+		}
+		filter.Active = &active
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	stocks, err := s.store.ListStocks(ctx, filter)
+	if err != nil {
+		s.handleStockError(w, err)
+		return
+	}
+
+	resp := stocksResp{
+		Stocks: make([]stockResp, 0, len(stocks)),
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+	for _, stock := range stocks {
+		resp.Stocks = append(resp.Stocks, toStockResp(&stock))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetStockHandler gets a stock of the universe.
+func (s *Server) GetStockHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	stock, err := s.store.FindStockBySymbol(ctx, r.PathValue("symbol"))
+	if err != nil {
+		s.handleStockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(toStockResp(stock)); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// CreateStockHandler adds a stock to the universe.
+func (s *Server) CreateStockHandler(w http.ResponseWriter, r *http.Request) {
+	var req stockReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	createStock := req.toCreateStock()
+	stock, err := s.store.CreateStock(ctx, &createStock)
+	if err != nil {
+		s.handleStockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/stocks/"+stock.Symbol)
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(toStockResp(stock)); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// UpdateStockHandler updates the details of a stock, the symbol being taken from the path.
+// A stock is reactivated by setting active, which is left unchanged if omitted.
+func (s *Server) UpdateStockHandler(w http.ResponseWriter, r *http.Request) {
+	var req stockReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	req.Symbol = r.PathValue("symbol")
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	update := &store.UpdateStock{CreateStock: req.toCreateStock()}
+	if req.Active != nil {
+		update.Active = *req.Active
+	} else {
+		current, err := s.store.FindStockBySymbol(ctx, update.Symbol)
+		if err != nil {
+			s.handleStockError(w, err)
+			return
+		}
+		update.Active = current.Active
+	}
+
+	stock, err := s.store.UpdateStock(ctx, update)
+	if err != nil {
+		s.handleStockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(toStockResp(stock)); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// DeactivateStockHandler removes a stock from the active universe.
+// The stock and its history are kept, it is no longer refreshed.
+func (s *Server) DeactivateStockHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	if err := s.store.DeactivateStock(ctx, r.PathValue("symbol")); err != nil {
+		s.handleStockError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleStockError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		http.Error(w, "Stock not found", http.StatusNotFound)
+	case errors.Is(err, store.ErrAlreadyExists):
+		http.Error(w, "Stock already exists", http.StatusConflict)
+	case errors.As(err, &store.ValidationError{}):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		s.log.Error("Failed to manage stocks", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func toStockResp(stock *store.Stock) stockResp {
+	return stockResp{
+		ID:        stock.ID.String(),
+		Symbol:    stock.Symbol,
+		Company:   stock.Company,
+		Exchange:  stock.Exchange,
+		Currency:  stock.Currency,
+		Sector:    stock.Sector,
+		Industry:  stock.Industry,
+		Active:    stock.Active,
+		CreatedAt: stock.CreatedAt,
+		UpdatedAt: stock.UpdatedAt,
+	}
+}
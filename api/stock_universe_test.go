@@ -0,0 +1,300 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestServer_StockUniverseHandlers(t *testing.T) {
+	t.Parallel()
+
+	stockID := uuid.New()
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	active, inactive := true, false
+	stock := &store.Stock{
+		Model:    store.Model{ID: stockID, CreatedAt: createdAt, UpdatedAt: createdAt},
+		Symbol:   "SAP",
+		Company:  "SAP SE",
+		Exchange: "XETRA",
+		Currency: "EUR",
+		Sector:   "Technology",
+		Active:   true,
+	}
+	stockJSON := `{
+		"id": "` + stockID.String() + `",
+		"symbol": "SAP",
+		"company": "SAP SE",
+		"exchange": "XETRA",
+		"currency": "EUR",
+		"sector": "Technology",
+		"industry": "",
+		"active": true,
+		"created_at": "2024-03-01T10:00:00Z",
+		"updated_at": "2024-03-01T10:00:00Z"
+	}`
+
+	pricesJSON := `{
+		"Meta Data": {
+			"1. Information": "Daily Prices",
+			"2. Symbol": "SAP",
+			"3. Last Refreshed": "2024-03-01",
+			"4. Output Size": "Compact",
+			"5. Time Zone": "US/Eastern"
+		},
+		"Time Series (Daily)": {"2024-03-01": {"4. close": "185.5"}}
+	}`
+	provider := newProviderServer(t, map[string]string{"TIME_SERIES_DAILY": pricesJSON})
+
+	tests := []struct {
+		name string
+
+		method   string
+		path     string
+		sendBody string
+		setup    func(m *storeMock)
+
+		wantStatus int
+		wantResult string
+	}{
+		{
+			name: "lists stocks",
+
+			method: http.MethodGet,
+			path:   "/stocks?q=sa&sector=Technology&exchange=xetra&active=true&limit=10&offset=20",
+			setup: func(m *storeMock) {
+				m.On("ListStocks", &store.StockFilter{
+					Query:    "sa",
+					Sector:   "Technology",
+					Exchange: "XETRA",
+					Active:   &active,
+					Limit:    10,
+					Offset:   20,
+				}).Return([]store.Stock{*stock}, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: `{"stocks": [` + stockJSON + `], "limit": 10, "offset": 20}`,
+		},
+		{
+			name: "handles invalid active filter",
+
+			method: http.MethodGet,
+			path:   "/stocks?active=maybe",
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles deprecated symbol query",
+
+			method: http.MethodGet,
+			path:   "/stocks?symbol=SAP",
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "gets stock prices",
+
+			method: http.MethodGet,
+			path:   "/stocks/SAP/prices",
+			setup: func(m *storeMock) {
+				m.On("FindStockBySymbol", "SAP").Return(nil, store.ErrNotFound)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: pricesJSON,
+		},
+		{
+			name: "handles invalid stock prices symbol",
+
+			method: http.MethodGet,
+			path:   "/stocks/SA%20P/prices",
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "gets stock",
+
+			method: http.MethodGet,
+			path:   "/stocks/SAP",
+			setup: func(m *storeMock) {
+				m.On("FindStockBySymbol", "SAP").Return(stock, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: stockJSON,
+		},
+		{
+			name: "creates stock",
+
+			method:   http.MethodPost,
+			path:     "/stocks",
+			sendBody: `{"symbol": " sap ", "company": "SAP SE", "exchange": "xetra", "currency": "eur", "sector": "Technology"}`,
+			setup: func(m *storeMock) {
+				m.On("CreateStock", &store.CreateStock{
+					Symbol:   "SAP",
+					Company:  "SAP SE",
+					Exchange: "XETRA",
+					Currency: "EUR",
+					Sector:   "Technology",
+				}).Return(stock, nil)
+			},
+
+			wantStatus: http.StatusCreated,
+			wantResult: stockJSON,
+		},
+		{
+			name: "handles existing stock",
+
+			method:   http.MethodPost,
+			path:     "/stocks",
+			sendBody: `{"symbol": "SAP", "company": "SAP SE"}`,
+			setup: func(m *storeMock) {
+				m.On("CreateStock", &store.CreateStock{Symbol: "SAP", Company: "SAP SE"}).Return(nil, store.ErrAlreadyExists)
+			},
+
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "handles invalid stock",
+
+			method:   http.MethodPost,
+			path:     "/stocks",
+			sendBody: `{"symbol": "SAP"}`,
+			setup: func(m *storeMock) {
+				m.On("CreateStock", &store.CreateStock{Symbol: "SAP"}).
+					Return(nil, store.ValidationError{Err: "company is required"})
+			},
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "updates stock keeping active flag",
+
+			method:   http.MethodPut,
+			path:     "/stocks/SAP",
+			sendBody: `{"company": "SAP SE", "exchange": "XETRA", "currency": "EUR", "sector": "Technology"}`,
+			setup: func(m *storeMock) {
+				m.On("FindStockBySymbol", "SAP").Return(stock, nil)
+				m.On("UpdateStock", &store.UpdateStock{
+					CreateStock: store.CreateStock{
+						Symbol:   "SAP",
+						Company:  "SAP SE",
+						Exchange: "XETRA",
+						Currency: "EUR",
+						Sector:   "Technology",
+					},
+					Active: true,
+				}).Return(stock, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: stockJSON,
+		},
+		{
+			name: "updates active flag",
+
+			method:   http.MethodPut,
+			path:     "/stocks/SAP",
+			sendBody: `{"company": "SAP SE", "active": false}`,
+			setup: func(m *storeMock) {
+				m.On("UpdateStock", &store.UpdateStock{
+					CreateStock: store.CreateStock{Symbol: "SAP", Company: "SAP SE"},
+					Active:      inactive,
+				}).Return(stock, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: stockJSON,
+		},
+		{
+			name: "deactivates stock",
+
+			method: http.MethodDelete,
+			path:   "/stocks/SAP",
+			setup: func(m *storeMock) {
+				m.On("DeactivateStock", "SAP").Return(nil)
+			},
+
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "handles stock not found error",
+
+			method: http.MethodDelete,
+			path:   "/stocks/UNKNOWN",
+			setup: func(m *storeMock) {
+				m.On("DeactivateStock", "UNKNOWN").Return(store.ErrNotFound)
+			},
+
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.setup != nil {
+				test.setup(storeMock)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(
+				testAPIKey,
+				testScoringFilePath,
+				cookieMock,
+				storeMock,
+				authMock,
+				obsvr,
+				api.WithProviderURL(provider.URL),
+			)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, test.method, test.path, bytes.NewBufferString(test.sendBody))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if test.wantResult != "" {
+				res, err := io.ReadAll(rr.Body)
+				require.NoError(t, err)
+
+				assert.JSONEq(t, test.wantResult, string(res))
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"math"
+	"maps"
 	"net/http"
 	"slices"
 	"strconv"
@@ -13,10 +13,21 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/pkg/symbol"
 	"github.com/huy125/finscope/store"
 )
 
+const (
+	// onboardingTimeout bounds the onboarding of a symbol unknown to the universe, in seconds.
+	// Its provider calls are spaced out by the provider rate limit.
+	onboardingTimeout = 20
+	// analysisTimeout bounds the analysis of a stock, in seconds.
+	// Its provider calls are spaced out by the provider rate limit.
+	analysisTimeout = 20
+)
+
 // StockMetadata represents the metadata of a stock.
 type StockMetadata struct {
 	Information   string `json:"1. Information"`
@@ -36,11 +47,17 @@ type TimeSeriesDaily struct {
 // OverviewMetadata represents the overall financial information of a stock.
 type OverviewMetadata struct {
 	Symbol                    string `json:"symbol"`
+	Name                      string `json:"Name"`
+	Exchange                  string `json:"Exchange"`
+	Currency                  string `json:"Currency"`
+	Sector                    string `json:"Sector"`
+	Industry                  string `json:"Industry"`
 	MarketCapitalization      string `json:"MarketCapitalization"`
 	PERatio                   string `json:"PERatio"`
 	EPS                       string `json:"EPS"`
 	DividendYield             string `json:"DividendYield"`
 	QuarterlyRevenueGrowthYOY string `json:"QuarterlyRevenueGrowthYOY"`
+	LatestQuarter             string `json:"LatestQuarter"`
 }
 
 // BalanceSheetMetadata represents a summary of the financial balances of a stock.
@@ -57,11 +74,19 @@ type AnnualReport struct {
 }
 
 type recommendationResp struct {
-	ID              string  `json:"id"`
-	AnalysisID      string  `json:"analysis_id"`
-	Action          string  `json:"action"`
-	ConfidenceLevel float64 `json:"confidence_level"`
-	Reason          string  `json:"reason"`
+	ID              string          `json:"id"`
+	AnalysisID      string          `json:"analysis_id"`
+	Action          string          `json:"action"`
+	ConfidenceLevel float64         `json:"confidence_level"`
+	Confidence      *confidenceResp `json:"confidence,omitempty"`
+	Reason          string          `json:"reason"`
+}
+
+// analysisResult represents the outcome of a stock analysis.
+type analysisResult struct {
+	analysis       *store.Analysis
+	recommendation *store.Recommendation
+	confidence     confidence
 }
 
 type fetchResult struct {
@@ -70,21 +95,18 @@ type fetchResult struct {
 	overviewErr, balanceSheetErr error
 }
 
-// GetStockBySymbolHandler fetches stock data for the given symbol.
-func (s *Server) GetStockBySymbolHandler(w http.ResponseWriter, r *http.Request) {
-	if !r.URL.Query().Has("symbol") {
-		http.Error(w, "Query parameter 'symbol' is required", http.StatusBadRequest)
+// GetStockPricesHandler fetches the daily prices of the given symbol.
+func (s *Server) GetStockPricesHandler(w http.ResponseWriter, r *http.Request) {
+	sym, err := symbol.Parse(r.PathValue("symbol"))
+	if err != nil {
+		http.Error(w, "Invalid symbol", http.StatusBadRequest)
 		return
 	}
 
-	symbol := r.URL.Query().Get("symbol")
-	symbol = strings.Trim(symbol, "\"")
-	symbol = strings.Trim(symbol, "'")
-
 	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
 	defer cancel()
 
-	data, err := s.fetchStockData(ctx, symbol)
+	data, err := s.fetchStockData(ctx, sym.String())
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
 			http.Error(w, fmt.Sprintf("Stock data not found: %v", err), http.StatusNotFound)
@@ -95,6 +117,8 @@ func (s *Server) GetStockBySymbolHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	s.recordStockPrices(ctx, sym.String(), data)
+
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		http.Error(w,
@@ -121,25 +145,50 @@ func (s *Server) GetStockAnalysisBySymbolHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	symbol := r.URL.Query().Get("symbol")
-	symbol = strings.Trim(symbol, "\"")
-	symbol = strings.Trim(symbol, "'")
+	sym, err := symbol.Parse(r.URL.Query().Get("symbol"))
+	if err != nil {
+		http.Error(w, "Invalid symbol", http.StatusBadRequest)
+		return
+	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
-	defer cancel()
+	cfg, err := loadScoringConfig(s.filePath)
+	if err != nil {
+		s.log.Error("Failed to load scoring config", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	thresholds, err := cfg.ProfileThresholds(r.URL.Query().Get("profile"))
+	if err != nil {
+		http.Error(w, "Scoring profile is not found", http.StatusBadRequest)
+		return
+	}
 
-	stock, err := s.store.FindStockBySymbol(ctx, symbol)
+	onboardCtx, cancelOnboard := context.WithTimeout(r.Context(), onboardingTimeout*time.Second)
+	stock, err := s.findOrOnboardStock(onboardCtx, sym)
+	cancelOnboard()
 	if err != nil {
 		if errors.Is(err, store.ErrNotFound) {
 			http.Error(w, "Stock data is not found", http.StatusNotFound)
 			return
 		}
 
+		s.log.Error("Failed to find stock", lctx.Str("symbol", sym.String()), lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), analysisTimeout*time.Second)
+	defer cancel()
+
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		s.log.Error("Failed to resolve the current user", lctx.Error("error", err))
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	recommendation, err := s.analyzeStock(ctx, stock)
+	result, err := s.analyzeStock(ctx, user, stock, cfg.Rules, thresholds)
 	if err != nil {
 		s.log.Error("Failed to create recommendation", lctx.Error("error", err))
 		http.Error(w,
@@ -150,47 +199,85 @@ func (s *Server) GetStockAnalysisBySymbolHandler(w http.ResponseWriter, r *http.
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(toRecommendationResp(recommendation))
+	err = json.NewEncoder(w).Encode(toAnalysisRecommendationResp(result))
 	if err != nil {
 		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
 		return
 	}
 }
 
-func (s *Server) analyzeStock(ctx context.Context, stock *store.Stock) (*store.Recommendation, error) {
-	stockMetrics, err := s.updateStockMetrics(ctx, stock)
+// analyzeStock refreshes the stock metrics, scores them and records the analysis on behalf of the user.
+// A nil user records the analysis on behalf of no user, e.g. for the scheduled refresh.
+// The provider data is fetched first, then the metrics, the analysis and the recommendation
+// are recorded in a single transaction.
+func (s *Server) analyzeStock(
+	ctx context.Context,
+	user *store.User,
+	stock *store.Stock,
+	rules map[string]Rule,
+	thresholds Thresholds,
+) (*analysisResult, error) {
+	data, err := s.fetchAnalysisData(ctx, stock.Symbol)
 	if err != nil {
-		return nil, fmt.Errorf("error while updating stock metrics for stock %s: %w", stock.Symbol, err)
+		return nil, fmt.Errorf("error while fetching stock data for stock %s: %w", stock.Symbol, err)
 	}
 
-	score, err := s.scoreStock(ctx, stock)
-	if err != nil {
-		return nil, fmt.Errorf("error while scoring for stock %s: %w", stock.Symbol, err)
+	var userID uuid.UUID
+	if user != nil {
+		userID = user.ID
 	}
 
-	createUser := &store.CreateUser{
-		Email:     "analysis@stock.com",
-		Firstname: "Analysis",
-		Lastname:  "Stock",
-	}
-	user, err := s.store.CreateUser(ctx, createUser)
-	if err != nil {
-		return nil, fmt.Errorf("error while creating user: %w", err)
-	}
+	var result *analysisResult
+	err = s.store.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.updateStockMetrics(ctx, stock, data); err != nil {
+			return fmt.Errorf("error while updating stock metrics for stock %s: %w", stock.Symbol, err)
+		}
+
+		card, err := s.scoreStock(ctx, stock, rules)
+		if err != nil {
+			return fmt.Errorf("error while scoring for stock %s: %w", stock.Symbol, err)
+		}
+
+		score := card.Score()
+		analysis, err := s.store.CreateAnalysis(ctx, userID, stock.ID, score)
+		if err != nil {
+			return fmt.Errorf("error while creating analysis for stock %s: %w", stock.Symbol, err)
+		}
+
+		action := thresholds.action(score)
+		confidence := calculateConfidence(card, thresholds, time.Now())
+		recommendation, err := s.store.CreateRecommendation(
+			ctx,
+			analysis.ID,
+			action,
+			confidence.Level(),
+			missingReason(card),
+		)
+		if err != nil {
+			return fmt.Errorf("error while creating recommendation for stock %s: %w", stock.Symbol, err)
+		}
 
-	analysis, err := s.store.CreateAnalysis(ctx, user.ID, stock.ID, score)
+		result = &analysisResult{
+			analysis:       analysis,
+			recommendation: recommendation,
+			confidence:     confidence,
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error while creating analysis for stock %s: %w", stock.Symbol, err)
+		return nil, err
 	}
 
-	action := recommendation(score)
-	confidenceLevel := calculateConfidenceLevel(stockMetrics)
-	recommendation, err := s.store.CreateRecommendation(ctx, analysis.ID, action, confidenceLevel, "")
-	if err != nil {
-		return nil, fmt.Errorf("error while creating recommendation for stock %s: %w", stock.Symbol, err)
+	return result, nil
+}
+
+// missingReason explains which metrics were missing from the analysis, if any.
+func missingReason(card *scorecard) string {
+	if len(card.Missing()) == 0 {
+		return ""
 	}
 
-	return recommendation, nil
+	return "Missing data for " + strings.Join(card.Missing(), ", ")
 }
 
 func calculateDebtEquityRatio(balanceSheet *BalanceSheetMetadata) (float64, error) {
@@ -218,7 +305,15 @@ func calculateDebtEquityRatio(balanceSheet *BalanceSheetMetadata) (float64, erro
 	return ratio, nil
 }
 
-func (s *Server) updateStockMetrics(ctx context.Context, stock *store.Stock) ([]store.StockMetric, error) {
+// analysisData represents the provider data a stock analysis is based on.
+type analysisData struct {
+	metrics      map[string]store.Metric
+	overview     *OverviewMetadata
+	balanceSheet *BalanceSheetMetadata
+}
+
+// fetchAnalysisData fetches the provider data of a stock along with the known metrics.
+func (s *Server) fetchAnalysisData(ctx context.Context, symbol string) (*analysisData, error) {
 	const maxNumMetric = 50
 	const offset = 0
 	metrics, err := s.store.ListMetrics(ctx, maxNumMetric, offset)
@@ -226,24 +321,33 @@ func (s *Server) updateStockMetrics(ctx context.Context, stock *store.Stock) ([]
 		return nil, err
 	}
 
-	metricMap := buildMetricMap(metrics)
-	overview, balanceSheet, fetchErr := s.combineStockData(ctx, stock.Symbol)
-	if fetchErr != nil {
-		return nil, fetchErr
+	overview, balanceSheet, err := s.combineStockData(ctx, symbol)
+	if err != nil {
+		return nil, err
 	}
 
-	var updatedStockMetrics []store.StockMetric
-	saveStockMetric := s.saveStockMetric(ctx, stock, &updatedStockMetrics)
+	return &analysisData{
+		metrics:      buildMetricMap(metrics),
+		overview:     overview,
+		balanceSheet: balanceSheet,
+	}, nil
+}
+
+// updateStockMetrics records the stock metrics and classification from the provider data.
+func (s *Server) updateStockMetrics(ctx context.Context, stock *store.Stock, data *analysisData) error {
+	saveStockMetric := s.saveStockMetric(ctx, stock)
 
-	if balanceSheet != nil {
-		s.processBalanceSheetMetrics(ctx, balanceSheet, metricMap, saveStockMetric)
+	var err error
+	if data.balanceSheet != nil {
+		err = errors.Join(err, s.processBalanceSheetMetrics(ctx, data.balanceSheet, data.metrics, saveStockMetric))
 	}
 
-	if overview != nil {
-		s.processOverviewMetrics(ctx, overview, metricMap, saveStockMetric)
+	if data.overview != nil {
+		err = errors.Join(err, s.updateStockClassification(ctx, stock, data.overview))
+		err = errors.Join(err, s.processOverviewMetrics(ctx, data.overview, data.metrics, saveStockMetric))
 	}
 
-	return updatedStockMetrics, nil
+	return err
 }
 
 func (s *Server) combineStockData(
@@ -306,24 +410,34 @@ func (s *Server) processBalanceSheetMetrics(
 	_ context.Context,
 	balanceSheet *BalanceSheetMetadata,
 	metricMap map[string]store.Metric,
-	save func(store.Metric, float64),
-) {
-	if metric, ok := metricMap["Debt/Equity Ratio"]; ok {
-		value, err := calculateDebtEquityRatio(balanceSheet)
-		if err != nil {
-			s.log.Error("failed to calculate Debt/Equity Ratio for", lctx.Error("error", err))
-			return
-		}
-		save(metric, value)
+	save func(store.Metric, *float64, *time.Time) error,
+) error {
+	metric, ok := metricMap["Debt/Equity Ratio"]
+	if !ok {
+		return nil
 	}
+
+	var periodEnd *time.Time
+	if len(balanceSheet.AnnualReports) > 0 {
+		periodEnd = parsePeriodEnd(balanceSheet.AnnualReports[0].FiscalDateEnding)
+	}
+
+	value, err := calculateDebtEquityRatio(balanceSheet)
+	if err != nil {
+		s.log.Warn("Debt/Equity Ratio is missing", lctx.Error("error", err))
+		return save(metric, nil, periodEnd)
+	}
+
+	return save(metric, &value, periodEnd)
 }
 
 func (s *Server) processOverviewMetrics(
 	_ context.Context,
 	overview *OverviewMetadata,
 	metricMap map[string]store.Metric,
-	save func(store.Metric, float64),
-) {
+	save func(store.Metric, *float64, *time.Time) error,
+) error {
+	periodEnd := parsePeriodEnd(overview.LatestQuarter)
 	metricExtractors := map[string]func(*OverviewMetadata) string{
 		"P/E Ratio":      func(o *OverviewMetadata) string { return o.PERatio },
 		"EPS":            func(o *OverviewMetadata) string { return o.EPS },
@@ -332,29 +446,68 @@ func (s *Server) processOverviewMetrics(
 		"Revenue Growth": func(o *OverviewMetadata) string { return o.QuarterlyRevenueGrowthYOY },
 	}
 
+	var errs error
 	for name, metricModel := range metricMap {
 		if extractor, exists := metricExtractors[name]; exists {
 			value, err := strconv.ParseFloat(extractor(overview), 64)
 			if err != nil {
-				s.log.Error("failed to convert metric to float", lctx.Error("error", err))
+				s.log.Warn("metric is missing", lctx.Str("metric", name), lctx.Error("error", err))
+				errs = errors.Join(errs, save(metricModel, nil, periodEnd))
+				continue
 			}
-			save(metricModel, value)
+			errs = errors.Join(errs, save(metricModel, &value, periodEnd))
 		}
 	}
+
+	return errs
+}
+
+// updateStockClassification keeps the stock sector and industry in sync with the overview.
+func (s *Server) updateStockClassification(
+	ctx context.Context,
+	stock *store.Stock,
+	overview *OverviewMetadata,
+) error {
+	if overview.Sector == "" || (overview.Sector == stock.Sector && overview.Industry == stock.Industry) {
+		return nil
+	}
+
+	updated, err := s.store.UpdateStockClassification(ctx, stock.ID, overview.Sector, overview.Industry)
+	if err != nil {
+		return fmt.Errorf("updating stock classification: %w", err)
+	}
+
+	stock.Sector = updated.Sector
+	stock.Industry = updated.Industry
+	return nil
+}
+
+// parsePeriodEnd parses the end date of a fiscal period, returning nil if it is unknown.
+func parsePeriodEnd(date string) *time.Time {
+	periodEnd, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return nil
+	}
+
+	return &periodEnd
 }
 
 func (s *Server) saveStockMetric(
 	ctx context.Context,
 	stock *store.Stock,
-	updatedMetrics *[]store.StockMetric,
-) func(metric store.Metric, value float64) {
-	return func(metric store.Metric, value float64) {
-		savedMetric, err := s.store.CreateStockMetric(ctx, stock.ID, metric.ID, value)
+) func(metric store.Metric, value *float64, periodEnd *time.Time) error {
+	return func(metric store.Metric, value *float64, periodEnd *time.Time) error {
+		_, err := s.store.CreateStockMetric(ctx, &store.CreateStockMetric{
+			StockID:   stock.ID,
+			MetricID:  metric.ID,
+			Value:     value,
+			PeriodEnd: periodEnd,
+		})
 		if err != nil {
-			s.log.Error("failed to save metric", lctx.Str("metric", metric.Name), lctx.Error("error", err))
-			return
+			return fmt.Errorf("saving metric %s: %w", metric.Name, err)
 		}
-		*updatedMetrics = append(*updatedMetrics, *savedMetric)
+
+		return nil
 	}
 }
 
@@ -366,97 +519,105 @@ func buildMetricMap(metrics []store.Metric) map[string]store.Metric {
 	return metricMap
 }
 
-func (s *Server) scoreStock(ctx context.Context, stock *store.Stock) (float64, error) {
-	var result float64
-
+func (s *Server) scoreStock(ctx context.Context, stock *store.Stock, rules map[string]Rule) (*scorecard, error) {
 	stockMetrics, err := s.store.FindLatestStockMetrics(ctx, stock.ID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to find stock: %w", err)
+		return nil, fmt.Errorf("failed to find stock: %w", err)
 	}
 
-	rules, err := loadScoringRules(s.filePath)
-	if err != nil {
-		return 0, fmt.Errorf("failed to load scoring rules: %w", err)
-	}
+	return s.scoreMetrics(ctx, stockMetrics, rules, s.storePeers(stock, time.Now()))
+}
+
+// peerSource returns the metric distributions of a peer group of the stock being scored.
+type peerSource func(ctx context.Context, group store.PeerGroup) (map[string][]float64, error)
 
+// scoreMetrics scores the given metric values of a stock against the rules.
+func (s *Server) scoreMetrics(
+	ctx context.Context,
+	stockMetrics []store.LatestStockMetric,
+	rules map[string]Rule,
+	peers peerSource,
+) (*scorecard, error) {
+	latest := make(map[string]store.LatestStockMetric, len(stockMetrics))
 	for _, stockMetric := range stockMetrics {
-		rule, exists := rules[stockMetric.MetricName]
-		if !exists {
+		if _, exists := rules[stockMetric.MetricName]; !exists {
+			s.log.Debug("metric has no scoring rule", lctx.Str("metric", stockMetric.MetricName))
 			continue
 		}
-
-		result += applyFactors(stockMetric.Value, rule)
+		latest[stockMetric.MetricName] = stockMetric
 	}
 
-	return result, nil
-}
+	card := &scorecard{}
+	for _, name := range slices.Sorted(maps.Keys(rules)) {
+		rule := rules[name]
 
-func recommendation(score float64) store.Action {
-	switch {
-	case score >= 8:
-		return store.ActionStrongBuy
-	case score >= 6:
-		return store.ActionBuy
-	case score >= 4:
-		return store.ActionHold
-	case score >= 2:
-		return store.ActionSell
-	default:
-		return store.ActionStrongSell
-	}
-}
+		stockMetric := latest[name]
+		if stockMetric.Value == nil {
+			card.addMissing(name, rule)
+			continue
+		}
+		value := *stockMetric.Value
 
-func calculateConfidenceLevel(stockMetrics []store.StockMetric) float64 {
-	n := len(stockMetrics)
-	if n == 0 {
-		return 0
-	}
+		if rule.Type != RuleTypePercentile {
+			card.add(name, rule, applyFactors(value, rule), stockMetric.ReportedAt)
+			continue
+		}
 
-	const exponent = 2
-	const maxPercentage = 100
-	normalizedMetrics := minMaxNormalizeMetrics(stockMetrics)
+		distributions, err := peers(ctx, rule.PeerGroup)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find %s distributions: %w", rule.PeerGroup, err)
+		}
 
-	var sum, varianceSum float64
-	for _, value := range normalizedMetrics {
-		sum += value
-	}
-	mean := sum / float64(n)
+		peerValues, ok := distributions[name]
+		if !ok {
+			card.addMissing(name, rule)
+			continue
+		}
 
-	for _, value := range normalizedMetrics {
-		varianceSum += math.Pow(value-mean, exponent)
+		card.add(name, rule, applyPercentileFactors(value, peerValues, rule), stockMetric.ReportedAt)
 	}
-	sampleVariance := varianceSum / float64(n-1)
 
-	stdDeviation := math.Sqrt(sampleVariance)
-	confidence := maxPercentage - math.Min(stdDeviation*maxPercentage, maxPercentage)
-
-	return confidence
+	return card, nil
 }
 
-func minMaxNormalizeMetrics(stockMetrics []store.StockMetric) []float64 {
-	if len(stockMetrics) == 0 {
-		return nil
-	}
+// storePeers returns a peer source reading the metric distributions of the stock's peer groups,
+// as known at the given time, from the store, caching them per group.
+func (s *Server) storePeers(stock *store.Stock, asOf time.Time) peerSource {
+	cache := make(map[store.PeerGroup]map[string][]float64)
 
-	values := make([]float64, len(stockMetrics))
-	for i, metric := range stockMetrics {
-		values[i] = metric.Value
-	}
+	return func(ctx context.Context, group store.PeerGroup) (map[string][]float64, error) {
+		if distributions, ok := cache[group]; ok {
+			return distributions, nil
+		}
 
-	minValue := slices.Min(values)
-	maxValue := slices.Max(values)
+		distributions := make(map[string][]float64)
+		name := peerGroupName(stock, group)
+		if name == "" {
+			cache[group] = distributions
+			return distributions, nil
+		}
 
-	if minValue == maxValue {
-		return make([]float64, len(stockMetrics))
+		metricDistributions, err := s.store.FindMetricDistributions(ctx, group, name, asOf)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, d := range metricDistributions {
+			distributions[d.MetricName] = d.Values
+		}
+		cache[group] = distributions
+
+		return distributions, nil
 	}
+}
 
-	// Normalize each metric using Min-Max formula
-	normalizedValues := make([]float64, len(stockMetrics))
-	for i, metric := range stockMetrics {
-		normalizedValues[i] = (metric.Value - minValue) / (maxValue - minValue)
+// peerGroupName returns the name of the stock's sector or industry.
+func peerGroupName(stock *store.Stock, group store.PeerGroup) string {
+	if group == store.PeerGroupIndustry {
+		return stock.Industry
 	}
 
-	return normalizedValues
+	return stock.Sector
 }
 
 func toRecommendationResp(r *store.Recommendation) recommendationResp {
@@ -468,3 +629,11 @@ func toRecommendationResp(r *store.Recommendation) recommendationResp {
 		Reason:          r.Reason,
 	}
 }
+
+func toAnalysisRecommendationResp(r *analysisResult) recommendationResp {
+	resp := toRecommendationResp(r.recommendation)
+	confidence := toConfidenceResp(r.confidence)
+	resp.Confidence = &confidence
+
+	return resp
+}
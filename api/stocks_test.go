@@ -0,0 +1,234 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+const testScoringFilePath = "../config/scoring_rule_config.json"
+
+func TestServer_GetStockAnalysisBySymbolHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+
+		query string
+
+		wantFindSymbol string
+		returnStock    *store.Stock
+		returnErr      error
+
+		provider        map[string]string
+		wantCreateStock *store.CreateStock
+
+		wantSubject   string
+		returnUserErr error
+
+		wantStatus int
+	}{
+		{
+			name: "handles missing symbol",
+
+			query: "",
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles unknown scoring profile",
+
+			query: "?symbol=AAPL&profile=unknown",
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles stock not found error",
+
+			query: "?symbol=UNKNOWN&profile=conservative",
+
+			wantFindSymbol: "UNKNOWN",
+			returnErr:      store.ErrNotFound,
+
+			provider: map[string]string{
+				"SYMBOL_SEARCH": `{"bestMatches": [{"1. symbol": "UNKNOWNX", "2. name": "Unknown Corp"}]}`,
+			},
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "onboards unknown symbol",
+
+			query: "?symbol=shop",
+
+			wantFindSymbol: "SHOP",
+			returnErr:      store.ErrNotFound,
+
+			provider: map[string]string{
+				"SYMBOL_SEARCH": `{"bestMatches": [
+					{"1. symbol": "SHOP", "2. name": "Shopify Inc", "4. region": "United States", "8. currency": "USD"},
+					{"1. symbol": "SHOP.TRT", "2. name": "Shopify Inc", "4. region": "Toronto", "8. currency": "CAD"}
+				]}`,
+				"OVERVIEW": `{
+					"Symbol": "SHOP",
+					"Name": "Shopify Inc",
+					"Exchange": "NYSE",
+					"Currency": "USD",
+					"Sector": "TECHNOLOGY",
+					"Industry": "SERVICES-PREPACKAGED SOFTWARE"
+				}`,
+			},
+			wantCreateStock: &store.CreateStock{
+				Symbol:   "SHOP",
+				Company:  "Shopify Inc",
+				Exchange: "NYSE",
+				Currency: "USD",
+				Sector:   "TECHNOLOGY",
+				Industry: "SERVICES-PREPACKAGED SOFTWARE",
+			},
+
+			wantSubject:   "foo",
+			returnUserErr: errors.New("test error"),
+
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "onboards exchange qualified symbol",
+
+			query: "?symbol=shop.to",
+
+			wantFindSymbol: "SHOP.TO",
+			returnErr:      store.ErrNotFound,
+
+			provider: map[string]string{
+				"SYMBOL_SEARCH": `{"bestMatches": [
+					{"1. symbol": "SHOP", "2. name": "Shopify Inc", "4. region": "United States", "8. currency": "USD"},
+					{"1. symbol": "SHOP.TRT", "2. name": "Shopify Inc", "4. region": "Toronto", "8. currency": "CAD"}
+				]}`,
+			},
+			wantCreateStock: &store.CreateStock{
+				Symbol:   "SHOP.TO",
+				Company:  "Shopify Inc",
+				Currency: "CAD",
+			},
+
+			wantSubject:   "foo",
+			returnUserErr: errors.New("test error"),
+
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "handles invalid symbol",
+
+			query: "?symbol=AA%20PL",
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles current user resolution error",
+
+			query: "?symbol=AAPL",
+
+			wantFindSymbol: "AAPL",
+			returnStock:    &store.Stock{Symbol: "AAPL"},
+
+			wantSubject:   "foo",
+			returnUserErr: errors.New("test error"),
+
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.wantFindSymbol != "" {
+				storeMock.On("FindStockBySymbol", test.wantFindSymbol).Return(test.returnStock, test.returnErr)
+			}
+			if test.wantCreateStock != nil {
+				storeMock.On("CreateStock", test.wantCreateStock).Return(&store.Stock{Symbol: test.wantCreateStock.Symbol}, nil)
+			}
+			if test.wantSubject != "" {
+				storeMock.On("ProvisionUser", mock.MatchedBy(func(u *store.ProvisionUser) bool {
+					return u.Subject == test.wantSubject
+				})).Return(nil, test.returnUserErr)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			provider := newProviderServer(t, test.provider)
+
+			obsvr := observe.NewFake()
+			srv := api.New(
+				testAPIKey,
+				testScoringFilePath,
+				cookieMock,
+				storeMock,
+				authMock,
+				obsvr,
+				api.WithProviderURL(provider.URL),
+			)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/stocks/analysis"+test.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+// newProviderServer returns a fake stock data provider serving the given responses by function,
+// and an empty object for the other functions.
+func newProviderServer(t *testing.T, responses map[string]string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("apikey") != testAPIKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		resp, ok := responses[r.URL.Query().Get("function")]
+		if !ok {
+			resp = "{}"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(resp))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
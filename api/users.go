@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,14 +16,6 @@ import (
 
 const requestTimeout = 5
 
-// Profile represents the structure of the user data sent to the client.
-type Profile struct {
-	Email   string `json:"email"`
-	Name    string `json:"name"`
-	Picture string `json:"picture"`
-	ID      string `json:"id"`
-}
-
 type userResp struct {
 	ID        string `json:"id"`
 	Email     string `json:"email"`
@@ -39,6 +32,37 @@ func toUserResp(u *store.User) userResp {
 	}
 }
 
+type identityResp struct {
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+	Email    string `json:"email,omitempty"`
+}
+
+// profileResp represents the stored profile of the current user along with its linked identities.
+type profileResp struct {
+	userResp
+
+	Picture    string         `json:"picture,omitempty"`
+	Identities []identityResp `json:"identities"`
+}
+
+func toProfileResp(u *store.User, picture string, identities []store.Identity) profileResp {
+	resp := profileResp{
+		userResp:   toUserResp(u),
+		Picture:    picture,
+		Identities: make([]identityResp, 0, len(identities)),
+	}
+	for _, identity := range identities {
+		resp.Identities = append(resp.Identities, identityResp{
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+		})
+	}
+
+	return resp
+}
+
 type userReq struct {
 	Email     string `json:"email"`
 	Firstname string `json:"firstname"`
@@ -153,30 +177,57 @@ func (s *Server) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetCurrentUserHandler returns the stored profile of the authenticated user,
+// provisioning it on first sign in.
 func (s *Server) GetCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
-	userCtx := r.Context().Value(middleware.UserContextKey)
-	claims, ok := userCtx.(middleware.Claims)
+	claims, ok := r.Context().Value(middleware.UserContextKey).(middleware.Claims)
 	if !ok {
 		http.Error(w, "Failed to get user claims", http.StatusInternalServerError)
 		return
 	}
 
-	// Create response object
-	user := Profile{
-		Email:   claims.Email,
-		Name:    claims.Name,
-		Picture: claims.Picture,
-		ID:      claims.Subject,
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		s.handleStoreError(w, err)
+		return
+	}
+
+	identities, err := s.store.ListUserIdentities(ctx, user.ID)
+	if err != nil {
+		s.handleStoreError(w, err)
+		return
 	}
 
-	// Set content type header
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(user); err != nil {
+	if err = json.NewEncoder(w).Encode(toProfileResp(user, claims.Picture, identities)); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
 }
 
+// currentUser returns the local user of the authenticated caller, provisioning it on first sign in
+// and keeping its profile in sync with the identity provider claims.
+func (s *Server) currentUser(ctx context.Context) (*store.User, error) {
+	claims, ok := ctx.Value(middleware.UserContextKey).(middleware.Claims)
+	if !ok {
+		return nil, ErrMissingClaims
+	}
+
+	firstname, lastname, _ := strings.Cut(strings.TrimSpace(claims.Name), " ")
+
+	return s.store.ProvisionUser(ctx, &store.ProvisionUser{
+		Provider:      claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Firstname:     firstname,
+		Lastname:      strings.TrimSpace(lastname),
+	})
+}
+
 func (s *Server) handleStoreError(w http.ResponseWriter, err error) {
 	switch {
 	case errors.Is(err, store.ErrNotFound):
@@ -381,10 +381,114 @@ func TestServer_GetUserHandler(t *testing.T) {
 	}
 }
 
+func TestServer_GetCurrentUserHandler(t *testing.T) {
+	t.Parallel()
+
+	id := uuid.New()
+	tests := []struct {
+		name string
+
+		returnUser       *store.User
+		returnErr        error
+		returnIdentities []store.Identity
+
+		wantStatus int
+		wantResult []byte
+	}{
+		{
+			name: "returns stored profile with identities",
+
+			returnUser: &store.User{
+				Model:     store.Model{ID: id},
+				Email:     "foo@example.com",
+				Firstname: "Foo",
+				Lastname:  "Bar",
+			},
+			returnIdentities: []store.Identity{
+				{UserID: id, Provider: "https://example.auth0.com/", Subject: "foo", Email: "foo@example.com"},
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: []byte(`
+				{
+					"id": "` + id.String() + `",
+					"email": "foo@example.com",
+					"firstname": "Foo",
+					"lastname": "Bar",
+					"identities": [
+						{"provider": "https://example.auth0.com/", "subject": "foo", "email": "foo@example.com"}
+					]
+				}`,
+			),
+		},
+		{
+			name: "handles provisioning error",
+
+			returnErr: errors.New("test error"),
+
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			storeMock.On("ProvisionUser", mock.MatchedBy(func(u *store.ProvisionUser) bool {
+				return u.Provider != "" && u.Subject == "foo" && u.Email == "foo@example.com" && u.EmailVerified
+			})).Return(test.returnUser, test.returnErr)
+			if test.returnUser != nil {
+				storeMock.On("ListUserIdentities", id).Return(test.returnIdentities, nil)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/users/me", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if rr.Code == http.StatusOK {
+				res, err := io.ReadAll(rr.Body)
+				require.NoError(t, err)
+
+				assert.JSONEq(t, string(test.wantResult), string(res))
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
 type storeMock struct {
 	mock.Mock
 }
 
+func (m *storeMock) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 func (m *storeMock) CreateUser(_ context.Context, user *store.CreateUser) (*store.User, error) {
 	args := m.Called(user)
 	if args.Get(0) == nil {
@@ -393,6 +497,19 @@ func (m *storeMock) CreateUser(_ context.Context, user *store.CreateUser) (*stor
 	return args.Get(0).(*store.User), args.Error(1)
 }
 
+func (m *storeMock) ProvisionUser(_ context.Context, user *store.ProvisionUser) (*store.User, error) {
+	args := m.Called(user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.User), args.Error(1)
+}
+
+func (m *storeMock) ListUserIdentities(_ context.Context, userID uuid.UUID) ([]store.Identity, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]store.Identity), args.Error(1)
+}
+
 func (m *storeMock) ListUsers(_ context.Context, limit, offset int) ([]store.User, error) {
 	args := m.Called(limit, offset)
 	return args.Get(0).([]store.User), args.Error(1)
@@ -415,6 +532,171 @@ func (m *storeMock) UpdateUser(_ context.Context, user *store.UpdateUser) (*stor
 	return args.Get(0).(*store.User), args.Error(1)
 }
 
+func (m *storeMock) ListStocks(_ context.Context, filter *store.StockFilter) ([]store.Stock, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]store.Stock), args.Error(1)
+}
+
+func (m *storeMock) SearchStocks(_ context.Context, search *store.StockSearch) ([]store.StockMatch, error) {
+	args := m.Called(search)
+	return args.Get(0).([]store.StockMatch), args.Error(1)
+}
+
+func (m *storeMock) ScreenStocks(_ context.Context, screen *store.StockScreen) ([]store.ScreenedStock, error) {
+	args := m.Called(screen)
+	return args.Get(0).([]store.ScreenedStock), args.Error(1)
+}
+
+func (m *storeMock) CreateSavedScreen(
+	_ context.Context,
+	screen *store.CreateSavedScreen,
+) (*store.SavedScreen, error) {
+	args := m.Called(screen)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.SavedScreen), args.Error(1)
+}
+
+func (m *storeMock) FindSavedScreen(_ context.Context, id uuid.UUID) (*store.SavedScreen, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.SavedScreen), args.Error(1)
+}
+
+func (m *storeMock) ListSavedScreens(_ context.Context, userID uuid.UUID) ([]store.SavedScreen, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]store.SavedScreen), args.Error(1)
+}
+
+func (m *storeMock) MarkSavedScreenSeen(_ context.Context, id uuid.UUID) (*store.SavedScreen, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.SavedScreen), args.Error(1)
+}
+
+func (m *storeMock) DeleteSavedScreen(_ context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *storeMock) UpdateSavedScreenStocks(
+	_ context.Context,
+	screenID uuid.UUID,
+	runID *uuid.UUID,
+	stocks []store.Stock,
+) (*store.ScreenChange, error) {
+	args := m.Called(screenID, runID, stocks)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.ScreenChange), args.Error(1)
+}
+
+func (m *storeMock) ListSavedScreenChanges(
+	_ context.Context,
+	screenID uuid.UUID,
+	limit, offset int,
+) ([]store.ScreenChange, error) {
+	args := m.Called(screenID, limit, offset)
+	return args.Get(0).([]store.ScreenChange), args.Error(1)
+}
+
+func (m *storeMock) CreateWatchlist(
+	_ context.Context,
+	watchlist *store.CreateWatchlist,
+) (*store.Watchlist, error) {
+	args := m.Called(watchlist)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.Watchlist), args.Error(1)
+}
+
+func (m *storeMock) FindWatchlist(_ context.Context, id uuid.UUID) (*store.Watchlist, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.Watchlist), args.Error(1)
+}
+
+func (m *storeMock) ListWatchlists(_ context.Context, userID uuid.UUID) ([]store.Watchlist, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]store.Watchlist), args.Error(1)
+}
+
+func (m *storeMock) RenameWatchlist(
+	_ context.Context,
+	watchlist *store.RenameWatchlist,
+) (*store.Watchlist, error) {
+	args := m.Called(watchlist)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.Watchlist), args.Error(1)
+}
+
+func (m *storeMock) DeleteWatchlist(_ context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *storeMock) AddWatchlistItem(_ context.Context, watchlistID, stockID uuid.UUID) error {
+	args := m.Called(watchlistID, stockID)
+	return args.Error(0)
+}
+
+func (m *storeMock) RemoveWatchlistItem(_ context.Context, watchlistID, stockID uuid.UUID) error {
+	args := m.Called(watchlistID, stockID)
+	return args.Error(0)
+}
+
+func (m *storeMock) ListWatchlistItems(_ context.Context, watchlistID uuid.UUID) ([]store.WatchlistItem, error) {
+	args := m.Called(watchlistID)
+	return args.Get(0).([]store.WatchlistItem), args.Error(1)
+}
+
+func (m *storeMock) ListRankings(_ context.Context, filter *store.RankingFilter) ([]store.StockRanking, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]store.StockRanking), args.Error(1)
+}
+
+func (m *storeMock) CompareStocks(_ context.Context, symbols []string) ([]store.StockSnapshot, error) {
+	args := m.Called(symbols)
+	return args.Get(0).([]store.StockSnapshot), args.Error(1)
+}
+
+func (m *storeMock) ListPeers(_ context.Context, filter *store.PeerFilter) ([]store.StockSnapshot, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]store.StockSnapshot), args.Error(1)
+}
+
+func (m *storeMock) CreateStock(_ context.Context, stock *store.CreateStock) (*store.Stock, error) {
+	args := m.Called(stock)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.Stock), args.Error(1)
+}
+
+func (m *storeMock) UpdateStock(_ context.Context, stock *store.UpdateStock) (*store.Stock, error) {
+	args := m.Called(stock)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.Stock), args.Error(1)
+}
+
+func (m *storeMock) DeactivateStock(_ context.Context, symbol string) error {
+	args := m.Called(symbol)
+	return args.Error(0)
+}
+
 func (m *storeMock) FindStockBySymbol(_ context.Context, symbol string) (*store.Stock, error) {
 	args := m.Called(symbol)
 	if args.Get(0) == nil {
@@ -424,17 +706,37 @@ func (m *storeMock) FindStockBySymbol(_ context.Context, symbol string) (*store.
 	return args.Get(0).(*store.Stock), args.Error(1)
 }
 
+func (m *storeMock) UpdateStockClassification(
+	_ context.Context,
+	stockID uuid.UUID,
+	sector, industry string,
+) (*store.Stock, error) {
+	args := m.Called(stockID, sector, industry)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*store.Stock), args.Error(1)
+}
+
+func (m *storeMock) FindMetricDistributions(
+	_ context.Context,
+	group store.PeerGroup,
+	name string,
+	asOf time.Time,
+) ([]store.MetricDistribution, error) {
+	args := m.Called(group, name, asOf)
+
+	return args.Get(0).([]store.MetricDistribution), args.Error(1)
+}
+
 func (m *storeMock) ListMetrics(_ context.Context, limit, offset int) ([]store.Metric, error) {
 	args := m.Called(limit, offset)
 	return args.Get(0).([]store.Metric), args.Error(1)
 }
 
-func (m *storeMock) CreateStockMetric(
-	_ context.Context,
-	storeID, metricID uuid.UUID,
-	value float64,
-) (*store.StockMetric, error) {
-	args := m.Called(storeID, metricID)
+func (m *storeMock) CreateStockMetric(_ context.Context, metric *store.CreateStockMetric) (*store.StockMetric, error) {
+	args := m.Called(metric)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -447,6 +749,33 @@ func (m *storeMock) FindLatestStockMetrics(_ context.Context, stockID uuid.UUID)
 	return args.Get(0).([]store.LatestStockMetric), args.Error(1)
 }
 
+func (m *storeMock) FindStockMetricsAsOf(
+	_ context.Context,
+	stockID uuid.UUID,
+	asOf time.Time,
+) ([]store.LatestStockMetric, error) {
+	args := m.Called(stockID, asOf)
+	return args.Get(0).([]store.LatestStockMetric), args.Error(1)
+}
+
+func (m *storeMock) FindStockMetricHistory(
+	_ context.Context,
+	from, until time.Time,
+) ([]store.HistoricalStockMetric, error) {
+	args := m.Called(from, until)
+	return args.Get(0).([]store.HistoricalStockMetric), args.Error(1)
+}
+
+func (m *storeMock) SaveStockPrices(_ context.Context, prices []store.StockPrice) error {
+	args := m.Called(prices)
+	return args.Error(0)
+}
+
+func (m *storeMock) ListStockPrices(_ context.Context, from, to time.Time) ([]store.StockPrice, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]store.StockPrice), args.Error(1)
+}
+
 func (m *storeMock) CreateAnalysis(
 	_ context.Context,
 	userID, stockID uuid.UUID,
@@ -459,6 +788,114 @@ func (m *storeMock) CreateAnalysis(
 	return args.Get(0).(*store.Analysis), args.Error(1)
 }
 
+func (m *storeMock) FindAnalysis(_ context.Context, id uuid.UUID) (*store.AnalysisRecommendation, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.AnalysisRecommendation), args.Error(1)
+}
+
+func (m *storeMock) ListAnalyses(
+	_ context.Context,
+	filter *store.AnalysisFilter,
+) ([]store.AnalysisRecommendation, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]store.AnalysisRecommendation), args.Error(1)
+}
+
+func (m *storeMock) ListRecommendationHistory(
+	_ context.Context,
+	filter *store.AnalysisFilter,
+) ([]store.AnalysisRecommendation, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]store.AnalysisRecommendation), args.Error(1)
+}
+
+func (m *storeMock) CreateAnalysisJob(_ context.Context, job *store.CreateAnalysisJob) (*store.AnalysisJob, error) {
+	args := m.Called(job)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.AnalysisJob), args.Error(1)
+}
+
+func (m *storeMock) FindAnalysisJob(_ context.Context, id uuid.UUID) (*store.AnalysisJob, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.AnalysisJob), args.Error(1)
+}
+
+func (m *storeMock) StartAnalysisJob(_ context.Context, id uuid.UUID) (*store.AnalysisJob, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.AnalysisJob), args.Error(1)
+}
+
+func (m *storeMock) UpdateAnalysisJobResults(
+	_ context.Context,
+	id uuid.UUID,
+	results []store.AnalysisJobResult,
+) error {
+	args := m.Called(id, results)
+	return args.Error(0)
+}
+
+func (m *storeMock) FinishAnalysisJob(_ context.Context, id uuid.UUID, status store.JobStatus, errMsg string) error {
+	args := m.Called(id, status, errMsg)
+	return args.Error(0)
+}
+
+func (m *storeMock) EnqueueJob(_ context.Context, job *store.EnqueueJob) (*store.Job, error) {
+	args := m.Called(job)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.Job), args.Error(1)
+}
+
+func (m *storeMock) StartScheduledRun(_ context.Context, id uuid.UUID) (*store.ScheduledRun, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*store.ScheduledRun), args.Error(1)
+}
+
+func (m *storeMock) UpdateScheduledRunProgress(
+	_ context.Context,
+	id uuid.UUID,
+	total, succeeded, failed int,
+	lastSymbol string,
+) error {
+	args := m.Called(id, total, succeeded, failed, lastSymbol)
+	return args.Error(0)
+}
+
+func (m *storeMock) FinishScheduledRun(_ context.Context, id uuid.UUID, status store.JobStatus, errMsg string) error {
+	args := m.Called(id, status, errMsg)
+	return args.Error(0)
+}
+
+func (m *storeMock) PauseScheduledRun(
+	_ context.Context,
+	run *store.ScheduledRun,
+	errMsg string,
+	resumeAt time.Time,
+) error {
+	args := m.Called(run, errMsg, resumeAt)
+	return args.Error(0)
+}
+
+func (m *storeMock) ReserveProviderCall(_ context.Context, call *store.ReserveProviderCall) (time.Time, error) {
+	args := m.Called(call)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
 func (m *storeMock) CreateRecommendation(
 	_ context.Context,
 	analysisID uuid.UUID,
@@ -0,0 +1,357 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/store"
+)
+
+type watchlistReq struct {
+	Name string `json:"name"`
+}
+
+type watchlistItemReq struct {
+	Symbol string `json:"symbol"`
+}
+
+type watchlistResp struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type watchlistsResp struct {
+	Watchlists []watchlistResp `json:"watchlists"`
+}
+
+// watchlistItemResp holds a stock of a watchlist. The price, score and action are left out
+// for the stocks not yet priced or analyzed.
+type watchlistItemResp struct {
+	Symbol   string     `json:"symbol"`
+	Company  string     `json:"company"`
+	Exchange string     `json:"exchange,omitempty"`
+	Currency string     `json:"currency,omitempty"`
+	Price    *float64   `json:"price,omitempty"`
+	PricedAt *time.Time `json:"priced_at,omitempty"`
+	Score    *float64   `json:"score,omitempty"`
+	Action   string     `json:"action,omitempty"`
+	ScoredAt *time.Time `json:"scored_at,omitempty"`
+	AddedAt  time.Time  `json:"added_at"`
+}
+
+type watchlistItemsResp struct {
+	watchlistResp
+
+	Items []watchlistItemResp `json:"items"`
+}
+
+// CreateWatchlistHandler creates a watchlist of the current user, e.g. {"name": "Dividends"}.
+func (s *Server) CreateWatchlistHandler(w http.ResponseWriter, r *http.Request) {
+	var req watchlistReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		s.log.Error("Failed to resolve the current user", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	watchlist, err := s.store.CreateWatchlist(ctx, &store.CreateWatchlist{UserID: user.ID, Name: req.Name})
+	if err != nil {
+		s.handleWatchlistError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(toWatchlistResp(watchlist)); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ListWatchlistsHandler lists the watchlists of the current user, ordered by name.
+func (s *Server) ListWatchlistsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		s.log.Error("Failed to resolve the current user", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	watchlists, err := s.store.ListWatchlists(ctx, user.ID)
+	if err != nil {
+		s.handleWatchlistError(w, err)
+		return
+	}
+
+	resp := watchlistsResp{Watchlists: make([]watchlistResp, 0, len(watchlists))}
+	for _, watchlist := range watchlists {
+		resp.Watchlists = append(resp.Watchlists, toWatchlistResp(&watchlist))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetWatchlistHandler returns a watchlist of the current user along with its stocks in the order they were added,
+// with their latest close price, score and recommended action.
+func (s *Server) GetWatchlistHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	watchlist, err := s.findUserWatchlist(ctx, id)
+	if err != nil {
+		s.handleWatchlistError(w, err)
+		return
+	}
+
+	s.writeWatchlistItems(ctx, w, http.StatusOK, watchlist)
+}
+
+// RenameWatchlistHandler renames a watchlist of the current user, e.g. {"name": "Growth"}.
+func (s *Server) RenameWatchlistHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req watchlistReq
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	if _, err = s.findUserWatchlist(ctx, id); err != nil {
+		s.handleWatchlistError(w, err)
+		return
+	}
+
+	watchlist, err := s.store.RenameWatchlist(ctx, &store.RenameWatchlist{ID: id, Name: req.Name})
+	if err != nil {
+		s.handleWatchlistError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(toWatchlistResp(watchlist)); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// DeleteWatchlistHandler deletes a watchlist of the current user along with its items.
+func (s *Server) DeleteWatchlistHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	if _, err = s.findUserWatchlist(ctx, id); err != nil {
+		s.handleWatchlistError(w, err)
+		return
+	}
+
+	if err = s.store.DeleteWatchlist(ctx, id); err != nil {
+		s.handleWatchlistError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddWatchlistItemHandler adds a stock to a watchlist of the current user, e.g. {"symbol": "AAPL"},
+// and returns the watchlist along with its stocks.
+func (s *Server) AddWatchlistItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req watchlistItemReq
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	watchlist, err := s.findUserWatchlist(ctx, id)
+	if err != nil {
+		s.handleWatchlistError(w, err)
+		return
+	}
+
+	stock, err := s.store.FindStockBySymbol(ctx, req.Symbol)
+	if err != nil {
+		s.handleStockError(w, err)
+		return
+	}
+
+	if err = s.store.AddWatchlistItem(ctx, id, stock.ID); err != nil {
+		if errors.Is(err, store.ErrAlreadyExists) {
+			http.Error(w, "Stock already in watchlist", http.StatusConflict)
+			return
+		}
+
+		s.handleWatchlistError(w, err)
+		return
+	}
+
+	s.writeWatchlistItems(ctx, w, http.StatusCreated, watchlist)
+}
+
+// RemoveWatchlistItemHandler removes a stock from a watchlist of the current user.
+func (s *Server) RemoveWatchlistItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout*time.Second)
+	defer cancel()
+
+	if _, err = s.findUserWatchlist(ctx, id); err != nil {
+		s.handleWatchlistError(w, err)
+		return
+	}
+
+	stock, err := s.store.FindStockBySymbol(ctx, r.PathValue("symbol"))
+	if err != nil {
+		s.handleStockError(w, err)
+		return
+	}
+
+	if err = s.store.RemoveWatchlistItem(ctx, id, stock.ID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Stock not in watchlist", http.StatusNotFound)
+			return
+		}
+
+		s.handleWatchlistError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findUserWatchlist returns a watchlist of the current user.
+// Watchlists of other users are reported as missing.
+func (s *Server) findUserWatchlist(ctx context.Context, id uuid.UUID) (*store.Watchlist, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("resolving the current user: %w", err)
+	}
+
+	watchlist, err := s.store.FindWatchlist(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if watchlist.UserID != user.ID {
+		return nil, store.ErrNotFound
+	}
+
+	return watchlist, nil
+}
+
+// writeWatchlistItems writes a watchlist along with its stocks.
+func (s *Server) writeWatchlistItems(
+	ctx context.Context,
+	w http.ResponseWriter,
+	status int,
+	watchlist *store.Watchlist,
+) {
+	items, err := s.store.ListWatchlistItems(ctx, watchlist.ID)
+	if err != nil {
+		s.handleWatchlistError(w, err)
+		return
+	}
+
+	resp := watchlistItemsResp{
+		watchlistResp: toWatchlistResp(watchlist),
+		Items:         make([]watchlistItemResp, 0, len(items)),
+	}
+	for _, item := range items {
+		resp.Items = append(resp.Items, watchlistItemResp{
+			Symbol:   item.Symbol,
+			Company:  item.Company,
+			Exchange: item.Exchange,
+			Currency: item.Currency,
+			Price:    item.Price,
+			PricedAt: item.PricedAt,
+			Score:    item.Score,
+			Action:   string(item.Action),
+			ScoredAt: item.ScoredAt,
+			AddedAt:  item.AddedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleWatchlistError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		http.Error(w, "Watchlist not found", http.StatusNotFound)
+	case errors.Is(err, store.ErrAlreadyExists):
+		http.Error(w, "Watchlist already exists", http.StatusConflict)
+	case errors.As(err, &store.ValidationError{}):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		s.log.Error("Failed to manage watchlists", lctx.Error("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func toWatchlistResp(watchlist *store.Watchlist) watchlistResp {
+	return watchlistResp{
+		ID:        watchlist.ID.String(),
+		Name:      watchlist.Name,
+		CreatedAt: watchlist.CreatedAt,
+		UpdatedAt: watchlist.UpdatedAt,
+	}
+}
@@ -0,0 +1,629 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestServer_CreateWatchlistHandler(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	watchlistID := uuid.New()
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+
+		sendBody string
+		setup    func(m *storeMock)
+
+		wantStatus int
+		wantResult string
+	}{
+		{
+			name: "creates watchlist",
+
+			sendBody: `{"name": "Dividends"}`,
+			setup: func(m *storeMock) {
+				m.On("CreateWatchlist", &store.CreateWatchlist{UserID: userID, Name: "Dividends"}).
+					Return(&store.Watchlist{
+						Model:  store.Model{ID: watchlistID, CreatedAt: createdAt, UpdatedAt: createdAt},
+						UserID: userID,
+						Name:   "Dividends",
+					}, nil)
+			},
+
+			wantStatus: http.StatusCreated,
+			wantResult: `{
+				"id": "` + watchlistID.String() + `",
+				"name": "Dividends",
+				"created_at": "2024-03-01T10:00:00Z",
+				"updated_at": "2024-03-01T10:00:00Z"
+			}`,
+		},
+		{
+			name: "handles existing watchlist name",
+
+			sendBody: `{"name": "Dividends"}`,
+			setup: func(m *storeMock) {
+				m.On("CreateWatchlist", mock.Anything).Return(nil, store.ErrAlreadyExists)
+			},
+
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "handles invalid watchlist",
+
+			sendBody: `{"name": " "}`,
+			setup: func(m *storeMock) {
+				m.On("CreateWatchlist", mock.Anything).Return(nil, store.ValidationError{Err: "name is required"})
+			},
+
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "handles invalid payload",
+
+			sendBody: `{"name": 1}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.setup != nil {
+				storeMock.On("ProvisionUser", mock.Anything).Return(&store.User{Model: store.Model{ID: userID}}, nil)
+				test.setup(storeMock)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/watchlists", bytes.NewBufferString(test.sendBody))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if test.wantResult != "" {
+				assert.JSONEq(t, test.wantResult, rr.Body.String())
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_GetWatchlistHandler(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	watchlistID := uuid.New()
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	pricedAt := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	price := 172.5
+	score := 7.25
+
+	tests := []struct {
+		name string
+
+		path  string
+		setup func(m *storeMock)
+
+		wantStatus int
+		wantResult string
+	}{
+		{
+			name: "returns watchlist with latest prices and scores",
+
+			path: "/watchlists/" + watchlistID.String(),
+			setup: func(m *storeMock) {
+				m.On("FindWatchlist", watchlistID).Return(&store.Watchlist{
+					Model:  store.Model{ID: watchlistID, CreatedAt: createdAt, UpdatedAt: createdAt},
+					UserID: userID,
+					Name:   "Tech",
+				}, nil)
+				m.On("ListWatchlistItems", watchlistID).Return([]store.WatchlistItem{
+					{
+						Stock:    store.Stock{Symbol: "AAPL", Company: "Apple Inc", Exchange: "NASDAQ", Currency: "USD"},
+						AddedAt:  createdAt,
+						Price:    &price,
+						PricedAt: &pricedAt,
+						Score:    &score,
+						ScoredAt: &pricedAt,
+						Action:   store.ActionBuy,
+					},
+					{
+						Stock:   store.Stock{Symbol: "NEW", Company: "New Corp"},
+						AddedAt: createdAt,
+					},
+				}, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: `{
+				"id": "` + watchlistID.String() + `",
+				"name": "Tech",
+				"created_at": "2024-03-01T10:00:00Z",
+				"updated_at": "2024-03-01T10:00:00Z",
+				"items": [
+					{
+						"symbol": "AAPL",
+						"company": "Apple Inc",
+						"exchange": "NASDAQ",
+						"currency": "USD",
+						"price": 172.5,
+						"priced_at": "2024-03-04T00:00:00Z",
+						"score": 7.25,
+						"action": "ActionBuy",
+						"scored_at": "2024-03-04T00:00:00Z",
+						"added_at": "2024-03-01T10:00:00Z"
+					},
+					{
+						"symbol": "NEW",
+						"company": "New Corp",
+						"added_at": "2024-03-01T10:00:00Z"
+					}
+				]
+			}`,
+		},
+		{
+			name: "hides watchlist of other user",
+
+			path: "/watchlists/" + watchlistID.String(),
+			setup: func(m *storeMock) {
+				m.On("FindWatchlist", watchlistID).Return(&store.Watchlist{UserID: uuid.New()}, nil)
+			},
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "handles store error",
+
+			path: "/watchlists/" + watchlistID.String(),
+			setup: func(m *storeMock) {
+				m.On("FindWatchlist", watchlistID).Return(nil, errors.New("test error"))
+			},
+
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "handles invalid id",
+
+			path: "/watchlists/abc",
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.setup != nil {
+				storeMock.On("ProvisionUser", mock.Anything).Return(&store.User{Model: store.Model{ID: userID}}, nil)
+				test.setup(storeMock)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, test.path, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if test.wantResult != "" {
+				assert.JSONEq(t, test.wantResult, rr.Body.String())
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_RenameWatchlistHandler(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	watchlistID := uuid.New()
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	updatedAt := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+
+		sendBody string
+		setup    func(m *storeMock)
+
+		wantStatus int
+		wantResult string
+	}{
+		{
+			name: "renames watchlist",
+
+			sendBody: `{"name": "Growth"}`,
+			setup: func(m *storeMock) {
+				m.On("FindWatchlist", watchlistID).Return(&store.Watchlist{UserID: userID}, nil)
+				m.On("RenameWatchlist", &store.RenameWatchlist{ID: watchlistID, Name: "Growth"}).
+					Return(&store.Watchlist{
+						Model:  store.Model{ID: watchlistID, CreatedAt: createdAt, UpdatedAt: updatedAt},
+						UserID: userID,
+						Name:   "Growth",
+					}, nil)
+			},
+
+			wantStatus: http.StatusOK,
+			wantResult: `{
+				"id": "` + watchlistID.String() + `",
+				"name": "Growth",
+				"created_at": "2024-03-01T10:00:00Z",
+				"updated_at": "2024-03-02T10:00:00Z"
+			}`,
+		},
+		{
+			name: "handles existing watchlist name",
+
+			sendBody: `{"name": "Growth"}`,
+			setup: func(m *storeMock) {
+				m.On("FindWatchlist", watchlistID).Return(&store.Watchlist{UserID: userID}, nil)
+				m.On("RenameWatchlist", mock.Anything).Return(nil, store.ErrAlreadyExists)
+			},
+
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "hides watchlist of other user",
+
+			sendBody: `{"name": "Growth"}`,
+			setup: func(m *storeMock) {
+				m.On("FindWatchlist", watchlistID).Return(&store.Watchlist{UserID: uuid.New()}, nil)
+			},
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "handles invalid payload",
+
+			sendBody: `{"name": 1}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.setup != nil {
+				storeMock.On("ProvisionUser", mock.Anything).Return(&store.User{Model: store.Model{ID: userID}}, nil)
+				test.setup(storeMock)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(
+				ctx,
+				http.MethodPut,
+				"/watchlists/"+watchlistID.String(),
+				bytes.NewBufferString(test.sendBody),
+			)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if test.wantResult != "" {
+				assert.JSONEq(t, test.wantResult, rr.Body.String())
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_AddWatchlistItemHandler(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	watchlistID := uuid.New()
+	stockID := uuid.New()
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	watchlist := &store.Watchlist{
+		Model:  store.Model{ID: watchlistID, CreatedAt: createdAt, UpdatedAt: createdAt},
+		UserID: userID,
+		Name:   "Tech",
+	}
+
+	tests := []struct {
+		name string
+
+		sendBody string
+		setup    func(m *storeMock)
+
+		wantStatus int
+		wantResult string
+	}{
+		{
+			name: "adds stock to watchlist",
+
+			sendBody: `{"symbol": "aapl"}`,
+			setup: func(m *storeMock) {
+				m.On("FindWatchlist", watchlistID).Return(watchlist, nil)
+				m.On("FindStockBySymbol", "aapl").Return(&store.Stock{Model: store.Model{ID: stockID}}, nil)
+				m.On("AddWatchlistItem", watchlistID, stockID).Return(nil)
+				m.On("ListWatchlistItems", watchlistID).Return([]store.WatchlistItem{
+					{Stock: store.Stock{Symbol: "AAPL", Company: "Apple Inc"}, AddedAt: createdAt},
+				}, nil)
+			},
+
+			wantStatus: http.StatusCreated,
+			wantResult: `{
+				"id": "` + watchlistID.String() + `",
+				"name": "Tech",
+				"created_at": "2024-03-01T10:00:00Z",
+				"updated_at": "2024-03-01T10:00:00Z",
+				"items": [
+					{"symbol": "AAPL", "company": "Apple Inc", "added_at": "2024-03-01T10:00:00Z"}
+				]
+			}`,
+		},
+		{
+			name: "handles stock already in watchlist",
+
+			sendBody: `{"symbol": "AAPL"}`,
+			setup: func(m *storeMock) {
+				m.On("FindWatchlist", watchlistID).Return(watchlist, nil)
+				m.On("FindStockBySymbol", "AAPL").Return(&store.Stock{Model: store.Model{ID: stockID}}, nil)
+				m.On("AddWatchlistItem", watchlistID, stockID).Return(store.ErrAlreadyExists)
+			},
+
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "handles unknown stock",
+
+			sendBody: `{"symbol": "NOPE"}`,
+			setup: func(m *storeMock) {
+				m.On("FindWatchlist", watchlistID).Return(watchlist, nil)
+				m.On("FindStockBySymbol", "NOPE").Return(nil, store.ErrNotFound)
+			},
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "handles invalid payload",
+
+			sendBody: `{"symbol": 1}`,
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.setup != nil {
+				storeMock.On("ProvisionUser", mock.Anything).Return(&store.User{Model: store.Model{ID: userID}}, nil)
+				test.setup(storeMock)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(
+				ctx,
+				http.MethodPost,
+				"/watchlists/"+watchlistID.String()+"/items",
+				bytes.NewBufferString(test.sendBody),
+			)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			if test.wantResult != "" {
+				assert.JSONEq(t, test.wantResult, rr.Body.String())
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_RemoveWatchlistItemHandler(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	watchlistID := uuid.New()
+	stockID := uuid.New()
+
+	tests := []struct {
+		name string
+
+		path  string
+		setup func(m *storeMock)
+
+		wantStatus int
+	}{
+		{
+			name: "removes stock from watchlist",
+
+			path: "/watchlists/" + watchlistID.String() + "/items/AAPL",
+			setup: func(m *storeMock) {
+				m.On("FindWatchlist", watchlistID).Return(&store.Watchlist{UserID: userID}, nil)
+				m.On("FindStockBySymbol", "AAPL").Return(&store.Stock{Model: store.Model{ID: stockID}}, nil)
+				m.On("RemoveWatchlistItem", watchlistID, stockID).Return(nil)
+			},
+
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "handles stock not in watchlist",
+
+			path: "/watchlists/" + watchlistID.String() + "/items/AAPL",
+			setup: func(m *storeMock) {
+				m.On("FindWatchlist", watchlistID).Return(&store.Watchlist{UserID: userID}, nil)
+				m.On("FindStockBySymbol", "AAPL").Return(&store.Stock{Model: store.Model{ID: stockID}}, nil)
+				m.On("RemoveWatchlistItem", watchlistID, stockID).Return(store.ErrNotFound)
+			},
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "hides watchlist of other user",
+
+			path: "/watchlists/" + watchlistID.String() + "/items/AAPL",
+			setup: func(m *storeMock) {
+				m.On("FindWatchlist", watchlistID).Return(&store.Watchlist{UserID: uuid.New()}, nil)
+			},
+
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "handles invalid id",
+
+			path: "/watchlists/abc/items/AAPL",
+
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cookieMock := api.ServerCookieConfig{
+				Name:     "test_access_token",
+				Path:     "/",
+				HttpOnly: false,
+				Secure:   false,
+			}
+
+			storeMock := &storeMock{}
+			if test.setup != nil {
+				storeMock.On("ProvisionUser", mock.Anything).Return(&store.User{Model: store.Model{ID: userID}}, nil)
+				test.setup(storeMock)
+			}
+
+			authMock := &authenticatorMock{}
+			idToken := createIDToken(t)
+			authMock.On("ExtractTokenFromRequest").Return("valid-token")
+			authMock.On("VerifyAccessToken", &oauth2.Token{AccessToken: "valid-token"}).Return(idToken, nil)
+
+			obsvr := observe.NewFake()
+			srv := api.New(testAPIKey, testScoringFilePath, cookieMock, storeMock, authMock, obsvr)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodDelete, test.path, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantStatus, rr.Code)
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
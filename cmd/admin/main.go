@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hamba/cmd/v2"
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/hamba/logger/v2"
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/pkg/listing"
+	"github.com/huy125/finscope/store"
+	"github.com/urfave/cli/v2"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// importTimeout bounds the import of a listing file.
+const importTimeout = 10 * time.Minute
+
+// Config holds the administration configuration parameters, read from the API server configuration file.
+type Config struct {
+	Pool PoolConfig `json:"pool"`
+}
+
+// PoolConfig holds database specific configuration.
+type PoolConfig struct {
+	MaxConns        int32 `json:"maxConnections"`
+	MinConns        int32 `json:"minConnections"`
+	MaxConnIdleTime int32 `json:"maxConnectionIdleTime"`
+	MaxConnLifetime int32 `json:"maxConnectionLifetime"`
+}
+
+func main() {
+	flags := cmd.Flags{
+		&cli.StringFlag{
+			Name:     "configPath",
+			Usage:    "Path to API server configuration file",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "dsn",
+			Usage:    "Data source name",
+			Required: true,
+		},
+	}.Merge(cmd.MonitoringFlags)
+
+	app := cli.NewApp()
+	app.Name = "financial-admin"
+	app.Usage = "Administers the stock universe"
+	app.Flags = flags
+	app.Commands = []*cli.Command{
+		{
+			Name:      "import-stocks",
+			Usage:     "Imports a listing file into the stock universe",
+			UsageText: "financial-admin --configPath=config.json --dsn=postgres://... import-stocks --file=listing_status.csv",
+			Description: "Reads an Alpha Vantage LISTING_STATUS CSV file or a generic symbol,name,exchange,asset_type " +
+				"CSV file. Listed stocks are added or updated, relisted stocks are reactivated and the stocks " +
+				"missing from the exchanges of the file or listed as delisted are deactivated.",
+			Flags: []cli.Flag{
+				&cli.PathFlag{
+					Name:     "file",
+					Usage:    "Path to the listing CSV file",
+					Required: true,
+				},
+				&cli.StringSliceFlag{
+					Name:  "assetType",
+					Usage: "Asset types to import, e.g. Stock or ETF",
+					Value: cli.NewStringSlice("Stock"),
+				},
+				&cli.StringFlag{
+					Name:  "currency",
+					Usage: "Currency of the added stocks",
+					Value: "USD",
+				},
+				&cli.BoolFlag{
+					Name:  "dryRun",
+					Usage: "Reports the changes without applying them",
+				},
+			},
+			Action: runImportStocks,
+		},
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// Run CLI app
+	if err := app.RunContext(ctx, os.Args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+	}
+}
+
+func runImportStocks(c *cli.Context) error {
+	obsrv, err := observe.NewFromCLI(c, "finscope-admin", &observe.Options{
+		LogTimestamps: true,
+		LogTimeFormat: logger.TimeFormatISO8601,
+		TracingAttrs:  []attribute.KeyValue{semconv.ServiceVersionKey.String("1.0.0")},
+	})
+	if err != nil {
+		return err
+	}
+	defer obsrv.Close()
+
+	listings, err := readListings(c.Path("file"))
+	if err != nil {
+		obsrv.Log.Error("Could not read listing file", lctx.Error("error", err))
+		return err
+	}
+
+	s, err := setupStore(c)
+	if err != nil {
+		obsrv.Log.Error("Could not set up store", lctx.Error("error", err))
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context, importTimeout)
+	defer cancel()
+
+	changes, err := s.ImportStocks(ctx, &store.ImportStocks{
+		Listings:   listings,
+		Currency:   c.String("currency"),
+		AssetTypes: c.StringSlice("assetType"),
+		DryRun:     c.Bool("dryRun"),
+	})
+	if err != nil {
+		obsrv.Log.Error("Could not import stocks", lctx.Error("error", err))
+		return err
+	}
+
+	return writeReport(c.App.Writer, changes, c.Bool("dryRun"))
+}
+
+// readListings reads the listings of a file.
+func readListings(path string) ([]listing.Listing, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening listing file: %w", err)
+	}
+	defer f.Close()
+
+	listings, err := listing.Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading listing file: %w", err)
+	}
+
+	return listings, nil
+}
+
+// setupStore creates the store of the configured database.
+func setupStore(c *cli.Context) (*store.Store, error) {
+	cfg, err := loadConfigFromFile(c.String("configPath"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	db, err := store.NewDB(
+		store.WithDSN(c.String("dsn")),
+		store.WithMaxConns(cfg.Pool.MaxConns),
+		store.WithMinConns(cfg.Pool.MinConns),
+		store.WithMaxConnLifetime(time.Minute*time.Duration(cfg.Pool.MaxConnLifetime)),
+		store.WithMaxConnIdleTime(time.Minute*time.Duration(cfg.Pool.MaxConnIdleTime)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up database: %w", err)
+	}
+
+	return store.New(db), nil
+}
+
+// writeReport writes the changes of an import, one per line, followed by their count by kind.
+func writeReport(w io.Writer, changes []listing.Change, dryRun bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	counts := map[listing.ChangeKind]int{}
+	for _, change := range changes {
+		counts[change.Kind]++
+
+		switch change.Kind {
+		case listing.Added:
+			fmt.Fprintf(tw, "+\t%s\t%s\t%s\n", change.Symbol(), change.After.Name, change.After.Exchange)
+		case listing.Delisted:
+			fmt.Fprintf(tw, "-\t%s\t%s\t%s\n", change.Symbol(), change.Before.Name, change.Before.Exchange)
+		default:
+			fmt.Fprintf(tw, "~\t%s\t%s\t%s\t(%s, was %s %s)\n",
+				change.Symbol(),
+				change.After.Name,
+				change.After.Exchange,
+				change.Kind,
+				change.Before.Name,
+				change.Before.Exchange,
+			)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%d added, %d updated, %d relisted, %d delisted\n",
+		counts[listing.Added],
+		counts[listing.Updated],
+		counts[listing.Relisted],
+		counts[listing.Delisted],
+	)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		_, err = fmt.Fprintln(w, "Dry run, no change applied")
+	}
+	return err
+}
+
+// loadConfigFromFile loads configuration from file.
+func loadConfigFromFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// Validate checks if the Config object has all required fields filled in.
+func (c *Config) Validate() error {
+	if c.Pool.MaxConns <= 0 {
+		return errors.New("max connections is required")
+	}
+	if c.Pool.MinConns <= 0 {
+		return errors.New("min connections is required")
+	}
+
+	return nil
+}
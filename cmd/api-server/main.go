@@ -11,6 +11,7 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Embeds the time zones of the schedules.
 
 	"github.com/hamba/cmd/v2"
 	"github.com/hamba/cmd/v2/observe"
@@ -19,6 +20,7 @@ import (
 	"github.com/hamba/pkg/v2/http/server"
 	"github.com/huy125/finscope/api"
 	"github.com/huy125/finscope/pkg/authenticator"
+	"github.com/huy125/finscope/pkg/cron"
 	"github.com/huy125/finscope/store"
 	"github.com/urfave/cli/v2"
 	"go.opentelemetry.io/otel/attribute"
@@ -27,10 +29,12 @@ import (
 
 // Config holds application configuration parameters.
 type Config struct {
-	API       APIConfig    `json:"api"`
-	Pool      PoolConfig   `json:"pool"`
-	Auth      AuthConfig   `json:"auth"`
-	CookieCfg CookieConfig `json:"cookieConfig"`
+	API       APIConfig       `json:"api"`
+	Pool      PoolConfig      `json:"pool"`
+	Auth      AuthConfig      `json:"auth"`
+	CookieCfg CookieConfig    `json:"cookieConfig"`
+	Worker    WorkerConfig    `json:"worker"`
+	Scheduler SchedulerConfig `json:"scheduler"`
 }
 
 // APIConfig holds API specific configurations.
@@ -39,6 +43,12 @@ type APIConfig struct {
 	AlgorithmPath string `json:"algorithmPath"`
 	Host          string `json:"host"`
 	Port          string `json:"port"`
+	// RequestsPerMinute limits the calls to the financial provider, unlimited if not set.
+	RequestsPerMinute int `json:"requestsPerMinute"`
+	// RequestsPerDay limits the calls to the financial provider per UTC day, unlimited if not set.
+	RequestsPerDay int `json:"requestsPerDay"`
+	// WriteTimeout is the time in seconds the server has to write a response, it must exceed the handler timeouts.
+	WriteTimeout int `json:"writeTimeout"`
 }
 
 // PoolConfig holds database specific configuration.
@@ -60,6 +70,31 @@ type AuthConfig struct {
 	ClientOrigin string `json:"clientOrigin"`
 }
 
+// WorkerConfig holds background job worker specific configurations.
+type WorkerConfig struct {
+	// InProcess runs the jobs inside the API server, otherwise they are left to the worker command.
+	InProcess   bool `json:"inProcess"`
+	Concurrency int  `json:"concurrency"`
+	// PollInterval is the interval in seconds at which the queue is polled when no job is due.
+	PollInterval int `json:"pollInterval"`
+	// VisibilityTimeout is the time in seconds after which a job abandoned by its worker is run again.
+	VisibilityTimeout int `json:"visibilityTimeout"`
+}
+
+// SchedulerConfig holds the schedules of the background jobs.
+type SchedulerConfig struct {
+	// Timezone is the IANA time zone the schedules are evaluated in, UTC if not set.
+	Timezone  string           `json:"timezone"`
+	Schedules []ScheduleConfig `json:"schedules"`
+}
+
+// ScheduleConfig holds a schedule queuing a job, e.g. "30 18 * * 1-5" for weekdays after market close.
+type ScheduleConfig struct {
+	Name string `json:"name"`
+	Cron string `json:"cron"`
+	Job  string `json:"job"`
+}
+
 // CookieConfig holds cookie specific configurations.
 type CookieConfig struct {
 	Name     string `json:"name"`
@@ -152,12 +187,44 @@ func runServer(c *cli.Context) error {
 		Secure:   cfg.CookieCfg.Secure,
 	}
 	addr := net.JoinHostPort(cfg.API.Host, cfg.API.Port)
-	h := api.New(cfg.API.Key, cfg.API.AlgorithmPath, cookieCfg, store, auth, obsrv)
+	h := api.New(
+		cfg.API.Key,
+		cfg.API.AlgorithmPath,
+		cookieCfg,
+		store,
+		auth,
+		obsrv,
+		api.WithProviderRateLimit(cfg.API.RequestsPerMinute),
+		api.WithProviderDailyLimit(cfg.API.RequestsPerDay),
+	)
+	if cfg.Worker.InProcess {
+		worker := setupWorker(store, cfg.Worker, obsrv.Log)
+		for typ, handler := range h.JobHandlers() {
+			worker.Handle(typ, handler)
+		}
+
+		// Run the queued jobs alongside the server.
+		go func() {
+			if err := worker.Run(ctx); err != nil {
+				obsrv.Log.Error("Could not run worker", lctx.Error("error", err))
+			}
+		}()
+	}
+
+	// Queue the scheduled jobs, the run log preventing duplicates with other servers and workers.
+	scheduler, err := setupScheduler(store, cfg.Scheduler, h.JobHandlers(), obsrv.Log)
+	if err != nil {
+		obsrv.Log.Error("Could not set up scheduler", lctx.Error("error", err))
+		return err
+	}
+	go scheduler.Run(ctx)
+
 	server := server.GenericServer[context.Context]{
-		Addr:    addr,
-		Handler: h,
-		Log:     obsrv.Log,
-		Stats:   obsrv.Stats,
+		Addr:         addr,
+		Handler:      h,
+		WriteTimeout: time.Second * time.Duration(cfg.API.WriteTimeout),
+		Log:          obsrv.Log,
+		Stats:        obsrv.Stats,
 	}
 
 	if err := server.Run(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -186,6 +253,46 @@ func setupDatabase(dsn string, cfg PoolConfig) (*store.Store, error) {
 	return store.New(db), nil
 }
 
+// setupWorker creates and configures a background job worker.
+func setupWorker(s *store.Store, cfg WorkerConfig, log *logger.Logger) *store.Worker {
+	return store.NewWorker(
+		s,
+		log,
+		store.WithConcurrency(cfg.Concurrency),
+		store.WithPollInterval(time.Second*time.Duration(cfg.PollInterval)),
+		store.WithVisibilityTimeout(time.Second*time.Duration(cfg.VisibilityTimeout)),
+	)
+}
+
+// setupScheduler creates a scheduler queuing the jobs of the configured schedules.
+func setupScheduler(
+	s *store.Store,
+	cfg SchedulerConfig,
+	handlers map[string]store.JobHandler,
+	log *logger.Logger,
+) (*store.Scheduler, error) {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("loading timezone: %w", err)
+	}
+
+	schedules := make([]store.Schedule, 0, len(cfg.Schedules))
+	for _, sc := range cfg.Schedules {
+		if _, ok := handlers[sc.Job]; !ok {
+			return nil, fmt.Errorf("schedule %s: unknown job %q", sc.Name, sc.Job)
+		}
+
+		expr, err := cron.Parse(sc.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", sc.Name, err)
+		}
+
+		schedules = append(schedules, store.Schedule{Name: sc.Name, JobType: sc.Job, Cron: expr})
+	}
+
+	return store.NewScheduler(s, schedules, location, log), nil
+}
+
 // setupAuthenticator creates and configures an authenticator.
 func setupAuthenticator(ctx context.Context, cfg AuthConfig, log *logger.Logger) (*authenticator.Authenticator, error) {
 	auth, err := authenticator.New(
@@ -255,6 +362,16 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if c.Worker.InProcess {
+		if err := c.Worker.validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Scheduler.validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -271,6 +388,15 @@ func (c *APIConfig) validate() error {
 	if c.Port == "" {
 		return errors.New("port is required")
 	}
+	if c.RequestsPerMinute < 0 {
+		return errors.New("requests per minute must not be negative")
+	}
+	if c.RequestsPerDay < 0 {
+		return errors.New("requests per day must not be negative")
+	}
+	if time.Second*time.Duration(c.WriteTimeout) <= api.HandlerTimeout {
+		return fmt.Errorf("write timeout must exceed the handler timeout of %s", api.HandlerTimeout)
+	}
 
 	return nil
 }
@@ -328,3 +454,43 @@ func (c *CookieConfig) validate() error {
 
 	return nil
 }
+
+func (c *WorkerConfig) validate() error {
+	if c.Concurrency <= 0 {
+		return errors.New("worker concurrency is required")
+	}
+	if c.PollInterval <= 0 {
+		return errors.New("worker poll interval is required")
+	}
+	if c.VisibilityTimeout <= 0 {
+		return errors.New("worker visibility timeout is required")
+	}
+
+	return nil
+}
+
+func (c *SchedulerConfig) validate() error {
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("invalid scheduler timezone: %w", err)
+	}
+
+	names := make(map[string]bool, len(c.Schedules))
+	for _, sc := range c.Schedules {
+		if sc.Name == "" {
+			return errors.New("schedule name is required")
+		}
+		if names[sc.Name] {
+			return fmt.Errorf("schedule %s is duplicated", sc.Name)
+		}
+		names[sc.Name] = true
+
+		if sc.Job == "" {
+			return fmt.Errorf("schedule %s: job is required", sc.Name)
+		}
+		if _, err := cron.Parse(sc.Cron); err != nil {
+			return fmt.Errorf("schedule %s: invalid cron expression: %w", sc.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Embeds the time zones of the schedules.
+
+	"github.com/hamba/cmd/v2"
+	"github.com/hamba/cmd/v2/observe"
+	"github.com/hamba/logger/v2"
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/api"
+	"github.com/huy125/finscope/pkg/cron"
+	"github.com/huy125/finscope/store"
+	"github.com/urfave/cli/v2"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// Config holds the worker configuration parameters, read from the API server configuration file.
+type Config struct {
+	API       APIConfig       `json:"api"`
+	Pool      PoolConfig      `json:"pool"`
+	Worker    WorkerConfig    `json:"worker"`
+	Scheduler SchedulerConfig `json:"scheduler"`
+}
+
+// APIConfig holds API specific configurations used by the jobs.
+type APIConfig struct {
+	Key           string `json:"key"`
+	AlgorithmPath string `json:"algorithmPath"`
+	// RequestsPerMinute limits the calls to the financial provider, unlimited if not set.
+	RequestsPerMinute int `json:"requestsPerMinute"`
+	// RequestsPerDay limits the calls to the financial provider per UTC day, unlimited if not set.
+	RequestsPerDay int `json:"requestsPerDay"`
+}
+
+// PoolConfig holds database specific configuration.
+type PoolConfig struct {
+	MaxConns        int32 `json:"maxConnections"`
+	MinConns        int32 `json:"minConnections"`
+	MaxConnIdleTime int32 `json:"maxConnectionIdleTime"`
+	MaxConnLifetime int32 `json:"maxConnectionLifetime"`
+}
+
+// WorkerConfig holds background job worker specific configurations.
+type WorkerConfig struct {
+	Concurrency int `json:"concurrency"`
+	// PollInterval is the interval in seconds at which the queue is polled when no job is due.
+	PollInterval int `json:"pollInterval"`
+	// VisibilityTimeout is the time in seconds after which a job abandoned by its worker is run again.
+	VisibilityTimeout int `json:"visibilityTimeout"`
+}
+
+// SchedulerConfig holds the schedules of the background jobs.
+type SchedulerConfig struct {
+	// Timezone is the IANA time zone the schedules are evaluated in, UTC if not set.
+	Timezone  string           `json:"timezone"`
+	Schedules []ScheduleConfig `json:"schedules"`
+}
+
+// ScheduleConfig holds a schedule queuing a job, e.g. "30 18 * * 1-5" for weekdays after market close.
+type ScheduleConfig struct {
+	Name string `json:"name"`
+	Cron string `json:"cron"`
+	Job  string `json:"job"`
+}
+
+func main() {
+	flags := cmd.Flags{
+		&cli.StringFlag{
+			Name:     "configPath",
+			Usage:    "Path to API server configuration file",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "dsn",
+			Usage:    "Data source name",
+			Required: true,
+		},
+	}.Merge(cmd.MonitoringFlags)
+
+	app := cli.NewApp()
+	app.Name = "financial-worker"
+	app.Usage = "Runs the background jobs queued by the API server"
+	app.Flags = flags
+	app.Action = runWorker
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// Run CLI app
+	if err := app.RunContext(ctx, os.Args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+	}
+}
+
+func runWorker(c *cli.Context) error {
+	obsrv, err := observe.NewFromCLI(c, "finscope-worker", &observe.Options{
+		LogTimestamps: true,
+		LogTimeFormat: logger.TimeFormatISO8601,
+		StatsRuntime:  true,
+		TracingAttrs:  []attribute.KeyValue{semconv.ServiceVersionKey.String("1.0.0")},
+	})
+	if err != nil {
+		return err
+	}
+	defer obsrv.Close()
+
+	cfg, err := loadConfigFromFile(c.String("configPath"))
+	if err != nil {
+		obsrv.Log.Error("Could not prepare config", lctx.Error("error", err))
+		return err
+	}
+
+	if err = cfg.Validate(); err != nil {
+		obsrv.Log.Error("Could not prepare config", lctx.Error("error", err))
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	db, err := store.NewDB(
+		store.WithDSN(c.String("dsn")),
+		store.WithMaxConns(cfg.Pool.MaxConns),
+		store.WithMinConns(cfg.Pool.MinConns),
+		store.WithMaxConnLifetime(time.Minute*time.Duration(cfg.Pool.MaxConnLifetime)),
+		store.WithMaxConnIdleTime(time.Minute*time.Duration(cfg.Pool.MaxConnIdleTime)),
+	)
+	if err != nil {
+		obsrv.Log.Error("Could not set up store", lctx.Error("error", err))
+		return err
+	}
+	s := store.New(db)
+
+	// The server is only used for its job handlers, it serves no request and needs no authenticator.
+	h := api.New(
+		cfg.API.Key,
+		cfg.API.AlgorithmPath,
+		api.ServerCookieConfig{},
+		s,
+		nil,
+		obsrv,
+		api.WithProviderRateLimit(cfg.API.RequestsPerMinute),
+		api.WithProviderDailyLimit(cfg.API.RequestsPerDay),
+	)
+
+	worker := store.NewWorker(
+		s,
+		obsrv.Log,
+		store.WithConcurrency(cfg.Worker.Concurrency),
+		store.WithPollInterval(time.Second*time.Duration(cfg.Worker.PollInterval)),
+		store.WithVisibilityTimeout(time.Second*time.Duration(cfg.Worker.VisibilityTimeout)),
+	)
+	for typ, handler := range h.JobHandlers() {
+		worker.Handle(typ, handler)
+	}
+
+	// Queue the scheduled jobs, the run log preventing duplicates with other workers and servers.
+	scheduler, err := setupScheduler(s, cfg.Scheduler, h.JobHandlers(), obsrv.Log)
+	if err != nil {
+		obsrv.Log.Error("Could not set up scheduler", lctx.Error("error", err))
+		return err
+	}
+	go scheduler.Run(c.Context)
+
+	obsrv.Log.Info("Worker started")
+
+	if err = worker.Run(c.Context); err != nil {
+		obsrv.Log.Error("Could not run worker", lctx.Error("error", err))
+		return err
+	}
+
+	obsrv.Log.Info("Worker terminated")
+	return nil
+}
+
+// setupScheduler creates a scheduler queuing the jobs of the configured schedules.
+func setupScheduler(
+	s *store.Store,
+	cfg SchedulerConfig,
+	handlers map[string]store.JobHandler,
+	log *logger.Logger,
+) (*store.Scheduler, error) {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("loading timezone: %w", err)
+	}
+
+	schedules := make([]store.Schedule, 0, len(cfg.Schedules))
+	for _, sc := range cfg.Schedules {
+		if _, ok := handlers[sc.Job]; !ok {
+			return nil, fmt.Errorf("schedule %s: unknown job %q", sc.Name, sc.Job)
+		}
+
+		expr, err := cron.Parse(sc.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", sc.Name, err)
+		}
+
+		schedules = append(schedules, store.Schedule{Name: sc.Name, JobType: sc.Job, Cron: expr})
+	}
+
+	return store.NewScheduler(s, schedules, location, log), nil
+}
+
+// loadConfigFromFile loads configuration from file.
+func loadConfigFromFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// Validate checks if the Config object has all required fields filled in.
+func (c *Config) Validate() error {
+	if c.API.Key == "" {
+		return errors.New("financial provider API key is required")
+	}
+	if c.API.AlgorithmPath == "" {
+		return errors.New("scoring algorithm path is required")
+	}
+	if c.API.RequestsPerMinute < 0 {
+		return errors.New("requests per minute must not be negative")
+	}
+	if c.API.RequestsPerDay < 0 {
+		return errors.New("requests per day must not be negative")
+	}
+
+	if c.Pool.MaxConns <= 0 {
+		return errors.New("max connections is required")
+	}
+	if c.Pool.MinConns <= 0 {
+		return errors.New("min connections is required")
+	}
+
+	if c.Worker.Concurrency <= 0 {
+		return errors.New("worker concurrency is required")
+	}
+	if c.Worker.PollInterval <= 0 {
+		return errors.New("worker poll interval is required")
+	}
+	if c.Worker.VisibilityTimeout <= 0 {
+		return errors.New("worker visibility timeout is required")
+	}
+
+	if _, err := time.LoadLocation(c.Scheduler.Timezone); err != nil {
+		return fmt.Errorf("invalid scheduler timezone: %w", err)
+	}
+	for _, sc := range c.Scheduler.Schedules {
+		if _, err := cron.Parse(sc.Cron); err != nil {
+			return fmt.Errorf("schedule %s: invalid cron expression: %w", sc.Name, err)
+		}
+	}
+
+	return nil
+}
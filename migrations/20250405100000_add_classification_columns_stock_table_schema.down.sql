@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_stock_industry;
+DROP INDEX IF EXISTS idx_stock_sector;
+
+ALTER TABLE stock
+    DROP COLUMN IF EXISTS sector,
+    DROP COLUMN IF EXISTS industry;
@@ -0,0 +1,6 @@
+ALTER TABLE stock
+	ADD COLUMN IF NOT EXISTS sector VARCHAR(255) NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS industry VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_stock_sector ON stock(sector);
+CREATE INDEX IF NOT EXISTS idx_stock_industry ON stock(industry);
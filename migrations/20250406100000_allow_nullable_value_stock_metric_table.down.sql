@@ -0,0 +1,4 @@
+DELETE FROM stock_metric WHERE value IS NULL;
+
+ALTER TABLE stock_metric
+ALTER COLUMN value SET NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE stock_metric
+ALTER COLUMN value DROP NOT NULL;
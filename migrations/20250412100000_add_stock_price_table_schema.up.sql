@@ -0,0 +1,20 @@
+CREATE TABLE stock_price (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    stock_id UUID REFERENCES stock(id) ON DELETE CASCADE NOT NULL,
+    date DATE NOT NULL,
+    open NUMERIC NOT NULL,
+    high NUMERIC NOT NULL,
+    low NUMERIC NOT NULL,
+    close NUMERIC NOT NULL,
+    volume BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT stock_price_stock_date_unique UNIQUE (stock_id, date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_price_date ON stock_price(date);
+
+CREATE TRIGGER update_stock_price_updated_at
+    BEFORE UPDATE ON stock_price
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
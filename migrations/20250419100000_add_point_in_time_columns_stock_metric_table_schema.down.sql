@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_stock_metric_stock_metric_reported_at;
+
+ALTER TABLE stock_metric
+    DROP COLUMN IF EXISTS period_end,
+    DROP COLUMN IF EXISTS reported_at;
@@ -0,0 +1,11 @@
+ALTER TABLE stock_metric
+	ADD COLUMN IF NOT EXISTS period_end DATE,
+	ADD COLUMN IF NOT EXISTS reported_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+-- Existing values were known from the time they were fetched.
+UPDATE stock_metric SET reported_at = created_at;
+
+ALTER TABLE stock_metric
+	ALTER COLUMN reported_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_stock_metric_stock_metric_reported_at ON stock_metric(stock_id, metric_id, reported_at);
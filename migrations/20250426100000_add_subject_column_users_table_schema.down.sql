@@ -0,0 +1,8 @@
+-- Remove constraints
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_subject_unique,
+    DROP CONSTRAINT IF EXISTS users_subject_check;
+
+-- Remove column
+ALTER TABLE users
+    DROP COLUMN IF EXISTS subject;
@@ -0,0 +1,7 @@
+-- Add the OIDC subject identifying users signing in through the identity provider
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS subject VARCHAR(255);
+
+ALTER TABLE users
+    ADD CONSTRAINT users_subject_unique UNIQUE (subject),
+    ADD CONSTRAINT users_subject_check CHECK (subject <> '');
@@ -0,0 +1,15 @@
+-- Restore the subject of users linked since, from their oldest identity
+UPDATE users u
+SET subject = i.subject
+FROM (
+    SELECT DISTINCT ON (user_id) user_id, subject
+    FROM user_identity
+    ORDER BY user_id, created_at
+) i
+WHERE u.id = i.user_id
+    AND u.subject IS NULL
+    AND NOT EXISTS (SELECT 1 FROM users other WHERE other.subject = i.subject);
+
+DROP TRIGGER IF EXISTS update_user_identity_updated_at ON user_identity;
+
+DROP TABLE IF EXISTS user_identity;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS user_identity (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	provider VARCHAR(255) NOT NULL,
+	subject VARCHAR(255) NOT NULL,
+	email VARCHAR(255),
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (provider, subject),
+	CHECK (provider <> '' AND subject <> '')
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identity_user_id ON user_identity(user_id);
+
+CREATE TRIGGER update_user_identity_updated_at
+    BEFORE UPDATE ON user_identity
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Identities replace the subject of users. The issuer of the existing subjects is unknown, so they are kept
+-- and linked to an identity on the first sign in with the subject, which clears it.
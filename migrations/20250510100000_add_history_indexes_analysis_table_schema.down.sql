@@ -0,0 +1,4 @@
+-- Remove indexes
+DROP INDEX IF EXISTS idx_recommendation_analysis_id;
+DROP INDEX IF EXISTS idx_analysis_user_id_created_at;
+DROP INDEX IF EXISTS idx_analysis_stock_id_created_at;
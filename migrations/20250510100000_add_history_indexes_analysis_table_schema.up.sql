@@ -0,0 +1,4 @@
+-- Create indexes for the analysis history lookups
+CREATE INDEX IF NOT EXISTS idx_analysis_stock_id_created_at ON analysis(stock_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_analysis_user_id_created_at ON analysis(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_recommendation_analysis_id ON recommendation(analysis_id);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS update_analysis_job_updated_at ON analysis_job;
+
+DROP TABLE IF EXISTS analysis_job;
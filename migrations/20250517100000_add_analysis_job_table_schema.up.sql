@@ -0,0 +1,21 @@
+CREATE TABLE analysis_job (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    symbols TEXT[] NOT NULL,
+    profile VARCHAR(100) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+    results JSONB NOT NULL DEFAULT '[]',
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (cardinality(symbols) > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_analysis_job_status_created_at ON analysis_job(status, created_at);
+
+CREATE TRIGGER update_analysis_job_updated_at
+    BEFORE UPDATE ON analysis_job
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS update_job_updated_at ON job;
+
+DROP TABLE IF EXISTS job;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS job (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5 CHECK (max_attempts > 0),
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Claiming only looks at jobs waiting to run or whose lock may have expired.
+CREATE INDEX IF NOT EXISTS idx_job_pending_run_at ON job(run_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_job_running_locked_until ON job(locked_until) WHERE status = 'running';
+
+CREATE TRIGGER update_job_updated_at
+    BEFORE UPDATE ON job
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,4 @@
+DELETE FROM analysis WHERE user_id IS NULL;
+
+ALTER TABLE analysis
+    ALTER COLUMN user_id SET NOT NULL;
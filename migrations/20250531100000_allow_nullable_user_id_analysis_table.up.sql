@@ -0,0 +1,3 @@
+-- Analyses made by the scheduled refresh are made on behalf of no user.
+ALTER TABLE analysis
+    ALTER COLUMN user_id DROP NOT NULL;
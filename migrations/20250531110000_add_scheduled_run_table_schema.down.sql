@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS update_scheduled_run_updated_at ON scheduled_run;
+
+DROP TABLE IF EXISTS scheduled_run;
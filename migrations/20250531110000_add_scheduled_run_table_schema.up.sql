@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS scheduled_run (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    schedule VARCHAR(100) NOT NULL,
+    job_type VARCHAR(100) NOT NULL,
+    scheduled_for TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+    total INTEGER NOT NULL DEFAULT 0,
+    succeeded INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    -- Every process running the scheduler tries to record the run, only the first one queues it.
+    UNIQUE (schedule, scheduled_for)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_run_scheduled_for ON scheduled_run(scheduled_for);
+
+CREATE TRIGGER update_scheduled_run_updated_at
+    BEFORE UPDATE ON scheduled_run
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_stock_active;
+DROP INDEX IF EXISTS idx_stock_exchange;
+
+ALTER TABLE stock
+	DROP COLUMN IF EXISTS active,
+	DROP COLUMN IF EXISTS currency,
+	DROP COLUMN IF EXISTS exchange;
@@ -0,0 +1,10 @@
+ALTER TABLE stock
+	ADD COLUMN IF NOT EXISTS exchange VARCHAR(50) NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- The seeded stocks are US large caps.
+UPDATE stock SET currency = 'USD';
+
+CREATE INDEX IF NOT EXISTS idx_stock_exchange ON stock(exchange);
+CREATE INDEX IF NOT EXISTS idx_stock_active ON stock(active);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_stock_company_trgm;
+DROP INDEX IF EXISTS idx_stock_symbol_trgm;
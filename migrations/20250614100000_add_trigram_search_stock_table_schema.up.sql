@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Trigram indexes serve the fuzzy stock search as well as the substring searches of the stock list.
+CREATE INDEX IF NOT EXISTS idx_stock_symbol_trgm ON stock USING GIN (symbol gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_stock_company_trgm ON stock USING GIN (company gin_trgm_ops);
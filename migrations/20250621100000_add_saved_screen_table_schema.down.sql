@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS saved_screen_change;
+
+DROP TABLE IF EXISTS saved_screen_member;
+
+DROP TRIGGER IF EXISTS update_saved_screen_updated_at ON saved_screen;
+
+DROP TABLE IF EXISTS saved_screen;
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS saved_screen (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    query TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name),
+    CHECK (name <> '')
+);
+
+CREATE TRIGGER update_saved_screen_updated_at
+    BEFORE UPDATE ON saved_screen
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- The stocks matching a saved screen as of its last refresh, to tell the stocks entering and leaving it.
+CREATE TABLE IF NOT EXISTS saved_screen_member (
+    screen_id UUID REFERENCES saved_screen(id) ON DELETE CASCADE NOT NULL,
+    stock_id UUID REFERENCES stock(id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (screen_id, stock_id)
+);
+
+CREATE TABLE IF NOT EXISTS saved_screen_change (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    screen_id UUID REFERENCES saved_screen(id) ON DELETE CASCADE NOT NULL,
+    run_id UUID REFERENCES scheduled_run(id) ON DELETE SET NULL,
+    entered TEXT[] NOT NULL DEFAULT '{}',
+    exited TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_screen_change_screen_id_created_at ON saved_screen_change(screen_id, created_at);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS update_analysis_latest_score ON analysis;
+
+DROP FUNCTION IF EXISTS update_latest_score();
+
+DROP TABLE IF EXISTS latest_score;
@@ -0,0 +1,34 @@
+-- The latest analysis score of each stock, kept up to date on every analysis so that rankings
+-- do not scan the analysis table.
+CREATE TABLE IF NOT EXISTS latest_score (
+    stock_id UUID PRIMARY KEY REFERENCES stock(id) ON DELETE CASCADE,
+    analysis_id UUID REFERENCES analysis(id) ON DELETE CASCADE NOT NULL,
+    score NUMERIC NOT NULL,
+    scored_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_latest_score_score ON latest_score(score);
+
+INSERT INTO latest_score (stock_id, analysis_id, score, scored_at)
+SELECT DISTINCT ON (stock_id) stock_id, id, score, created_at
+FROM analysis
+ORDER BY stock_id, created_at DESC;
+
+CREATE OR REPLACE FUNCTION update_latest_score()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO latest_score (stock_id, analysis_id, score, scored_at)
+    VALUES (NEW.stock_id, NEW.id, NEW.score, NEW.created_at)
+    ON CONFLICT (stock_id) DO UPDATE
+    SET analysis_id = EXCLUDED.analysis_id,
+        score = EXCLUDED.score,
+        scored_at = EXCLUDED.scored_at
+    WHERE latest_score.scored_at <= EXCLUDED.scored_at;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER update_analysis_latest_score
+    AFTER INSERT ON analysis
+    FOR EACH ROW
+    EXECUTE FUNCTION update_latest_score();
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS watchlist_item;
+
+DROP TRIGGER IF EXISTS update_watchlist_updated_at ON watchlist;
+
+DROP TABLE IF EXISTS watchlist;
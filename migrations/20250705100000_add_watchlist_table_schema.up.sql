@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS watchlist (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name),
+    CHECK (name <> '')
+);
+
+CREATE TRIGGER update_watchlist_updated_at
+    BEFORE UPDATE ON watchlist
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS watchlist_item (
+    watchlist_id UUID REFERENCES watchlist(id) ON DELETE CASCADE NOT NULL,
+    stock_id UUID REFERENCES stock(id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (watchlist_id, stock_id)
+);
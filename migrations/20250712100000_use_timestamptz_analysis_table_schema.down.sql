@@ -0,0 +1,5 @@
+ALTER TABLE latest_score
+    ALTER COLUMN scored_at TYPE TIMESTAMP;
+
+ALTER TABLE analysis
+    ALTER COLUMN created_at TYPE TIMESTAMP;
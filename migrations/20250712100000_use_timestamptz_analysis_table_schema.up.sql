@@ -0,0 +1,7 @@
+-- Analyses are compared with the zoned report times of stock metrics. The existing times were
+-- recorded in the time zone of the database, which the conversion interprets them in.
+ALTER TABLE analysis
+    ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE latest_score
+    ALTER COLUMN scored_at TYPE TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,2 @@
+ALTER TABLE scheduled_run
+    DROP COLUMN IF EXISTS last_symbol;
@@ -0,0 +1,3 @@
+-- Runs resume after the last stock they processed, the universe changing between their attempts.
+ALTER TABLE scheduled_run
+    ADD COLUMN IF NOT EXISTS last_symbol VARCHAR(50) NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+-- The symbols are left in their canonical form, their original form being unknown.
@@ -0,0 +1,67 @@
+-- Symbols are normalized on write to their canonical form: upper case, the share class separated
+-- with a dot and the exchange suffix of quote services, e.g. "BRK-B" becomes "BRK.B", "ABR-P-D" becomes
+-- "ABR.P.D" and "SHOP.TRT" becomes "SHOP.TO". The existing symbols are normalized alike, invalid symbols
+-- being left unchanged.
+CREATE TEMPORARY TABLE stock_symbol AS
+WITH normalized AS (
+    SELECT id, symbol, upper(btrim(btrim(btrim(symbol), '"'''))) AS symbol_upper
+    FROM stock
+), suffixed AS (
+    SELECT
+        id,
+        symbol,
+        symbol_upper,
+        CASE substring(symbol_upper FROM '\.([A-Z]+)$')
+            WHEN 'TO' THEN 'TO' WHEN 'TRT' THEN 'TO'
+            WHEN 'V' THEN 'V' WHEN 'TRV' THEN 'V'
+            WHEN 'L' THEN 'L' WHEN 'LON' THEN 'L'
+            WHEN 'DE' THEN 'DE' WHEN 'DEX' THEN 'DE'
+            WHEN 'BO' THEN 'BO' WHEN 'BSE' THEN 'BO'
+            WHEN 'SS' THEN 'SS' WHEN 'SHH' THEN 'SS'
+            WHEN 'SZ' THEN 'SZ' WHEN 'SHZ' THEN 'SZ'
+        END AS exchange
+    FROM normalized
+), split AS (
+    SELECT
+        id,
+        symbol,
+        exchange,
+        translate(
+            CASE WHEN exchange IS NULL THEN symbol_upper ELSE regexp_replace(symbol_upper, '\.[A-Z]+$', '') END,
+            '-/',
+            '..'
+        ) AS base
+    FROM suffixed
+)
+SELECT
+    id,
+    symbol,
+    CASE
+        WHEN base ~ '^[A-Z0-9&]{1,12}(\.[A-Z0-9&]{1,12}|\.P\.[A-Z0-9&]{1,12})?$' THEN base || COALESCE('.' || exchange, '')
+        ELSE symbol
+    END AS canonical
+FROM split;
+
+-- Stocks sharing a canonical symbol are left unchanged, to be merged by hand along with their history.
+DO $$
+DECLARE
+    duplicate RECORD;
+BEGIN
+    FOR duplicate IN
+        SELECT canonical, string_agg(symbol, ', ' ORDER BY symbol) AS symbols
+        FROM stock_symbol
+        GROUP BY canonical
+        HAVING count(*) > 1
+    LOOP
+        RAISE WARNING 'Stocks % share the canonical symbol %', duplicate.symbols, duplicate.canonical;
+    END LOOP;
+END $$;
+
+UPDATE stock
+SET symbol = ss.canonical
+FROM stock_symbol ss
+WHERE stock.id = ss.id
+    AND stock.symbol <> ss.canonical
+    AND ss.canonical IN (SELECT canonical FROM stock_symbol GROUP BY canonical HAVING count(*) = 1);
+
+DROP TABLE stock_symbol;
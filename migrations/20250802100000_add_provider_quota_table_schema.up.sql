@@ -0,0 +1,8 @@
+-- The calls to a stock data provider, shared by every server and worker so that together they stay
+-- within its rate limit and daily budget. The calls are counted per UTC day.
+CREATE TABLE IF NOT EXISTS provider_quota (
+    provider VARCHAR(50) PRIMARY KEY,
+    next_call_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    day DATE NOT NULL,
+    calls INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,9 @@
+UPDATE scheduled_run
+SET status = 'failed',
+    finished_at = CURRENT_TIMESTAMP
+WHERE status = 'partial';
+
+ALTER TABLE scheduled_run
+    DROP CONSTRAINT IF EXISTS scheduled_run_status_check,
+    ADD CONSTRAINT scheduled_run_status_check
+        CHECK (status IN ('pending', 'running', 'succeeded', 'failed'));
@@ -0,0 +1,5 @@
+-- A partial run stopped before refreshing every stock, it is resumed by a job queued for later.
+ALTER TABLE scheduled_run
+    DROP CONSTRAINT IF EXISTS scheduled_run_status_check,
+    ADD CONSTRAINT scheduled_run_status_check
+        CHECK (status IN ('pending', 'running', 'partial', 'succeeded', 'failed'));
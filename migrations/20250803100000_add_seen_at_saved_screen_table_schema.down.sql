@@ -0,0 +1,2 @@
+ALTER TABLE saved_screen
+    DROP COLUMN IF EXISTS seen_at;
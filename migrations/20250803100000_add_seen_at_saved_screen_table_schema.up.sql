@@ -0,0 +1,3 @@
+-- The changes of a saved screen recorded after seen_at are unread by its user.
+ALTER TABLE saved_screen
+    ADD COLUMN IF NOT EXISTS seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,292 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Frequency represents how often the portfolio is rebalanced.
+type Frequency string
+
+const (
+	FrequencyWeekly    Frequency = "weekly"
+	FrequencyMonthly   Frequency = "monthly"
+	FrequencyQuarterly Frequency = "quarterly"
+)
+
+// Bar represents the closing price of a symbol on a trading day.
+type Bar struct {
+	Date  time.Time
+	Close float64
+}
+
+// Selector returns the symbols to hold from the given rebalancing date until the next one.
+type Selector func(date time.Time) ([]string, error)
+
+// Config holds the backtest parameters.
+type Config struct {
+	Start     time.Time
+	End       time.Time
+	Frequency Frequency
+}
+
+// Period represents the outcome of holding a portfolio between two rebalancing dates.
+type Period struct {
+	Start           time.Time
+	End             time.Time
+	Holdings        []string
+	Return          float64
+	BenchmarkReturn float64
+	Turnover        float64
+}
+
+// Result represents the performance of a backtest.
+//
+// Returns are expressed as fractions (0.1 is 10%). Drawdowns are the largest peak-to-trough
+// losses of the compounded period returns. The hit rate is the share of picks that outperformed
+// the benchmark over their period, and the turnover is the average share of the portfolio
+// replaced at each rebalancing.
+type Result struct {
+	TotalReturn          float64
+	BenchmarkReturn      float64
+	MaxDrawdown          float64
+	BenchmarkMaxDrawdown float64
+	HitRate              float64
+	Turnover             float64
+	Periods              []Period
+}
+
+// Validate validates a backtest configuration.
+func (c Config) Validate() error {
+	if c.Start.IsZero() || c.End.IsZero() {
+		return errors.New("start and end dates are required")
+	}
+
+	if !c.End.After(c.Start) {
+		return errors.New("end date must be after start date")
+	}
+
+	switch c.Frequency {
+	case FrequencyWeekly, FrequencyMonthly, FrequencyQuarterly:
+	default:
+		return fmt.Errorf("unknown frequency %q", c.Frequency)
+	}
+
+	return nil
+}
+
+// RebalanceDates returns the rebalancing dates between the start and end dates, including both.
+func (c Config) RebalanceDates() []time.Time {
+	var dates []time.Time
+	for d := c.Start; d.Before(c.End); d = c.next(d) {
+		dates = append(dates, d)
+	}
+
+	return append(dates, c.End)
+}
+
+func (c Config) next(d time.Time) time.Time {
+	switch c.Frequency {
+	case FrequencyWeekly:
+		return d.AddDate(0, 0, 7)
+	case FrequencyQuarterly:
+		return d.AddDate(0, 3, 0)
+	default:
+		return d.AddDate(0, 1, 0)
+	}
+}
+
+// Run replays the selector over the rebalancing dates and measures the performance of an equally weighted
+// portfolio of the selected symbols against the benchmark.
+// Bars must be sorted by date. Without benchmark bars, the benchmark is an equally weighted portfolio
+// of every symbol with prices.
+func Run(cfg Config, prices map[string][]Bar, benchmark []Bar, selector Selector) (*Result, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	res := &Result{}
+	equity, benchmarkEquity := newEquityCurve(), newEquityCurve()
+
+	var hits, picks int
+	var previous map[string]float64
+	dates := cfg.RebalanceDates()
+	for i := range len(dates) - 1 {
+		start, end := dates[i], dates[i+1]
+
+		selected, err := selector(start)
+		if err != nil {
+			return nil, fmt.Errorf("selecting holdings on %s: %w", start.Format(time.DateOnly), err)
+		}
+
+		returns := periodReturns(prices, selected, start, end)
+		holdings := make([]string, 0, len(returns))
+		for symbol := range returns {
+			holdings = append(holdings, symbol)
+		}
+		sort.Strings(holdings)
+
+		benchmarkReturn, ok := periodReturn(benchmark, start, end)
+		if !ok {
+			benchmarkReturn = mean(periodReturns(prices, nil, start, end))
+		}
+
+		weights := equalWeights(holdings)
+		period := Period{
+			Start:           start,
+			End:             end,
+			Holdings:        holdings,
+			Return:          mean(returns),
+			BenchmarkReturn: benchmarkReturn,
+			Turnover:        turnover(previous, weights),
+		}
+		previous = weights
+
+		for _, r := range returns {
+			picks++
+			if r > benchmarkReturn {
+				hits++
+			}
+		}
+
+		equity.add(period.Return)
+		benchmarkEquity.add(period.BenchmarkReturn)
+		res.Turnover += period.Turnover
+		res.Periods = append(res.Periods, period)
+	}
+
+	res.TotalReturn = equity.value - 1
+	res.BenchmarkReturn = benchmarkEquity.value - 1
+	res.MaxDrawdown = equity.maxDrawdown
+	res.BenchmarkMaxDrawdown = benchmarkEquity.maxDrawdown
+	if picks > 0 {
+		res.HitRate = float64(hits) / float64(picks)
+	}
+	if len(res.Periods) > 0 {
+		res.Turnover /= float64(len(res.Periods))
+	}
+
+	return res, nil
+}
+
+// periodReturns returns the returns of the given symbols between two dates, skipping symbols without prices.
+// A nil symbol list returns the returns of every symbol.
+func periodReturns(prices map[string][]Bar, symbols []string, start, end time.Time) map[string]float64 {
+	if symbols == nil {
+		symbols = make([]string, 0, len(prices))
+		for symbol := range prices {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	returns := make(map[string]float64, len(symbols))
+	for _, symbol := range symbols {
+		if r, ok := periodReturn(prices[symbol], start, end); ok {
+			returns[symbol] = r
+		}
+	}
+
+	return returns
+}
+
+// periodReturn returns the return between the last closes on or before each date.
+func periodReturn(bars []Bar, start, end time.Time) (float64, bool) {
+	from, ok := closeOn(bars, start)
+	if !ok || from == 0 {
+		return 0, false
+	}
+
+	to, ok := closeOn(bars, end)
+	if !ok {
+		return 0, false
+	}
+
+	return to/from - 1, true
+}
+
+// closeOn returns the last close on or before the given date.
+func closeOn(bars []Bar, date time.Time) (float64, bool) {
+	i, found := slices.BinarySearchFunc(bars, date, func(b Bar, d time.Time) int {
+		return b.Date.Compare(d)
+	})
+	if found {
+		return bars[i].Close, true
+	}
+
+	if i == 0 {
+		return 0, false
+	}
+
+	return bars[i-1].Close, true
+}
+
+func mean(values map[string]float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+
+	return sum / float64(len(values))
+}
+
+func equalWeights(symbols []string) map[string]float64 {
+	weights := make(map[string]float64, len(symbols))
+	for _, symbol := range symbols {
+		weights[symbol] = 1 / float64(len(symbols))
+	}
+
+	return weights
+}
+
+// turnover returns the share of the portfolio replaced when moving from one set of weights to another,
+// the remainder of the weights being held in cash. The initial allocation is not counted as turnover.
+func turnover(from, to map[string]float64) float64 {
+	if from == nil {
+		return 0
+	}
+
+	sum := math.Abs(cash(to) - cash(from))
+	for symbol, w := range to {
+		sum += math.Abs(w - from[symbol])
+	}
+	for symbol, w := range from {
+		if _, ok := to[symbol]; !ok {
+			sum += w
+		}
+	}
+
+	return sum / 2
+}
+
+func cash(weights map[string]float64) float64 {
+	invested := 0.0
+	for _, w := range weights {
+		invested += w
+	}
+
+	return 1 - invested
+}
+
+type equityCurve struct {
+	value       float64
+	peak        float64
+	maxDrawdown float64
+}
+
+func newEquityCurve() *equityCurve {
+	return &equityCurve{value: 1, peak: 1}
+}
+
+func (c *equityCurve) add(r float64) {
+	c.value *= 1 + r
+	c.peak = max(c.peak, c.value)
+	c.maxDrawdown = max(c.maxDrawdown, 1-c.value/c.peak)
+}
@@ -0,0 +1,126 @@
+package backtest_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/huy125/finscope/pkg/backtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	day := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC) }
+	prices := map[string][]backtest.Bar{
+		"AAA": {
+			{Date: day(time.January, 1), Close: 100},
+			{Date: day(time.February, 1), Close: 110},
+			{Date: day(time.March, 1), Close: 99},
+		},
+		"BBB": {
+			{Date: day(time.January, 1), Close: 50},
+			{Date: day(time.February, 1), Close: 50},
+			{Date: day(time.February, 29), Close: 60},
+		},
+	}
+	cfg := backtest.Config{
+		Start:     day(time.January, 1),
+		End:       day(time.March, 1),
+		Frequency: backtest.FrequencyMonthly,
+	}
+	selector := func(date time.Time) ([]string, error) {
+		if date.Month() == time.January {
+			return []string{"AAA"}, nil
+		}
+		return []string{"AAA", "BBB", "UNKNOWN"}, nil
+	}
+
+	got, err := backtest.Run(cfg, prices, nil, selector)
+	require.NoError(t, err)
+
+	require.Len(t, got.Periods, 2)
+	assert.Equal(t, []string{"AAA"}, got.Periods[0].Holdings)
+	assert.InDelta(t, 0.1, got.Periods[0].Return, 1e-9)
+	assert.InDelta(t, 0.05, got.Periods[0].BenchmarkReturn, 1e-9)
+	assert.Equal(t, []string{"AAA", "BBB"}, got.Periods[1].Holdings)
+	assert.InDelta(t, 0.05, got.Periods[1].Return, 1e-9)
+	assert.InDelta(t, 0.5, got.Periods[1].Turnover, 1e-9)
+
+	assert.InDelta(t, 1.1*1.05-1, got.TotalReturn, 1e-9)
+	assert.InDelta(t, 1.05*1.05-1, got.BenchmarkReturn, 1e-9)
+	assert.InDelta(t, 0, got.MaxDrawdown, 1e-9)
+	assert.InDelta(t, 2.0/3, got.HitRate, 1e-9)
+	assert.InDelta(t, 0.25, got.Turnover, 1e-9)
+}
+
+func TestRun_WithBenchmarkAndDrawdown(t *testing.T) {
+	t.Parallel()
+
+	day := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC) }
+	prices := map[string][]backtest.Bar{
+		"AAA": {
+			{Date: day(time.January, 1), Close: 100},
+			{Date: day(time.January, 8), Close: 80},
+			{Date: day(time.January, 15), Close: 88},
+		},
+	}
+	benchmark := []backtest.Bar{
+		{Date: day(time.January, 1), Close: 10},
+		{Date: day(time.January, 15), Close: 11},
+	}
+	cfg := backtest.Config{
+		Start:     day(time.January, 1),
+		End:       day(time.January, 15),
+		Frequency: backtest.FrequencyWeekly,
+	}
+	selector := func(time.Time) ([]string, error) { return []string{"AAA"}, nil }
+
+	got, err := backtest.Run(cfg, prices, benchmark, selector)
+	require.NoError(t, err)
+
+	assert.InDelta(t, -0.12, got.TotalReturn, 1e-9)
+	assert.InDelta(t, 0.1, got.BenchmarkReturn, 1e-9)
+	assert.InDelta(t, 0.2, got.MaxDrawdown, 1e-9)
+	assert.InDelta(t, 0, got.BenchmarkMaxDrawdown, 1e-9)
+	assert.InDelta(t, 0, got.HitRate, 1e-9)
+}
+
+func TestRun_HandlesErrors(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		cfg      backtest.Config
+		selector backtest.Selector
+	}{
+		{
+			name:     "invalid frequency",
+			cfg:      backtest.Config{Start: start, End: start.AddDate(0, 1, 0), Frequency: "daily"},
+			selector: func(time.Time) ([]string, error) { return nil, nil },
+		},
+		{
+			name:     "end before start",
+			cfg:      backtest.Config{Start: start, End: start, Frequency: backtest.FrequencyMonthly},
+			selector: func(time.Time) ([]string, error) { return nil, nil },
+		},
+		{
+			name:     "selector error",
+			cfg:      backtest.Config{Start: start, End: start.AddDate(0, 1, 0), Frequency: backtest.FrequencyMonthly},
+			selector: func(time.Time) ([]string, error) { return nil, errors.New("test") },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := backtest.Run(test.cfg, nil, nil, test.selector)
+
+			assert.Error(t, err)
+		})
+	}
+}
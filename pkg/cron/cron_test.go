@@ -0,0 +1,129 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/huy125/finscope/pkg/cron"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	t.Parallel()
+
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+
+		expr  string
+		after time.Time
+
+		want time.Time
+	}{
+		{
+			name: "weekday after market close",
+
+			expr:  "30 18 * * 1-5",
+			after: time.Date(2024, 3, 1, 19, 0, 0, 0, newYork), // Friday
+
+			want: time.Date(2024, 3, 4, 18, 30, 0, 0, newYork),
+		},
+		{
+			name: "same day",
+
+			expr:  "30 18 * * 1-5",
+			after: time.Date(2024, 3, 4, 9, 15, 42, 0, newYork),
+
+			want: time.Date(2024, 3, 4, 18, 30, 0, 0, newYork),
+		},
+		{
+			name: "strictly after",
+
+			expr:  "30 18 * * *",
+			after: time.Date(2024, 3, 4, 18, 30, 0, 0, time.UTC),
+
+			want: time.Date(2024, 3, 5, 18, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "steps and lists",
+
+			expr:  "*/20 9,17 * * *",
+			after: time.Date(2024, 3, 4, 9, 45, 0, 0, time.UTC),
+
+			want: time.Date(2024, 3, 4, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day of month or day of week",
+
+			expr:  "0 0 15 * 0",
+			after: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), // Monday
+
+			want: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "sunday as 7",
+
+			expr:  "0 12 * * 7",
+			after: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+
+			want: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "next year",
+
+			expr:  "0 0 1 1 *",
+			after: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+
+			want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := cron.Parse(test.expr)
+			require.NoError(t, err)
+
+			got, err := s.Next(test.after)
+			require.NoError(t, err)
+
+			assert.True(t, test.want.Equal(got), "want %s, got %s", test.want, got)
+		})
+	}
+}
+
+func TestSchedule_NextHandlesNoActivation(t *testing.T) {
+	t.Parallel()
+
+	s, err := cron.Parse("0 0 30 2 *")
+	require.NoError(t, err)
+
+	_, err = s.Next(time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC))
+
+	assert.ErrorIs(t, err, cron.ErrNoActivation)
+}
+
+func TestParse_HandlesInvalidExpressions(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	} {
+		_, err := cron.Parse(expr)
+
+		assert.Error(t, err, expr)
+	}
+}
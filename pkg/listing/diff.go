@@ -0,0 +1,120 @@
+package listing
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// ChangeKind is the kind of change of a stock between a universe and a listing file.
+type ChangeKind string
+
+// ChangeKind constants.
+const (
+	// Added is a listed stock unknown to the universe.
+	Added ChangeKind = "added"
+	// Updated is an active stock whose name or exchange changed.
+	Updated ChangeKind = "updated"
+	// Relisted is an inactive stock listed again.
+	Relisted ChangeKind = "relisted"
+	// Delisted is an active stock no longer listed.
+	Delisted ChangeKind = "delisted"
+)
+
+// Change is the change of a stock of the universe, from its current listing to its listing in the file.
+type Change struct {
+	Kind   ChangeKind
+	Before Listing
+	After  Listing
+}
+
+// Symbol returns the symbol of the changed stock.
+func (c Change) Symbol() string {
+	if c.Kind == Added {
+		return c.After.Symbol
+	}
+	return c.Before.Symbol
+}
+
+// Diff returns the changes bringing the current universe in line with the listings of a file, ordered by symbol.
+// A stock is delisted when the file lists it as delisted or when the file misses it while covering its exchange,
+// the file being assumed to list its exchanges entirely. An exchange is covered by the stocks the file lists as
+// active only, e.g. a file of delistings covers no exchange. Stocks of the other exchanges are left untouched.
+// An empty exchange in the file keeps the current exchange of the stock.
+func Diff(current, listings []Listing) []Change {
+	exchanges := map[string]bool{}
+	listed := make(map[string]Listing, len(listings))
+	for _, l := range listings {
+		listed[l.Symbol] = l
+		if l.Exchange != "" && !l.Delisted {
+			exchanges[l.Exchange] = true
+		}
+	}
+
+	var changes []Change
+	known := make(map[string]bool, len(current))
+	for _, cur := range current {
+		known[cur.Symbol] = true
+
+		l, ok := listed[cur.Symbol]
+		if !ok {
+			if !cur.Delisted && exchanges[cur.Exchange] {
+				changes = append(changes, delisted(cur))
+			}
+			continue
+		}
+
+		if l.Exchange == "" {
+			l.Exchange = cur.Exchange
+		}
+
+		switch {
+		case l.Delisted && !cur.Delisted:
+			changes = append(changes, delisted(cur))
+		case l.Delisted:
+		case cur.Delisted:
+			changes = append(changes, Change{Kind: Relisted, Before: cur, After: l})
+		case l.Name != cur.Name || l.Exchange != cur.Exchange:
+			changes = append(changes, Change{Kind: Updated, Before: cur, After: l})
+		}
+	}
+
+	for _, l := range listings {
+		if known[l.Symbol] || l.Delisted {
+			continue
+		}
+		changes = append(changes, Change{Kind: Added, After: l})
+	}
+
+	slices.SortFunc(changes, func(a, b Change) int {
+		return cmp.Compare(a.Symbol(), b.Symbol())
+	})
+
+	return changes
+}
+
+// FilterAddedAssetTypes returns the changes without the added stocks of other asset types than the given ones,
+// compared case insensitively. Added stocks without asset type and the other changes are kept, so that the
+// listings of every asset type still cover their exchanges and keep their stocks active.
+func FilterAddedAssetTypes(changes []Change, types ...string) []Change {
+	if len(types) == 0 {
+		return changes
+	}
+
+	return slices.DeleteFunc(slices.Clone(changes), func(c Change) bool {
+		if c.Kind != Added || c.After.AssetType == "" {
+			return false
+		}
+
+		return !slices.ContainsFunc(types, func(typ string) bool {
+			return strings.EqualFold(typ, c.After.AssetType)
+		})
+	})
+}
+
+func delisted(cur Listing) Change {
+	after := cur
+	after.Delisted = true
+
+	return Change{Kind: Delisted, Before: cur, After: after}
+}
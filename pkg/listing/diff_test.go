@@ -0,0 +1,123 @@
+package listing_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/huy125/finscope/pkg/listing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	current := []listing.Listing{
+		{Symbol: "AAPL", Name: "Apple Inc", Exchange: "NASDAQ"},
+		{Symbol: "FB", Name: "Facebook Inc", Exchange: "NASDAQ"},
+		{Symbol: "IBM", Name: "IBM", Exchange: "NYSE"},
+		{Symbol: "SAP", Name: "SAP SE", Exchange: "XETRA"},
+		{Symbol: "TWTR", Name: "Twitter Inc", Exchange: "NYSE"},
+		{Symbol: "GME", Name: "GameStop Corp", Exchange: "NYSE", Delisted: true},
+		{Symbol: "MSFT", Name: "Microsoft Corporation"},
+	}
+	listings := []listing.Listing{
+		{Symbol: "AAPL", Name: "Apple Inc", Exchange: "NASDAQ"},
+		{Symbol: "IBM", Name: "International Business Machines", Exchange: "NYSE"},
+		{Symbol: "TWTR", Name: "Twitter Inc", Exchange: "NYSE", Delisted: true},
+		{Symbol: "GME", Name: "GameStop Corp", Exchange: "NYSE"},
+		{Symbol: "MSFT", Name: "Microsoft Corporation"},
+		{Symbol: "NVDA", Name: "NVIDIA Corp", Exchange: "NASDAQ"},
+		{Symbol: "LEH", Name: "Lehman Brothers", Exchange: "NYSE", Delisted: true},
+	}
+
+	got := listing.Diff(current, listings)
+
+	want := []listing.Change{
+		{
+			Kind:   listing.Delisted,
+			Before: listing.Listing{Symbol: "FB", Name: "Facebook Inc", Exchange: "NASDAQ"},
+			After:  listing.Listing{Symbol: "FB", Name: "Facebook Inc", Exchange: "NASDAQ", Delisted: true},
+		},
+		{
+			Kind:   listing.Relisted,
+			Before: listing.Listing{Symbol: "GME", Name: "GameStop Corp", Exchange: "NYSE", Delisted: true},
+			After:  listing.Listing{Symbol: "GME", Name: "GameStop Corp", Exchange: "NYSE"},
+		},
+		{
+			Kind:   listing.Updated,
+			Before: listing.Listing{Symbol: "IBM", Name: "IBM", Exchange: "NYSE"},
+			After:  listing.Listing{Symbol: "IBM", Name: "International Business Machines", Exchange: "NYSE"},
+		},
+		{
+			Kind:  listing.Added,
+			After: listing.Listing{Symbol: "NVDA", Name: "NVIDIA Corp", Exchange: "NASDAQ"},
+		},
+		{
+			Kind:   listing.Delisted,
+			Before: listing.Listing{Symbol: "TWTR", Name: "Twitter Inc", Exchange: "NYSE"},
+			After:  listing.Listing{Symbol: "TWTR", Name: "Twitter Inc", Exchange: "NYSE", Delisted: true},
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestDiff_KeepsExchangeWhenMissing(t *testing.T) {
+	t.Parallel()
+
+	current := []listing.Listing{{Symbol: "AAPL", Name: "Apple Inc", Exchange: "NASDAQ"}}
+	listings := []listing.Listing{{Symbol: "AAPL", Name: "Apple Inc"}}
+
+	got := listing.Diff(current, listings)
+
+	assert.Empty(t, got)
+}
+
+func TestDiff_FileOfDelistings(t *testing.T) {
+	t.Parallel()
+
+	current := []listing.Listing{
+		{Symbol: "AAPL", Name: "Apple Inc", Exchange: "NASDAQ"},
+		{Symbol: "FB", Name: "Facebook Inc", Exchange: "NASDAQ"},
+	}
+	listings, err := listing.Read(strings.NewReader(
+		"symbol,name,exchange,assetType,ipoDate,delistingDate,status\n" +
+			"FB,Facebook Inc,NASDAQ,Stock,2012-05-18,2022-06-09,Delisted\n",
+	))
+	require.NoError(t, err)
+
+	got := listing.Diff(current, listings)
+
+	want := []listing.Change{
+		{
+			Kind:   listing.Delisted,
+			Before: listing.Listing{Symbol: "FB", Name: "Facebook Inc", Exchange: "NASDAQ"},
+			After:  listing.Listing{Symbol: "FB", Name: "Facebook Inc", Exchange: "NASDAQ", Delisted: true},
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestFilterAddedAssetTypes(t *testing.T) {
+	t.Parallel()
+
+	current := []listing.Listing{
+		{Symbol: "AAPL", Name: "Apple Inc", Exchange: "NASDAQ"},
+		{Symbol: "QQQ", Name: "Invesco QQQ Trust", Exchange: "NASDAQ"},
+	}
+	listings := []listing.Listing{
+		{Symbol: "AAPL", Name: "Apple Inc", Exchange: "NASDAQ", AssetType: "Stock"},
+		{Symbol: "MSFT", Name: "Microsoft Corp", Exchange: "NASDAQ", AssetType: "Stock"},
+		{Symbol: "QQQ", Name: "Invesco QQQ Trust", Exchange: "NASDAQ", AssetType: "ETF"},
+		{Symbol: "QQQM", Name: "Invesco NASDAQ 100 ETF", Exchange: "NASDAQ", AssetType: "ETF"},
+		{Symbol: "SAP", Name: "SAP SE", Exchange: "NASDAQ"},
+	}
+
+	got := listing.FilterAddedAssetTypes(listing.Diff(current, listings), "stock")
+
+	want := []listing.Change{
+		{Kind: listing.Added, After: listings[1]},
+		{Kind: listing.Added, After: listings[4]},
+	}
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,126 @@
+// Package listing reads exchange listing files and compares them with a known universe of stocks.
+package listing
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/huy125/finscope/pkg/symbol"
+)
+
+// Listing is a security listed on an exchange.
+type Listing struct {
+	Symbol    string
+	Name      string
+	Exchange  string
+	AssetType string
+	Delisted  bool
+}
+
+// Read reads a listing file, in the Alpha Vantage LISTING_STATUS CSV format
+// (symbol,name,exchange,assetType,ipoDate,delistingDate,status)
+// or in a generic symbol,name,exchange,asset_type CSV format.
+// Columns are identified by their header, only the symbol and name being required.
+// Symbols are normalized to their canonical form, e.g. "BRK-A" to "BRK.A", and exchanges are upper cased.
+// A symbol listed several times is kept once, its active listing taking precedence over its delisted ones.
+func Read(r io.Reader) ([]Listing, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("missing header")
+		}
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	cols := columns(header)
+	for _, name := range []string{"symbol", "name"} {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("missing %s column", name)
+		}
+	}
+
+	var (
+		listings []Listing
+		index    = map[string]int{}
+	)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading record: %w", err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		l := Listing{
+			Name:      cols.get(record, "name"),
+			Exchange:  strings.ToUpper(cols.get(record, "exchange")),
+			AssetType: cols.get(record, "assettype"),
+			Delisted:  isDelisted(cols.get(record, "status"), cols.get(record, "delistingdate")),
+		}
+
+		raw := cols.get(record, "symbol")
+		sym, err := symbol.Parse(raw)
+		switch {
+		case raw == "":
+			return nil, fmt.Errorf("line %d: symbol is required", line)
+		case err != nil:
+			return nil, fmt.Errorf("line %d: symbol %q is invalid", line, raw)
+		case l.Name == "":
+			return nil, fmt.Errorf("line %d: name is required", line)
+		}
+		l.Symbol = sym.String()
+
+		if i, ok := index[l.Symbol]; ok {
+			if listings[i].Delisted {
+				listings[i] = l
+			}
+			continue
+		}
+
+		index[l.Symbol] = len(listings)
+		listings = append(listings, l)
+	}
+
+	return listings, nil
+}
+
+type columnIndex map[string]int
+
+// columns indexes the header columns by their lower cased name, without underscores,
+// so that "assetType" and "asset_type" are the same column. A leading byte order mark is ignored.
+func columns(header []string) columnIndex {
+	cols := make(columnIndex, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		cols[strings.ReplaceAll(name, "_", "")] = i
+	}
+
+	return cols
+}
+
+func (c columnIndex) get(record []string, name string) string {
+	i, ok := c[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+
+	return strings.TrimSpace(record[i])
+}
+
+// isDelisted reports whether a listing is delisted. Alpha Vantage reports "null" for a missing delisting date.
+func isDelisted(status, delistingDate string) bool {
+	if status != "" {
+		return strings.EqualFold(status, "delisted")
+	}
+
+	return delistingDate != "" && !strings.EqualFold(delistingDate, "null")
+}
@@ -0,0 +1,120 @@
+package listing_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/huy125/finscope/pkg/listing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+
+		file string
+
+		want    []listing.Listing
+		wantErr string
+	}{
+		{
+			name: "reads listing status file",
+
+			file: "\ufeffsymbol,name,exchange,assetType,ipoDate,delistingDate,status\r\n" +
+				"A,Agilent Technologies Inc,NYSE,Stock,1999-11-18,null,Active\r\n" +
+				"AAA,Listed Funds Trust,NYSE ARCA,ETF,2020-09-09,null,Active\r\n" +
+				"AABA,Altaba Inc,NASDAQ,Stock,1996-04-12,2019-10-07,Delisted\r\n",
+
+			want: []listing.Listing{
+				{Symbol: "A", Name: "Agilent Technologies Inc", Exchange: "NYSE", AssetType: "Stock"},
+				{Symbol: "AAA", Name: "Listed Funds Trust", Exchange: "NYSE ARCA", AssetType: "ETF"},
+				{Symbol: "AABA", Name: "Altaba Inc", Exchange: "NASDAQ", AssetType: "Stock", Delisted: true},
+			},
+		},
+		{
+			name: "reads generic file",
+
+			file: "symbol,name,exchange,asset_type\n" +
+				" sap ,SAP SE,xetra,Stock\n" +
+				"\"BRK-B\",\"Berkshire Hathaway, Inc\",NYSE,\n",
+
+			want: []listing.Listing{
+				{Symbol: "SAP", Name: "SAP SE", Exchange: "XETRA", AssetType: "Stock"},
+				{Symbol: "BRK.B", Name: "Berkshire Hathaway, Inc", Exchange: "NYSE"},
+			},
+		},
+		{
+			name: "reads preferred shares",
+
+			file: "symbol,name,exchange,assetType,ipoDate,delistingDate,status\n" +
+				"ABR-P-D,Arbor Realty Trust Inc,NYSE,Stock,2021-05-26,null,Active\n",
+
+			want: []listing.Listing{
+				{Symbol: "ABR.P.D", Name: "Arbor Realty Trust Inc", Exchange: "NYSE", AssetType: "Stock"},
+			},
+		},
+		{
+			name: "reads file without optional columns",
+
+			file: "name,symbol\nApple Inc,AAPL\n",
+
+			want: []listing.Listing{{Symbol: "AAPL", Name: "Apple Inc"}},
+		},
+		{
+			name: "keeps the active listing of a reused symbol",
+
+			file: "symbol,name,exchange,assetType,ipoDate,delistingDate,status\n" +
+				"META,Old Meta Corp,NYSE,Stock,2001-01-01,2010-01-01,Delisted\n" +
+				"META,Meta Platforms Inc,NASDAQ,Stock,2012-05-18,null,Active\n" +
+				"META,Other Meta Corp,NYSE,Stock,1990-01-01,2000-01-01,Delisted\n",
+
+			want: []listing.Listing{{Symbol: "META", Name: "Meta Platforms Inc", Exchange: "NASDAQ", AssetType: "Stock"}},
+		},
+		{
+			name: "handles empty file",
+
+			file: "",
+
+			wantErr: "missing header",
+		},
+		{
+			name: "handles missing symbol column",
+
+			file: "ticker,name\nAAPL,Apple Inc\n",
+
+			wantErr: "missing symbol column",
+		},
+		{
+			name: "handles invalid symbol",
+
+			file: "symbol,name\nAAPL,Apple Inc\nAA PL,Apple Inc\n",
+
+			wantErr: `line 3: symbol "AA PL" is invalid`,
+		},
+		{
+			name: "handles missing name",
+
+			file: "symbol,name\nAAPL,Apple Inc\nMSFT,\n",
+
+			wantErr: "line 3: name is required",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := listing.Read(strings.NewReader(test.file))
+
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
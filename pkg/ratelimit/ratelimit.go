@@ -0,0 +1,88 @@
+// Package ratelimit spaces out calls to rate limited external APIs.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter allows a fixed number of calls per minute, evenly spaced.
+// A nil limiter or a limiter without rate never waits.
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// New returns a limiter allowing the given number of calls per minute.
+// A non-positive rate disables the limit.
+func New(perMinute int) *Limiter {
+	if perMinute <= 0 {
+		return &Limiter{}
+	}
+
+	return &Limiter{interval: time.Minute / time.Duration(perMinute)}
+}
+
+// Wait blocks until the next call is allowed or the context is done.
+// A call given up because of the context still consumes its slot.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil || l.interval <= 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := now
+	if l.next.After(now) {
+		slot = l.next
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	wait := slot.Sub(now)
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// TryWait takes the next call slot if it is due, without waiting, and reports whether the call is allowed.
+func (l *Limiter) TryWait() bool {
+	if l == nil || l.interval <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if l.next.After(now) {
+		return false
+	}
+	l.next = now.Add(l.interval)
+
+	return true
+}
+
+// Refund gives back a call slot taken for a call that was not made, making the next call due earlier.
+func (l *Limiter) Refund() {
+	if l == nil || l.interval <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.next = l.next.Add(-l.interval)
+}
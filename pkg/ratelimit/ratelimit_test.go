@@ -0,0 +1,94 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/huy125/finscope/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Wait(t *testing.T) {
+	t.Parallel()
+
+	// 600 calls per minute spaces the calls by 100ms.
+	l := ratelimit.New(600)
+
+	start := time.Now()
+	for range 3 {
+		require.NoError(t, l.Wait(t.Context()))
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestLimiter_WaitHandlesDoneContext(t *testing.T) {
+	t.Parallel()
+
+	l := ratelimit.New(1)
+	require.NoError(t, l.Wait(t.Context()))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	err := l.Wait(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLimiter_WaitWithoutRate(t *testing.T) {
+	t.Parallel()
+
+	var nilLimiter *ratelimit.Limiter
+	for _, l := range []*ratelimit.Limiter{ratelimit.New(0), nilLimiter} {
+		start := time.Now()
+		for range 10 {
+			require.NoError(t, l.Wait(t.Context()))
+		}
+
+		assert.Less(t, time.Since(start), 50*time.Millisecond)
+	}
+}
+
+func TestLimiter_TryWait(t *testing.T) {
+	t.Parallel()
+
+	l := ratelimit.New(1)
+
+	assert.True(t, l.TryWait())
+	assert.False(t, l.TryWait())
+}
+
+func TestLimiter_TryWaitBehindWait(t *testing.T) {
+	t.Parallel()
+
+	l := ratelimit.New(1)
+	require.NoError(t, l.Wait(t.Context()))
+
+	assert.False(t, l.TryWait())
+}
+
+func TestLimiter_TryWaitWithoutRate(t *testing.T) {
+	t.Parallel()
+
+	var nilLimiter *ratelimit.Limiter
+	for _, l := range []*ratelimit.Limiter{ratelimit.New(0), nilLimiter} {
+		for range 10 {
+			assert.True(t, l.TryWait())
+		}
+	}
+}
+
+func TestLimiter_Refund(t *testing.T) {
+	t.Parallel()
+
+	l := ratelimit.New(1)
+	require.True(t, l.TryWait())
+
+	l.Refund()
+
+	assert.True(t, l.TryWait())
+	assert.False(t, l.TryWait())
+}
@@ -0,0 +1,168 @@
+// Package symbol normalizes stock ticker symbols and translates them for the stock data providers.
+package symbol
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	// maxPartLength bounds the length of the ticker, the share class and the preferred series of a symbol.
+	maxPartLength = 12
+	// preferredClass is the share class of the preferred shares, followed by their series.
+	preferredClass = "P"
+)
+
+// ErrInvalid is returned when a symbol cannot be parsed.
+var ErrInvalid = errors.New("invalid symbol")
+
+// exchange is a non US exchange, identified by a symbol suffix.
+type exchange struct {
+	// suffix is the canonical suffix of the exchange, as used by most quote services.
+	suffix string
+	// alphaVantage is the suffix of the exchange at Alpha Vantage.
+	alphaVantage string
+}
+
+// classSeparators replaces the share class separators with the canonical one.
+var classSeparators = strings.NewReplacer("-", ".", "/", ".")
+
+var exchanges = []exchange{
+	{suffix: "TO", alphaVantage: "TRT"}, // Toronto Stock Exchange
+	{suffix: "V", alphaVantage: "TRV"},  // TSX Venture Exchange
+	{suffix: "L", alphaVantage: "LON"},  // London Stock Exchange
+	{suffix: "DE", alphaVantage: "DEX"}, // XETRA
+	{suffix: "BO", alphaVantage: "BSE"}, // Bombay Stock Exchange
+	{suffix: "SS", alphaVantage: "SHH"}, // Shanghai Stock Exchange
+	{suffix: "SZ", alphaVantage: "SHZ"}, // Shenzhen Stock Exchange
+}
+
+// Symbol is a stock ticker symbol. Its canonical form is upper case, separates the share class with a dot
+// and qualifies listings outside the US with the suffix of their exchange, e.g. "AAPL", "BRK.A", "ABR.P.D"
+// or "SHOP.TO".
+type Symbol struct {
+	ticker   string
+	class    string
+	exchange string
+}
+
+// Parse parses a symbol, ignoring surrounding blanks and quotes and the case.
+// The share class can be separated by a dot, a dash or a slash, e.g. "BRK.A", "BRK-A" or "BRK/A",
+// the series of a preferred share following its class alike, e.g. "ABR-P-D".
+// The exchange suffix can be canonical or the one of Alpha Vantage, e.g. "SHOP.TO" or "SHOP.TRT".
+func Parse(s string) (Symbol, error) {
+	s = strings.ToUpper(strings.TrimSpace(strings.Trim(strings.TrimSpace(s), `"'`)))
+	if s == "" {
+		return Symbol{}, ErrInvalid
+	}
+
+	var sym Symbol
+	if i := strings.LastIndexByte(s, '.'); i >= 0 {
+		if ex, ok := findExchange(s[i+1:]); ok {
+			sym.exchange = ex.suffix
+			s = s[:i]
+		}
+	}
+
+	parts := strings.Split(classSeparators.Replace(s), ".")
+	// Only preferred shares have a second separator, before their series, e.g. "ABR-P-D".
+	if len(parts) > 3 || (len(parts) == 3 && parts[1] != preferredClass) {
+		return Symbol{}, ErrInvalid
+	}
+	for _, part := range parts {
+		if !isValidPart(part) {
+			return Symbol{}, ErrInvalid
+		}
+	}
+
+	sym.ticker = parts[0]
+	if len(parts) > 1 {
+		sym.class = strings.Join(parts[1:], ".")
+	}
+
+	return sym, nil
+}
+
+// Normalize returns the canonical form of a symbol, or the symbol unchanged if it is invalid.
+func Normalize(s string) string {
+	sym, err := Parse(s)
+	if err != nil {
+		return s
+	}
+
+	return sym.String()
+}
+
+// Ticker returns the ticker of the symbol, without share class and exchange.
+func (s Symbol) Ticker() string {
+	return s.ticker
+}
+
+// Class returns the share class of the symbol, empty if the symbol has a single class.
+// The class of a preferred share includes its series, e.g. "P.D" for "ABR.P.D".
+func (s Symbol) Class() string {
+	return s.class
+}
+
+// Exchange returns the canonical suffix of the exchange of the symbol, empty for US listings.
+func (s Symbol) Exchange() string {
+	return s.exchange
+}
+
+// String returns the canonical form of the symbol.
+func (s Symbol) String() string {
+	return s.format(".", s.exchange)
+}
+
+// AlphaVantage returns the symbol as known to Alpha Vantage, which separates the share class with a dash
+// and has its own exchange suffixes, e.g. "BRK-A" or "SHOP.TRT".
+func (s Symbol) AlphaVantage() string {
+	suffix := ""
+	if ex, ok := findExchange(s.exchange); ok {
+		suffix = ex.alphaVantage
+	}
+
+	return s.format("-", suffix)
+}
+
+func (s Symbol) format(classSep, suffix string) string {
+	var b strings.Builder
+	b.WriteString(s.ticker)
+	if s.class != "" {
+		b.WriteString(classSep)
+		b.WriteString(strings.ReplaceAll(s.class, ".", classSep))
+	}
+	if suffix != "" {
+		b.WriteByte('.')
+		b.WriteString(suffix)
+	}
+
+	return b.String()
+}
+
+// findExchange finds an exchange by its canonical or Alpha Vantage suffix.
+func findExchange(suffix string) (exchange, bool) {
+	for _, ex := range exchanges {
+		if suffix == ex.suffix || suffix == ex.alphaVantage {
+			return ex, true
+		}
+	}
+
+	return exchange{}, false
+}
+
+func isValidPart(part string) bool {
+	if part == "" || len(part) > maxPartLength {
+		return false
+	}
+
+	for _, r := range part {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '&':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
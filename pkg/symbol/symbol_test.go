@@ -0,0 +1,145 @@
+package symbol_test
+
+import (
+	"testing"
+
+	"github.com/huy125/finscope/pkg/symbol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+
+		symbol string
+
+		want             string
+		wantTicker       string
+		wantClass        string
+		wantExchange     string
+		wantAlphaVantage string
+	}{
+		{
+			name:             "lower case",
+			symbol:           "aapl",
+			want:             "AAPL",
+			wantTicker:       "AAPL",
+			wantAlphaVantage: "AAPL",
+		},
+		{
+			name:             "blanks and quotes",
+			symbol:           ` "AAPL" `,
+			want:             "AAPL",
+			wantTicker:       "AAPL",
+			wantAlphaVantage: "AAPL",
+		},
+		{
+			name:             "share class with dot",
+			symbol:           "BRK.A",
+			want:             "BRK.A",
+			wantTicker:       "BRK",
+			wantClass:        "A",
+			wantAlphaVantage: "BRK-A",
+		},
+		{
+			name:             "share class with dash",
+			symbol:           "brk-a",
+			want:             "BRK.A",
+			wantTicker:       "BRK",
+			wantClass:        "A",
+			wantAlphaVantage: "BRK-A",
+		},
+		{
+			name:             "share class with slash",
+			symbol:           "BRK/B",
+			want:             "BRK.B",
+			wantTicker:       "BRK",
+			wantClass:        "B",
+			wantAlphaVantage: "BRK-B",
+		},
+		{
+			name:             "exchange suffix",
+			symbol:           "shop.to",
+			want:             "SHOP.TO",
+			wantTicker:       "SHOP",
+			wantExchange:     "TO",
+			wantAlphaVantage: "SHOP.TRT",
+		},
+		{
+			name:             "alpha vantage exchange suffix",
+			symbol:           "SHOP.TRT",
+			want:             "SHOP.TO",
+			wantTicker:       "SHOP",
+			wantExchange:     "TO",
+			wantAlphaVantage: "SHOP.TRT",
+		},
+		{
+			name:             "share class and exchange suffix",
+			symbol:           "RCI-B.TO",
+			want:             "RCI.B.TO",
+			wantTicker:       "RCI",
+			wantClass:        "B",
+			wantExchange:     "TO",
+			wantAlphaVantage: "RCI-B.TRT",
+		},
+		{
+			name:             "preferred share series",
+			symbol:           "ABR-P-D",
+			want:             "ABR.P.D",
+			wantTicker:       "ABR",
+			wantClass:        "P.D",
+			wantAlphaVantage: "ABR-P-D",
+		},
+		{
+			name:             "numeric ticker",
+			symbol:           "600104.SHH",
+			want:             "600104.SS",
+			wantTicker:       "600104",
+			wantExchange:     "SS",
+			wantAlphaVantage: "600104.SHH",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := symbol.Parse(test.symbol)
+
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got.String())
+			assert.Equal(t, test.wantTicker, got.Ticker())
+			assert.Equal(t, test.wantClass, got.Class())
+			assert.Equal(t, test.wantExchange, got.Exchange())
+			assert.Equal(t, test.wantAlphaVantage, got.AlphaVantage())
+
+			reparsed, err := symbol.Parse(got.String())
+			require.NoError(t, err)
+			assert.Equal(t, got, reparsed)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"", "  ", `""`, "BRK.", ".A", "BRK..A", "A.B.C", "ABR-P-", "ABR-P-D-E", "AA PL", "AAPL$", "ABCDEFGHIJKLM"} {
+		t.Run(s, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := symbol.Parse(s)
+
+			assert.ErrorIs(t, err, symbol.ErrInvalid)
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "BRK.A", symbol.Normalize(" brk-a "))
+	assert.Equal(t, "AAPL$", symbol.Normalize("AAPL$"))
+}
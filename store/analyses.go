@@ -2,11 +2,17 @@ package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // Analysis represents the analysis schema in database.
+// The user ID is nil for analyses made on behalf of no user, e.g. by the scheduled refresh.
 type Analysis struct {
 	Model
 
@@ -26,8 +32,8 @@ func (s *analysisService) Create(ctx context.Context, analysis *Analysis) (*Anal
 		RETURNING id, created_at, updated_at
 	`
 
-	err := s.db.pool.QueryRow(ctx, sql,
-		analysis.UserID,
+	err := s.db.conn(ctx).QueryRow(ctx, sql,
+		uuid.NullUUID{UUID: analysis.UserID, Valid: analysis.UserID != uuid.Nil},
 		analysis.StockID,
 		analysis.Score,
 	).Scan(&analysis.ID, &analysis.CreatedAt, &analysis.UpdatedAt)
@@ -37,3 +43,173 @@ func (s *analysisService) Create(ctx context.Context, analysis *Analysis) (*Anal
 
 	return analysis, nil
 }
+
+// AnalysisRecommendation represents an analysis of a stock along with the recommendation it concluded to.
+// The recommendation is nil if the analysis run did not complete.
+type AnalysisRecommendation struct {
+	Analysis
+
+	Symbol         string
+	Recommendation *Recommendation
+}
+
+// AnalysisFilter filters the listed analyses. Zero values are ignored.
+type AnalysisFilter struct {
+	Symbol string
+	UserID uuid.UUID
+	From   time.Time
+	To     time.Time
+	Action Action
+
+	Limit  int
+	Offset int
+}
+
+const analysisRecommendationColumns = `
+	a.id, a.user_id, a.stock_id, a.score::float8, a.created_at, a.updated_at, s.symbol,
+	r.id, r.action, r.confidence_level, r.reason, r.created_at, r.updated_at
+`
+
+func (s *analysisService) Find(ctx context.Context, id uuid.UUID) (*AnalysisRecommendation, error) {
+	sql := `
+		SELECT ` + analysisRecommendationColumns + `
+		FROM analysis a
+		INNER JOIN stock s ON a.stock_id = s.id
+		LEFT JOIN recommendation r ON r.analysis_id = a.id
+		WHERE a.id = $1
+	`
+
+	analysis, err := scanAnalysisRecommendation(s.db.conn(ctx).QueryRow(ctx, sql, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return analysis, nil
+}
+
+// List returns the analyses matching the filter, the most recent first unless ascending is set.
+func (s *analysisService) List(
+	ctx context.Context,
+	filter *AnalysisFilter,
+	ascending bool,
+) ([]AnalysisRecommendation, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Symbol != "" {
+		where("s.symbol = $%d", filter.Symbol)
+	}
+	if filter.UserID != uuid.Nil {
+		where("a.user_id = $%d", filter.UserID)
+	}
+	if !filter.From.IsZero() {
+		where("a.created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("a.created_at < $%d", filter.To)
+	}
+	if filter.Action != "" {
+		where("r.action = $%d", filter.Action)
+	}
+
+	sql := `
+		SELECT ` + analysisRecommendationColumns + `
+		FROM analysis a
+		INNER JOIN stock s ON a.stock_id = s.id
+		LEFT JOIN recommendation r ON r.analysis_id = a.id
+	`
+	if len(conditions) > 0 {
+		sql += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	order := "DESC"
+	if ascending {
+		order = "ASC"
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	sql += fmt.Sprintf(" ORDER BY a.created_at %s, a.id LIMIT $%d OFFSET $%d", order, len(args)-1, len(args))
+
+	rows, err := s.db.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var analyses []AnalysisRecommendation
+	for rows.Next() {
+		analysis, err := scanAnalysisRecommendation(rows)
+		if err != nil {
+			return nil, err
+		}
+		analyses = append(analyses, *analysis)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return analyses, nil
+}
+
+func scanAnalysisRecommendation(row pgx.Row) (*AnalysisRecommendation, error) {
+	var (
+		analysis AnalysisRecommendation
+
+		userID           uuid.NullUUID
+		recommendationID *uuid.UUID
+		action           *string
+		confidenceLevel  *float64
+		reason           *string
+		createdAt        *time.Time
+		updatedAt        *time.Time
+	)
+
+	err := row.Scan(
+		&analysis.ID,
+		&userID,
+		&analysis.StockID,
+		&analysis.Score,
+		&analysis.CreatedAt,
+		&analysis.UpdatedAt,
+		&analysis.Symbol,
+		&recommendationID,
+		&action,
+		&confidenceLevel,
+		&reason,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis.UserID = userID.UUID
+
+	if recommendationID != nil {
+		analysis.Recommendation = &Recommendation{
+			Model: Model{
+				ID:        *recommendationID,
+				CreatedAt: *createdAt,
+				UpdatedAt: *updatedAt,
+			},
+			AnalysisID:      analysis.ID,
+			Action:          Action(*action),
+			ConfidenceLevel: *confidenceLevel,
+		}
+		if reason != nil {
+			analysis.Recommendation.Reason = *reason
+		}
+	}
+
+	return &analysis, nil
+}
@@ -0,0 +1,55 @@
+package store_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_FindStockMetricsAsOfAnalysis(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+
+	sym := "T" + strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:11])
+	stock, err := s.CreateStock(t.Context(), &store.CreateStock{Symbol: sym, Company: "Test Inc."})
+	require.NoError(t, err)
+	metrics, err := s.ListMetrics(t.Context(), 1, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, metrics)
+
+	value := 1.5
+	var analysisID uuid.UUID
+	err = s.WithTx(t.Context(), func(ctx context.Context) error {
+		if _, err := s.CreateStockMetric(ctx, &store.CreateStockMetric{
+			StockID:  stock.ID,
+			MetricID: metrics[0].ID,
+			Value:    &value,
+		}); err != nil {
+			return err
+		}
+
+		analysis, err := s.CreateAnalysis(ctx, uuid.Nil, stock.ID, 5)
+		if err != nil {
+			return err
+		}
+		analysisID = analysis.ID
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	analysis, err := s.FindAnalysis(t.Context(), analysisID)
+	require.NoError(t, err)
+	got, err := s.FindStockMetricsAsOf(t.Context(), stock.ID, analysis.CreatedAt)
+
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, metrics[0].Name, got[0].MetricName)
+	assert.InDelta(t, value, *got[0].Value, 1e-9)
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// AnalysisJobResult represents the outcome of the analysis of a single symbol of a job.
+// The analysis ID is nil if the analysis failed.
+type AnalysisJobResult struct {
+	Symbol          string     `json:"symbol"`
+	AnalysisID      *uuid.UUID `json:"analysisId,omitempty"`
+	Score           float64    `json:"score,omitempty"`
+	Action          Action     `json:"action,omitempty"`
+	ConfidenceLevel float64    `json:"confidenceLevel,omitempty"`
+	Error           string     `json:"error,omitempty"`
+}
+
+// AnalysisJob represents the analysis job schema in database,
+// analyzing a list of symbols in the background on behalf of a user.
+type AnalysisJob struct {
+	Model
+
+	UserID     uuid.UUID
+	Symbols    []string
+	Profile    string
+	Status     JobStatus
+	Results    []AnalysisJobResult
+	Error      string
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+type analysisJobService struct {
+	db *DB
+}
+
+const analysisJobColumns = `
+	id, user_id, symbols, profile, status, results, error, started_at, finished_at, created_at, updated_at
+`
+
+func (s *analysisJobService) Create(ctx context.Context, job *AnalysisJob) (*AnalysisJob, error) {
+	sql := `
+		INSERT INTO analysis_job (user_id, symbols, profile)
+		VALUES ($1, $2, $3)
+		RETURNING ` + analysisJobColumns
+
+	return scanAnalysisJob(s.db.conn(ctx).QueryRow(ctx, sql, job.UserID, job.Symbols, job.Profile))
+}
+
+func (s *analysisJobService) Find(ctx context.Context, id uuid.UUID) (*AnalysisJob, error) {
+	sql := "SELECT " + analysisJobColumns + " FROM analysis_job WHERE id = $1"
+
+	return scanAnalysisJob(s.db.conn(ctx).QueryRow(ctx, sql, id))
+}
+
+// Start marks a pending or running job as running and returns it.
+// It returns ErrNotFound if the job does not exist or is already finished.
+func (s *analysisJobService) Start(ctx context.Context, id uuid.UUID) (*AnalysisJob, error) {
+	sql := `
+		UPDATE analysis_job
+		SET status = 'running',
+			started_at = COALESCE(started_at, CURRENT_TIMESTAMP)
+		WHERE id = $1 AND status IN ('pending', 'running')
+		RETURNING ` + analysisJobColumns
+
+	return scanAnalysisJob(s.db.conn(ctx).QueryRow(ctx, sql, id))
+}
+
+func (s *analysisJobService) UpdateResults(ctx context.Context, id uuid.UUID, results []AnalysisJobResult) error {
+	sql := "UPDATE analysis_job SET results = $1 WHERE id = $2"
+
+	res, err := s.db.conn(ctx).Exec(ctx, sql, results, id)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *analysisJobService) Finish(ctx context.Context, id uuid.UUID, status JobStatus, errMsg string) error {
+	sql := `
+		UPDATE analysis_job
+		SET status = $1,
+			error = $2,
+			finished_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+	`
+
+	res, err := s.db.conn(ctx).Exec(ctx, sql, status, errMsg, id)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func scanAnalysisJob(row pgx.Row) (*AnalysisJob, error) {
+	var job AnalysisJob
+	err := row.Scan(
+		&job.ID,
+		&job.UserID,
+		&job.Symbols,
+		&job.Profile,
+		&job.Status,
+		&job.Results,
+		&job.Error,
+		&job.StartedAt,
+		&job.FinishedAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return &job, nil
+}
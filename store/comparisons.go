@@ -0,0 +1,195 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockSnapshot represents a stock along with its latest score and recommended action, if analyzed,
+// and the latest known value of each of its metrics.
+type StockSnapshot struct {
+	Stock
+
+	Score    *float64
+	ScoredAt *time.Time
+	Action   Action
+	Metrics  map[string]float64
+}
+
+// PeerFilter contains the peer filtering information, the active stocks of a sector or an industry
+// apart from the compared stock.
+type PeerFilter struct {
+	Group   PeerGroup
+	Name    string
+	StockID uuid.UUID
+
+	Limit  int
+	Offset int
+}
+
+// Validate validates a PeerFilter configuration.
+func (f *PeerFilter) Validate() error {
+	var err error
+
+	if _, groupErr := f.Group.column(); groupErr != nil {
+		err = errors.Join(err, groupErr)
+	}
+
+	if f.Name == "" {
+		err = errors.Join(err, ValidationError{Err: "peer group name is required"})
+	}
+
+	if f.Limit <= 0 {
+		err = errors.Join(err, ValidationError{Err: "limit must be positive"})
+	}
+
+	if f.Offset < 0 {
+		err = errors.Join(err, ValidationError{Err: "offset must not be negative"})
+	}
+
+	return err
+}
+
+const snapshotColumns = `
+	` + stockColumns + `,
+	ls.score::float8,
+	ls.scored_at,
+	(SELECT r.action FROM recommendation r WHERE r.analysis_id = ls.analysis_id LIMIT 1)
+`
+
+// FindSnapshots returns the snapshots of the stocks with the given symbols, ordered by symbol.
+// Unknown symbols are left out.
+func (s *stockService) FindSnapshots(ctx context.Context, symbols []string) ([]StockSnapshot, error) {
+	sql := `
+		SELECT ` + snapshotColumns + `
+		FROM stock
+		LEFT JOIN latest_score ls ON ls.stock_id = stock.id
+		WHERE stock.symbol = ANY($1)
+		ORDER BY stock.symbol
+	`
+
+	return s.querySnapshots(ctx, sql, symbols)
+}
+
+// FindPeerSnapshots returns a page of the snapshots of the peers matching the filter,
+// ordered by decreasing latest score, the unscored ones last, then by symbol.
+func (s *stockService) FindPeerSnapshots(ctx context.Context, filter *PeerFilter) ([]StockSnapshot, error) {
+	column, err := filter.Group.column()
+	if err != nil {
+		return nil, err
+	}
+
+	sql := `
+		SELECT ` + snapshotColumns + `
+		FROM stock
+		LEFT JOIN latest_score ls ON ls.stock_id = stock.id
+		WHERE stock.` + column + ` = $1 AND stock.active AND stock.id <> $2
+		ORDER BY ls.score DESC NULLS LAST, stock.symbol
+		LIMIT $3 OFFSET $4
+	`
+
+	return s.querySnapshots(ctx, sql, filter.Name, filter.StockID, filter.Limit, filter.Offset)
+}
+
+// querySnapshots returns the snapshots of the stocks selected by the query, in its order,
+// along with the latest value of each of their metrics.
+func (s *stockService) querySnapshots(ctx context.Context, sql string, args ...any) ([]StockSnapshot, error) {
+	rows, err := s.db.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		snapshots []StockSnapshot
+		ids       []uuid.UUID
+	)
+	for rows.Next() {
+		var (
+			snapshot StockSnapshot
+			action   *string
+		)
+		err = rows.Scan(
+			&snapshot.ID,
+			&snapshot.Symbol,
+			&snapshot.Company,
+			&snapshot.Exchange,
+			&snapshot.Currency,
+			&snapshot.Sector,
+			&snapshot.Industry,
+			&snapshot.Active,
+			&snapshot.CreatedAt,
+			&snapshot.UpdatedAt,
+			&snapshot.Score,
+			&snapshot.ScoredAt,
+			&action,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if action != nil {
+			snapshot.Action = Action(*action)
+		}
+		snapshot.Metrics = map[string]float64{}
+
+		snapshots = append(snapshots, snapshot)
+		ids = append(ids, snapshot.ID)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	if len(snapshots) == 0 {
+		return snapshots, nil
+	}
+
+	sql = `
+		SELECT
+			DISTINCT ON (sm.stock_id, sm.metric_id)
+			sm.stock_id,
+			m.name AS metric_name,
+			sm.value::float8
+		FROM stock_metric sm
+		INNER JOIN metric m ON sm.metric_id = m.id
+		WHERE sm.stock_id = ANY($1)
+		ORDER BY sm.stock_id, sm.metric_id, COALESCE(sm.period_end, sm.reported_at::date) DESC, sm.reported_at DESC
+	`
+
+	rows, err = s.db.conn(ctx).Query(ctx, sql, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byID := make(map[uuid.UUID]*StockSnapshot, len(snapshots))
+	for i := range snapshots {
+		byID[snapshots[i].ID] = &snapshots[i]
+	}
+
+	for rows.Next() {
+		var (
+			stockID uuid.UUID
+			name    string
+			value   *float64
+		)
+		if err = rows.Scan(&stockID, &name, &value); err != nil {
+			return nil, err
+		}
+
+		// A missing latest value leaves the metric out.
+		if value != nil {
+			byID[stockID].Metrics[name] = *value
+		}
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return snapshots, nil
+}
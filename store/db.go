@@ -5,9 +5,22 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// querier is implemented by both the connection pool and a transaction.
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+type txKey struct{}
+
 // DB holds the overall store configuration.
 type DB struct {
 	pool *pgxpool.Pool
@@ -86,3 +99,20 @@ func NewDB(opts ...Option) (*DB, error) {
 		pool: pool,
 	}, nil
 }
+
+// conn returns the transaction carried by the context, or the connection pool outside of a transaction.
+func (p *DB) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	return p.pool
+}
+
+// withTx runs the function in a transaction carried by its context.
+// Within a transaction, the function runs in a nested transaction backed by a savepoint.
+func (p *DB) withTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return pgx.BeginFunc(ctx, p.conn(ctx), func(tx pgx.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
@@ -2,8 +2,13 @@ package store
 
 import (
 	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// uniqueViolationCode is the Postgres error code of a unique constraint violation.
+const uniqueViolationCode = "23505"
+
 // ErrNotFound represents a not found error in the store.
 var ErrNotFound = errors.New("not found")
 
@@ -16,3 +21,14 @@ type ValidationError struct {
 func (v ValidationError) Error() string {
 	return v.Err
 }
+
+// ErrAlreadyExists represents a conflict with an existing record in the store.
+var ErrAlreadyExists = errors.New("already exists")
+
+// ErrQuotaExceeded represents a call to an external provider beyond its quota.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
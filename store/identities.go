@@ -0,0 +1,253 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Identity represents the user identity schema in database,
+// linking an OIDC subject of an identity provider to a local user.
+type Identity struct {
+	Model
+
+	UserID   uuid.UUID
+	Provider string
+	Subject  string
+	Email    string
+}
+
+type identityService struct {
+	db *DB
+}
+
+// provisionAttempts is the number of times provisioning runs when racing a concurrent first sign in.
+const provisionAttempts = 2
+
+// errProvisionConflict reports an identity or email claimed by a concurrent sign in.
+var errProvisionConflict = errors.New("identity provisioned concurrently")
+
+// Provision returns the user linked to the identity, keeping its profile in sync with the given one.
+// An unknown identity is linked to the user signed in with its subject before identities existed,
+// to the user with the same verified email, or to a new user.
+// Unverified emails are never claimed by users, they are only kept on the identity.
+func (s *identityService) Provision(
+	ctx context.Context,
+	identity *Identity,
+	user *User,
+	emailVerified bool,
+) (*User, error) {
+	// Known identities whose claims did not change are served by a read-only lookup.
+	linked, err := s.find(ctx, identity)
+	switch {
+	case err == nil && inSync(linked, identity, user, emailVerified):
+		return linked.user, nil
+	case err != nil && !errors.Is(err, ErrNotFound):
+		return nil, err
+	}
+
+	for range provisionAttempts {
+		// A concurrent first sign in rolls the transaction back, the lookup finding its identity when run again.
+		err = s.db.withTx(ctx, func(ctx context.Context) error {
+			return s.provision(ctx, identity, user, emailVerified)
+		})
+		if !errors.Is(err, errProvisionConflict) && !isUniqueViolation(err) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// linkedIdentity represents an identity with its linked user.
+type linkedIdentity struct {
+	user  *User
+	email string
+}
+
+func (s *identityService) find(ctx context.Context, identity *Identity) (*linkedIdentity, error) {
+	sql := `
+		SELECT
+			u.id, COALESCE(u.email, ''), COALESCE(u.firstname, ''), COALESCE(u.lastname, ''), u.created_at, u.updated_at,
+			COALESCE(i.email, '')
+		FROM user_identity i
+		JOIN users u ON u.id = i.user_id
+		WHERE i.provider = $1 AND i.subject = $2
+	`
+
+	linked := &linkedIdentity{user: &User{}}
+	err := s.db.conn(ctx).QueryRow(ctx, sql, identity.Provider, identity.Subject).Scan(
+		&linked.user.ID,
+		&linked.user.Email,
+		&linked.user.Firstname,
+		&linked.user.Lastname,
+		&linked.user.CreatedAt,
+		&linked.user.UpdatedAt,
+		&linked.email,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return linked, nil
+}
+
+// inSync reports whether syncing the identity would leave the linked user and identity unchanged.
+func inSync(linked *linkedIdentity, identity *Identity, user *User, emailVerified bool) bool {
+	return linked.email == identity.Email &&
+		(!emailVerified || user.Email == "" || linked.user.Email == user.Email) &&
+		(user.Firstname == "" || linked.user.Firstname == user.Firstname) &&
+		(user.Lastname == "" || linked.user.Lastname == user.Lastname)
+}
+
+func (s *identityService) provision(ctx context.Context, identity *Identity, user *User, emailVerified bool) error {
+	tx := s.db.conn(ctx)
+
+	sql := "SELECT user_id FROM user_identity WHERE provider = $1 AND subject = $2 FOR UPDATE"
+	err := tx.QueryRow(ctx, sql, identity.Provider, identity.Subject).Scan(&user.ID)
+	switch {
+	case err == nil:
+		return syncIdentity(ctx, tx, identity, user, emailVerified)
+	case !errors.Is(err, pgx.ErrNoRows):
+		return err
+	}
+
+	// Users signed in before identities keep their subject until it is linked to an identity.
+	sql = "UPDATE users SET subject = NULL WHERE subject = $1 RETURNING id"
+	err = tx.QueryRow(ctx, sql, identity.Subject).Scan(&user.ID)
+	linked := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	email := ""
+	if emailVerified {
+		email = user.Email
+	}
+
+	if !linked && email != "" {
+		sql = "SELECT id FROM users WHERE email = $1 FOR UPDATE"
+		err = tx.QueryRow(ctx, sql, email).Scan(&user.ID)
+		switch {
+		case err == nil:
+			linked = true
+		case !errors.Is(err, pgx.ErrNoRows):
+			return err
+		}
+	}
+
+	if !linked {
+		sql = `
+			INSERT INTO users (email, firstname, lastname)
+			VALUES (NULLIF($1, ''), $2, $3)
+			ON CONFLICT (email) DO NOTHING
+			RETURNING id
+		`
+		err = tx.QueryRow(ctx, sql, email, user.Firstname, user.Lastname).Scan(&user.ID)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return errProvisionConflict
+		case err != nil:
+			return err
+		}
+	}
+
+	sql = `
+		INSERT INTO user_identity (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		ON CONFLICT (provider, subject) DO NOTHING
+	`
+	tag, err := tx.Exec(ctx, sql, user.ID, identity.Provider, identity.Subject, identity.Email)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errProvisionConflict
+	}
+
+	return syncIdentity(ctx, tx, identity, user, emailVerified)
+}
+
+// syncIdentity updates the linked user with the non-empty profile fields of the identity.
+// The email of the user is only updated with a verified email no other user has.
+func syncIdentity(ctx context.Context, tx querier, identity *Identity, user *User, emailVerified bool) error {
+	sql := `
+		UPDATE user_identity
+		SET email = NULLIF($1, '')
+		WHERE provider = $2 AND subject = $3 AND email IS DISTINCT FROM NULLIF($1, '')
+	`
+	if _, err := tx.Exec(ctx, sql, identity.Email, identity.Provider, identity.Subject); err != nil {
+		return err
+	}
+
+	email := ""
+	if emailVerified {
+		email = user.Email
+	}
+
+	sql = `
+		UPDATE users
+		SET email = CASE
+				WHEN NOT EXISTS (SELECT 1 FROM users other WHERE other.email = $1 AND other.id <> $4)
+				THEN COALESCE(NULLIF($1, ''), email)
+				ELSE email
+			END,
+			firstname = COALESCE(NULLIF($2, ''), firstname),
+			lastname = COALESCE(NULLIF($3, ''), lastname)
+		WHERE id = $4
+		RETURNING COALESCE(email, ''), COALESCE(firstname, ''), COALESCE(lastname, ''), created_at, updated_at
+	`
+
+	return tx.QueryRow(ctx, sql, email, user.Firstname, user.Lastname, user.ID).Scan(
+		&user.Email,
+		&user.Firstname,
+		&user.Lastname,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+}
+
+func (s *identityService) ListByUser(ctx context.Context, userID uuid.UUID) ([]Identity, error) {
+	sql := `
+		SELECT id, user_id, provider, subject, COALESCE(email, ''), created_at, updated_at
+		FROM user_identity
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+	rows, err := s.db.conn(ctx).Query(ctx, sql, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []Identity
+	for rows.Next() {
+		var identity Identity
+		if err := rows.Scan(
+			&identity.ID,
+			&identity.UserID,
+			&identity.Provider,
+			&identity.Subject,
+			&identity.Email,
+			&identity.CreatedAt,
+			&identity.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return identities, nil
+}
@@ -0,0 +1,218 @@
+package store_test
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/huy125/finscope/store"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testProvider = "https://issuer.example.com/"
+
+// testDSN returns the DSN of the migrated test database, skipping the test when FINSCOPE_TEST_DSN is not set.
+func testDSN(t *testing.T) string {
+	t.Helper()
+
+	dsn := os.Getenv("FINSCOPE_TEST_DSN")
+	if dsn == "" {
+		t.Skip("FINSCOPE_TEST_DSN is not set")
+	}
+
+	return dsn
+}
+
+// newTestStore connects to the migrated test database.
+func newTestStore(t *testing.T) *store.Store {
+	t.Helper()
+
+	db, err := store.NewDB(store.WithDSN(testDSN(t)), store.WithMaxConns(10))
+	require.NoError(t, err)
+
+	return store.New(db)
+}
+
+// execTestSQL runs a statement on the test database, for state the store cannot create.
+func execTestSQL(t *testing.T, sql string, args ...any) {
+	t.Helper()
+
+	conn, err := pgx.Connect(t.Context(), testDSN(t))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close(t.Context()) }()
+
+	_, err = conn.Exec(t.Context(), sql, args...)
+	require.NoError(t, err)
+}
+
+func TestStore_ProvisionUserLinksVerifiedEmail(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+	prefix := uuid.NewString()
+	email := prefix + "@example.com"
+
+	existing, err := s.ProvisionUser(t.Context(), &store.ProvisionUser{
+		Provider:      testProvider,
+		Subject:       prefix + "-existing",
+		Email:         email,
+		EmailVerified: true,
+	})
+	require.NoError(t, err)
+
+	got, err := s.ProvisionUser(t.Context(), &store.ProvisionUser{
+		Provider:      testProvider,
+		Subject:       prefix + "-new",
+		Email:         email,
+		EmailVerified: true,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, got.ID)
+	assert.Equal(t, email, got.Email)
+}
+
+func TestStore_ProvisionUserLinksSubjectOfExistingUser(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+	prefix := uuid.NewString()
+	existing, err := s.CreateUser(t.Context(), &store.CreateUser{
+		Email:     prefix + "@example.com",
+		Firstname: "Jane",
+		Lastname:  "Doe",
+	})
+	require.NoError(t, err)
+	execTestSQL(t, "UPDATE users SET subject = $1 WHERE id = $2", prefix, existing.ID)
+
+	got, err := s.ProvisionUser(t.Context(), &store.ProvisionUser{Provider: testProvider, Subject: prefix})
+	require.NoError(t, err)
+	other, err := s.ProvisionUser(t.Context(), &store.ProvisionUser{Provider: testProvider + "other/", Subject: prefix})
+
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, got.ID)
+	assert.NotEqual(t, existing.ID, other.ID)
+}
+
+func TestStore_ProvisionUserWithUnverifiedEmailOfExistingUser(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+	prefix := uuid.NewString()
+	email := prefix + "@example.com"
+
+	existing, err := s.ProvisionUser(t.Context(), &store.ProvisionUser{
+		Provider:      testProvider,
+		Subject:       prefix + "-existing",
+		Email:         email,
+		EmailVerified: true,
+	})
+	require.NoError(t, err)
+
+	got, err := s.ProvisionUser(t.Context(), &store.ProvisionUser{
+		Provider: testProvider,
+		Subject:  prefix + "-new",
+		Email:    email,
+	})
+
+	require.NoError(t, err)
+	assert.NotEqual(t, existing.ID, got.ID)
+	assert.Empty(t, got.Email)
+}
+
+func TestStore_ProvisionUserSyncingEmailOfAnotherUser(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+	prefix := uuid.NewString()
+	email := prefix + "@example.com"
+
+	_, err := s.ProvisionUser(t.Context(), &store.ProvisionUser{
+		Provider:      testProvider,
+		Subject:       prefix + "-other",
+		Email:         email,
+		EmailVerified: true,
+	})
+	require.NoError(t, err)
+	user := &store.ProvisionUser{
+		Provider:      testProvider,
+		Subject:       prefix + "-user",
+		Email:         prefix + "-user@example.com",
+		EmailVerified: true,
+	}
+	existing, err := s.ProvisionUser(t.Context(), user)
+	require.NoError(t, err)
+
+	user.Email = email
+	got, err := s.ProvisionUser(t.Context(), user)
+
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, got.ID)
+	assert.Equal(t, prefix+"-user@example.com", got.Email)
+}
+
+func TestStore_ProvisionUserKnownIdentity(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+	prefix := uuid.NewString()
+	user := &store.ProvisionUser{
+		Provider:      testProvider,
+		Subject:       prefix,
+		Email:         prefix + "@example.com",
+		EmailVerified: true,
+		Firstname:     "Jane",
+		Lastname:      "Doe",
+	}
+	existing, err := s.ProvisionUser(t.Context(), user)
+	require.NoError(t, err)
+
+	unchanged, err := s.ProvisionUser(t.Context(), user)
+	require.NoError(t, err)
+	user.Lastname = "Smith"
+	renamed, err := s.ProvisionUser(t.Context(), user)
+
+	require.NoError(t, err)
+	assert.Equal(t, existing.UpdatedAt, unchanged.UpdatedAt)
+	assert.Equal(t, existing.ID, renamed.ID)
+	assert.Equal(t, "Smith", renamed.Lastname)
+	assert.True(t, renamed.UpdatedAt.After(existing.UpdatedAt))
+}
+
+func TestStore_ProvisionUserConcurrently(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+	prefix := uuid.NewString()
+	const signIns = 5
+
+	var wg sync.WaitGroup
+	ids := make([]uuid.UUID, signIns)
+	errs := make([]error, signIns)
+	for i := range signIns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			user, err := s.ProvisionUser(t.Context(), &store.ProvisionUser{
+				Provider:      testProvider,
+				Subject:       prefix,
+				Email:         prefix + "@example.com",
+				EmailVerified: true,
+			})
+			errs[i] = err
+			if err == nil {
+				ids[i] = user.ID
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range signIns {
+		require.NoError(t, errs[i])
+		assert.Equal(t, ids[0], ids[i])
+	}
+}
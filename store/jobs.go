@@ -0,0 +1,187 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// JobStatus represents the lifecycle state of a background job.
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+	// JobStatusPartial marks a scheduled run stopped before the end, resumed by a job queued for later.
+	JobStatusPartial JobStatus = "partial"
+	// JobStatusDead marks a queued job that failed all its attempts and is no longer retried.
+	JobStatusDead JobStatus = "dead"
+)
+
+// Job represents the job schema in database, a unit of background work of a given type
+// claimed by a single worker at a time.
+// A running job is locked until the locked until time, after which it is claimed again by another worker.
+type Job struct {
+	Model
+
+	Type        string
+	Payload     json.RawMessage
+	Status      JobStatus
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LockedUntil *time.Time
+	LastError   string
+}
+
+type jobService struct {
+	db *DB
+}
+
+const jobColumns = `
+	id, type, payload, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, updated_at
+`
+
+func (s *jobService) Enqueue(
+	ctx context.Context,
+	typ string,
+	payload any,
+	maxAttempts int,
+	runAt time.Time,
+) (*Job, error) {
+	sql := `
+		INSERT INTO job (type, payload, max_attempts, run_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + jobColumns
+
+	return scanJob(s.db.conn(ctx).QueryRow(ctx, sql, typ, payload, maxAttempts, runAt))
+}
+
+// Claim marks the next job of the given types due to run as running, locked until the given time, and returns it.
+// A running job whose lock expired is considered abandoned and claimed again.
+// Jobs locked by other workers are skipped rather than waited for.
+func (s *jobService) Claim(ctx context.Context, types []string, lockedUntil time.Time) (*Job, error) {
+	sql := `
+		UPDATE job
+		SET status = 'running',
+			attempts = attempts + 1,
+			locked_until = $2
+		WHERE id = (
+			SELECT id
+			FROM job
+			WHERE type = ANY($1)
+				AND (
+					(status = 'pending' AND run_at <= CURRENT_TIMESTAMP)
+					OR (status = 'running' AND locked_until < CURRENT_TIMESTAMP)
+				)
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+
+	return scanJob(s.db.conn(ctx).QueryRow(ctx, sql, types, lockedUntil))
+}
+
+// Extend moves the lock of a running job forward.
+func (s *jobService) Extend(ctx context.Context, id uuid.UUID, lockedUntil time.Time) error {
+	sql := "UPDATE job SET locked_until = $1 WHERE id = $2 AND status = 'running'"
+
+	return s.exec(ctx, sql, lockedUntil, id)
+}
+
+func (s *jobService) Complete(ctx context.Context, id uuid.UUID) error {
+	sql := `
+		UPDATE job
+		SET status = 'succeeded',
+			locked_until = NULL,
+			last_error = ''
+		WHERE id = $1
+	`
+
+	return s.exec(ctx, sql, id)
+}
+
+// Fail records the error of a job run, scheduling the job to run again at the given time
+// or moving it to the dead letters once it has no attempts left. It returns the resulting status.
+func (s *jobService) Fail(ctx context.Context, id uuid.UUID, errMsg string, retryAt time.Time) (JobStatus, error) {
+	sql := `
+		UPDATE job
+		SET status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
+			run_at = $1,
+			locked_until = NULL,
+			last_error = $2
+		WHERE id = $3
+		RETURNING status
+	`
+
+	var status JobStatus
+	if err := s.db.conn(ctx).QueryRow(ctx, sql, retryAt, errMsg, id).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNotFound
+		}
+
+		return "", err
+	}
+
+	return status, nil
+}
+
+// Release puts a running job back in the queue without counting the interrupted attempt.
+func (s *jobService) Release(ctx context.Context, id uuid.UUID) error {
+	sql := `
+		UPDATE job
+		SET status = 'pending',
+			attempts = GREATEST(attempts - 1, 0),
+			run_at = CURRENT_TIMESTAMP,
+			locked_until = NULL
+		WHERE id = $1 AND status = 'running'
+	`
+
+	return s.exec(ctx, sql, id)
+}
+
+func (s *jobService) exec(ctx context.Context, sql string, args ...any) error {
+	res, err := s.db.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func scanJob(row pgx.Row) (*Job, error) {
+	var job Job
+	err := row.Scan(
+		&job.ID,
+		&job.Type,
+		&job.Payload,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.RunAt,
+		&job.LockedUntil,
+		&job.LastError,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return &job, nil
+}
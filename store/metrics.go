@@ -18,7 +18,7 @@ type metricService struct {
 
 func (s *metricService) ListMetrics(ctx context.Context, limit, offset int) ([]Metric, error) {
 	sql := "SELECT id, name, description FROM metric LIMIT $1 OFFSET $2"
-	rows, err := s.db.pool.Query(ctx, sql, limit, offset)
+	rows, err := s.db.conn(ctx).Query(ctx, sql, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// StockPrice represents the daily price bar schema of a stock in database.
+type StockPrice struct {
+	Model
+
+	StockID uuid.UUID
+	Date    time.Time
+	Open    float64
+	High    float64
+	Low     float64
+	Close   float64
+	Volume  int64
+}
+
+type priceService struct {
+	db *DB
+}
+
+func (s *priceService) Upsert(ctx context.Context, prices []StockPrice) error {
+	sql := `
+		INSERT INTO stock_price (stock_id, date, open, high, low, close, volume)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (stock_id, date) DO UPDATE
+		SET open = EXCLUDED.open,
+			high = EXCLUDED.high,
+			low = EXCLUDED.low,
+			close = EXCLUDED.close,
+			volume = EXCLUDED.volume
+	`
+
+	batch := &pgx.Batch{}
+	for _, p := range prices {
+		batch.Queue(sql, p.StockID, p.Date, p.Open, p.High, p.Low, p.Close, p.Volume)
+	}
+
+	return s.db.conn(ctx).SendBatch(ctx, batch).Close()
+}
+
+func (s *priceService) List(ctx context.Context, from, to time.Time) ([]StockPrice, error) {
+	sql := `
+		SELECT id, stock_id, date, open::float8, high::float8, low::float8, close::float8, volume, created_at, updated_at
+		FROM stock_price
+		WHERE date BETWEEN $1 AND $2
+		ORDER BY stock_id, date
+	`
+
+	rows, err := s.db.conn(ctx).Query(ctx, sql, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []StockPrice
+	for rows.Next() {
+		var p StockPrice
+		if err := rows.Scan(
+			&p.ID,
+			&p.StockID,
+			&p.Date,
+			&p.Open,
+			&p.High,
+			&p.Low,
+			&p.Close,
+			&p.Volume,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		prices = append(prices, p)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return prices, nil
+}
@@ -0,0 +1,48 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type providerQuotaService struct {
+	db *DB
+}
+
+// Reserve takes the next call slot of a provider and returns its time, the slots being spaced out by the interval.
+// Without waiting, only a slot due right away is taken. It returns ErrQuotaExceeded if the daily limit
+// is reached or, without waiting, if no slot is due.
+func (s *providerQuotaService) Reserve(
+	ctx context.Context,
+	provider string,
+	interval time.Duration,
+	dailyLimit int,
+	wait bool,
+) (time.Time, error) {
+	sql := `
+		INSERT INTO provider_quota AS q (provider, next_call_at, day, calls)
+		VALUES ($1, CURRENT_TIMESTAMP + $2::float8 * INTERVAL '1 second', (CURRENT_TIMESTAMP AT TIME ZONE 'UTC')::date, 1)
+		ON CONFLICT (provider) DO UPDATE
+		SET next_call_at = GREATEST(q.next_call_at, CURRENT_TIMESTAMP) + $2::float8 * INTERVAL '1 second',
+			calls = CASE WHEN q.day = EXCLUDED.day THEN q.calls + 1 ELSE 1 END,
+			day = EXCLUDED.day
+		WHERE ($3::int <= 0 OR q.day <> EXCLUDED.day OR q.calls < $3::int)
+			AND ($4::boolean OR q.next_call_at <= CURRENT_TIMESTAMP)
+		RETURNING next_call_at - $2::float8 * INTERVAL '1 second'
+	`
+
+	var slot time.Time
+	err := s.db.conn(ctx).QueryRow(ctx, sql, provider, interval.Seconds(), dailyLimit, wait).Scan(&slot)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, ErrQuotaExceeded
+		}
+
+		return time.Time{}, err
+	}
+
+	return slot, nil
+}
@@ -0,0 +1,36 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_ReserveProviderCall(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+	call := &store.ReserveProviderCall{
+		Provider:   uuid.NewString(),
+		Interval:   time.Minute,
+		DailyLimit: 2,
+		Wait:       true,
+	}
+
+	first, err := s.ReserveProviderCall(t.Context(), call)
+	require.NoError(t, err)
+	call.Wait = false
+	_, tryErr := s.ReserveProviderCall(t.Context(), call)
+	call.Wait = true
+	second, err := s.ReserveProviderCall(t.Context(), call)
+	require.NoError(t, err)
+	_, exceededErr := s.ReserveProviderCall(t.Context(), call)
+
+	require.ErrorIs(t, tryErr, store.ErrQuotaExceeded)
+	require.ErrorIs(t, exceededErr, store.ErrQuotaExceeded)
+	assert.WithinDuration(t, first.Add(time.Minute), second, time.Second)
+}
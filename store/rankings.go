@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RankingFilter filters the ranked stocks. Zero values are ignored.
+type RankingFilter struct {
+	Sector string
+
+	Limit  int
+	Offset int
+}
+
+// Validate validates a RankingFilter configuration.
+func (f *RankingFilter) Validate() error {
+	var err error
+
+	if f.Limit <= 0 {
+		err = errors.Join(err, ValidationError{Err: "limit must be positive"})
+	}
+
+	if f.Offset < 0 {
+		err = errors.Join(err, ValidationError{Err: "offset must not be negative"})
+	}
+
+	return err
+}
+
+// StockRanking represents the rank of an active stock by its latest analysis score,
+// along with its latest score as of a week and a month before, if it was analyzed by then.
+type StockRanking struct {
+	Stock
+
+	Rank          int
+	Score         float64
+	ScoredAt      time.Time
+	WeekAgoScore  *float64
+	MonthAgoScore *float64
+}
+
+// ListRankings returns the active stocks ordered by decreasing latest score, the symbol breaking ties.
+// Stocks of equal scores share the same rank. The past scores are the latest ones as of the given times.
+func (s *analysisService) ListRankings(
+	ctx context.Context,
+	filter *RankingFilter,
+	weekAgo, monthAgo time.Time,
+) ([]StockRanking, error) {
+	args := []any{weekAgo, monthAgo}
+
+	where := "WHERE stock.active"
+	if filter.Sector != "" {
+		args = append(args, filter.Sector)
+		where += fmt.Sprintf(" AND stock.sector = $%d", len(args))
+	}
+	args = append(args, filter.Limit, filter.Offset)
+
+	sql := `
+		SELECT ` + stockColumns + `, ranked.rank, ranked.score, ranked.scored_at, week_ago.score, month_ago.score
+		FROM (
+			SELECT
+				` + stockColumns + `,
+				RANK() OVER (ORDER BY ls.score DESC) AS rank,
+				ls.score::float8 AS score,
+				ls.scored_at
+			FROM stock
+			INNER JOIN latest_score ls ON ls.stock_id = stock.id
+			` + where + `
+			ORDER BY ls.score DESC, stock.symbol
+			LIMIT $` + fmt.Sprint(len(args)-1) + ` OFFSET $` + fmt.Sprint(len(args)) + `
+		) ranked
+		LEFT JOIN LATERAL (
+			SELECT a.score::float8 AS score
+			FROM analysis a
+			WHERE a.stock_id = ranked.id AND a.created_at <= $1
+			ORDER BY a.created_at DESC
+			LIMIT 1
+		) week_ago ON true
+		LEFT JOIN LATERAL (
+			SELECT a.score::float8 AS score
+			FROM analysis a
+			WHERE a.stock_id = ranked.id AND a.created_at <= $2
+			ORDER BY a.created_at DESC
+			LIMIT 1
+		) month_ago ON true
+		ORDER BY ranked.rank, ranked.symbol
+	`
+
+	rows, err := s.db.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rankings []StockRanking
+	for rows.Next() {
+		var ranking StockRanking
+		err = rows.Scan(
+			&ranking.ID,
+			&ranking.Symbol,
+			&ranking.Company,
+			&ranking.Exchange,
+			&ranking.Currency,
+			&ranking.Sector,
+			&ranking.Industry,
+			&ranking.Active,
+			&ranking.CreatedAt,
+			&ranking.UpdatedAt,
+			&ranking.Rank,
+			&ranking.Score,
+			&ranking.ScoredAt,
+			&ranking.WeekAgoScore,
+			&ranking.MonthAgoScore,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		rankings = append(rankings, ranking)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return rankings, nil
+}
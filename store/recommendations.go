@@ -41,7 +41,7 @@ func (r *recommendationService) Create(ctx context.Context, recommendation *Reco
 		RETURNING id, created_at, updated_at
 	`
 
-	err := r.db.pool.QueryRow(ctx, sql,
+	err := r.db.conn(ctx).QueryRow(ctx, sql,
 		recommendation.AnalysisID,
 		recommendation.Action,
 		recommendation.ConfidenceLevel,
@@ -0,0 +1,284 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxScreenNameLength bounds the length of the saved screen names.
+const maxScreenNameLength = 100
+
+// SavedScreen represents the saved screen schema in database, a screener query saved by a user under a name.
+// The query holds the screener query parameters, URL encoded.
+// The changes recorded after SeenAt are unread by the user, UnreadChanges counts them.
+type SavedScreen struct {
+	Model
+
+	UserID        uuid.UUID
+	Name          string
+	Query         string
+	SeenAt        time.Time
+	UnreadChanges int
+}
+
+// ScreenChange represents the saved screen change schema in database,
+// the stocks entering and leaving the result of a saved screen after a refresh.
+type ScreenChange struct {
+	ID        uuid.UUID
+	ScreenID  uuid.UUID
+	RunID     *uuid.UUID
+	Entered   []string
+	Exited    []string
+	CreatedAt time.Time
+}
+
+// Changed reports whether stocks entered or left the saved screen.
+func (c *ScreenChange) Changed() bool {
+	return len(c.Entered) > 0 || len(c.Exited) > 0
+}
+
+type savedScreenService struct {
+	db *DB
+}
+
+const savedScreenColumns = `
+	id, user_id, name, query, created_at, updated_at, seen_at,
+	(
+		SELECT count(*)
+		FROM saved_screen_change
+		WHERE screen_id = saved_screen.id AND created_at > saved_screen.seen_at
+	)
+`
+
+const screenChangeColumns = `
+	id, screen_id, run_id, entered, exited, created_at
+`
+
+// Create records a saved screen. It returns ErrAlreadyExists if the user already has a screen of the same name.
+func (s *savedScreenService) Create(ctx context.Context, screen *SavedScreen) (*SavedScreen, error) {
+	sql := `
+		INSERT INTO saved_screen (user_id, name, query)
+		VALUES ($1, $2, $3)
+		RETURNING ` + savedScreenColumns
+
+	created, err := scanSavedScreen(s.db.conn(ctx).QueryRow(ctx, sql, screen.UserID, screen.Name, screen.Query))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrAlreadyExists
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *savedScreenService) Find(ctx context.Context, id uuid.UUID) (*SavedScreen, error) {
+	sql := "SELECT " + savedScreenColumns + " FROM saved_screen WHERE id = $1"
+
+	return scanSavedScreen(s.db.conn(ctx).QueryRow(ctx, sql, id))
+}
+
+// List returns the saved screens of a user, or of every user if the user ID is nil, ordered by name.
+func (s *savedScreenService) List(ctx context.Context, userID uuid.UUID) ([]SavedScreen, error) {
+	sql := "SELECT " + savedScreenColumns + " FROM saved_screen"
+	var args []any
+	if userID != uuid.Nil {
+		sql += " WHERE user_id = $1"
+		args = append(args, userID)
+	}
+	sql += " ORDER BY name, id"
+
+	rows, err := s.db.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var screens []SavedScreen
+	for rows.Next() {
+		screen, err := scanSavedScreen(rows)
+		if err != nil {
+			return nil, err
+		}
+		screens = append(screens, *screen)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return screens, nil
+}
+
+// MarkSeen marks the changes of a saved screen recorded so far as read.
+func (s *savedScreenService) MarkSeen(ctx context.Context, id uuid.UUID) (*SavedScreen, error) {
+	sql := `
+		UPDATE saved_screen
+		SET seen_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING ` + savedScreenColumns
+
+	return scanSavedScreen(s.db.conn(ctx).QueryRow(ctx, sql, id))
+}
+
+func (s *savedScreenService) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := s.db.conn(ctx).Exec(ctx, "DELETE FROM saved_screen WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ListMembers returns the stocks matching a saved screen as of its last refresh, ordered by symbol.
+func (s *savedScreenService) ListMembers(ctx context.Context, screenID uuid.UUID) ([]Stock, error) {
+	sql := `
+		SELECT ` + stockColumns + `
+		FROM stock
+		WHERE id IN (SELECT stock_id FROM saved_screen_member WHERE screen_id = $1)
+		ORDER BY symbol
+	`
+
+	rows, err := s.db.conn(ctx).Query(ctx, sql, screenID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stocks []Stock
+	for rows.Next() {
+		stock, err := scanStock(rows)
+		if err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, *stock)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return stocks, nil
+}
+
+// SetMembers replaces the stocks matching a saved screen.
+func (s *savedScreenService) SetMembers(ctx context.Context, screenID uuid.UUID, stockIDs []uuid.UUID) error {
+	sql := "DELETE FROM saved_screen_member WHERE screen_id = $1 AND NOT (stock_id = ANY($2))"
+	if _, err := s.db.conn(ctx).Exec(ctx, sql, screenID, stockIDs); err != nil {
+		return err
+	}
+
+	sql = `
+		INSERT INTO saved_screen_member (screen_id, stock_id)
+		SELECT $1, unnest($2::uuid[])
+		ON CONFLICT (screen_id, stock_id) DO NOTHING
+	`
+	_, err := s.db.conn(ctx).Exec(ctx, sql, screenID, stockIDs)
+
+	return err
+}
+
+func (s *savedScreenService) CreateChange(ctx context.Context, change *ScreenChange) (*ScreenChange, error) {
+	sql := `
+		INSERT INTO saved_screen_change (screen_id, run_id, entered, exited)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + screenChangeColumns
+
+	return scanScreenChange(s.db.conn(ctx).QueryRow(
+		ctx,
+		sql,
+		change.ScreenID,
+		change.RunID,
+		change.Entered,
+		change.Exited,
+	))
+}
+
+// ListChanges returns the changes of a saved screen, the most recent first.
+func (s *savedScreenService) ListChanges(
+	ctx context.Context,
+	screenID uuid.UUID,
+	limit, offset int,
+) ([]ScreenChange, error) {
+	sql := `
+		SELECT ` + screenChangeColumns + `
+		FROM saved_screen_change
+		WHERE screen_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := s.db.conn(ctx).Query(ctx, sql, screenID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []ScreenChange
+	for rows.Next() {
+		change, err := scanScreenChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *change)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return changes, nil
+}
+
+func scanSavedScreen(row pgx.Row) (*SavedScreen, error) {
+	var screen SavedScreen
+	err := row.Scan(
+		&screen.ID,
+		&screen.UserID,
+		&screen.Name,
+		&screen.Query,
+		&screen.CreatedAt,
+		&screen.UpdatedAt,
+		&screen.SeenAt,
+		&screen.UnreadChanges,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return &screen, nil
+}
+
+func scanScreenChange(row pgx.Row) (*ScreenChange, error) {
+	var change ScreenChange
+	err := row.Scan(
+		&change.ID,
+		&change.ScreenID,
+		&change.RunID,
+		&change.Entered,
+		&change.Exited,
+		&change.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return &change, nil
+}
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ScheduledRun represents the scheduled run schema in database, the log of a run of a schedule
+// and the progress of the job it queued.
+type ScheduledRun struct {
+	Model
+
+	Schedule     string
+	JobType      string
+	ScheduledFor time.Time
+	Status       JobStatus
+	Total        int
+	Succeeded    int
+	Failed       int
+	LastSymbol   string
+	Error        string
+	StartedAt    *time.Time
+	FinishedAt   *time.Time
+}
+
+// ScheduledRunPayload is the payload of the jobs queued by the scheduler.
+type ScheduledRunPayload struct {
+	RunID uuid.UUID `json:"runId"`
+}
+
+type scheduledRunService struct {
+	db *DB
+}
+
+const scheduledRunColumns = `
+	id, schedule, job_type, scheduled_for, status, total, succeeded, failed, last_symbol, error,
+	started_at, finished_at, created_at, updated_at
+`
+
+// Create records a run of a schedule. It returns ErrAlreadyExists if the run is already recorded.
+func (s *scheduledRunService) Create(
+	ctx context.Context,
+	schedule, jobType string,
+	scheduledFor time.Time,
+) (*ScheduledRun, error) {
+	sql := `
+		INSERT INTO scheduled_run (schedule, job_type, scheduled_for)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (schedule, scheduled_for) DO NOTHING
+		RETURNING ` + scheduledRunColumns
+
+	run, err := scanScheduledRun(s.db.conn(ctx).QueryRow(ctx, sql, schedule, jobType, scheduledFor))
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrAlreadyExists
+	}
+
+	return run, err
+}
+
+// Start marks a pending, running or partial run as running and returns it.
+// It returns ErrNotFound if the run does not exist or is already finished.
+func (s *scheduledRunService) Start(ctx context.Context, id uuid.UUID) (*ScheduledRun, error) {
+	sql := `
+		UPDATE scheduled_run
+		SET status = 'running',
+			started_at = COALESCE(started_at, CURRENT_TIMESTAMP)
+		WHERE id = $1 AND status IN ('pending', 'running', 'partial')
+		RETURNING ` + scheduledRunColumns
+
+	return scanScheduledRun(s.db.conn(ctx).QueryRow(ctx, sql, id))
+}
+
+func (s *scheduledRunService) UpdateProgress(
+	ctx context.Context,
+	id uuid.UUID,
+	total, succeeded, failed int,
+	lastSymbol string,
+) error {
+	sql := "UPDATE scheduled_run SET total = $1, succeeded = $2, failed = $3, last_symbol = $4 WHERE id = $5"
+
+	res, err := s.db.conn(ctx).Exec(ctx, sql, total, succeeded, failed, lastSymbol, id)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *scheduledRunService) Finish(ctx context.Context, id uuid.UUID, status JobStatus, errMsg string) error {
+	sql := `
+		UPDATE scheduled_run
+		SET status = $1,
+			error = $2,
+			finished_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+	`
+
+	res, err := s.db.conn(ctx).Exec(ctx, sql, status, errMsg, id)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Pause marks a running run as partial.
+func (s *scheduledRunService) Pause(ctx context.Context, id uuid.UUID, errMsg string) error {
+	sql := "UPDATE scheduled_run SET status = 'partial', error = $1 WHERE id = $2 AND status = 'running'"
+
+	res, err := s.db.conn(ctx).Exec(ctx, sql, errMsg, id)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func scanScheduledRun(row pgx.Row) (*ScheduledRun, error) {
+	var run ScheduledRun
+	err := row.Scan(
+		&run.ID,
+		&run.Schedule,
+		&run.JobType,
+		&run.ScheduledFor,
+		&run.Status,
+		&run.Total,
+		&run.Succeeded,
+		&run.Failed,
+		&run.LastSymbol,
+		&run.Error,
+		&run.StartedAt,
+		&run.FinishedAt,
+		&run.CreatedAt,
+		&run.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return &run, nil
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hamba/logger/v2"
+	lctx "github.com/hamba/logger/v2/ctx"
+	"github.com/huy125/finscope/pkg/cron"
+)
+
+// scheduleTimeout bounds the recording of a scheduled run.
+const scheduleTimeout = 10 * time.Second
+
+// Schedule queues a job of the given type at each activation of a cron schedule.
+type Schedule struct {
+	Name    string
+	JobType string
+	Cron    *cron.Schedule
+}
+
+// Scheduler queues the jobs of schedules, recording each run in the run log.
+// Several schedulers can run at once, each run being queued by the first one to record it.
+type Scheduler struct {
+	store     *Store
+	schedules []Schedule
+	location  *time.Location
+
+	log *logger.Logger
+}
+
+// NewScheduler returns a scheduler evaluating the cron schedules in the given location.
+func NewScheduler(s *Store, schedules []Schedule, location *time.Location, log *logger.Logger) *Scheduler {
+	return &Scheduler{
+		store:     s,
+		schedules: schedules,
+		location:  location,
+		log:       log.With(lctx.Str("component", "scheduler")),
+	}
+}
+
+// Run queues the jobs of the schedules until the context is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, schedule := range s.schedules {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			s.run(ctx, schedule)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) run(ctx context.Context, schedule Schedule) {
+	log := s.log.With(lctx.Str("schedule", schedule.Name))
+
+	for {
+		next, err := schedule.Cron.Next(time.Now().In(s.location))
+		if err != nil {
+			log.Error("Failed to compute next run", lctx.Error("error", err))
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.schedule(ctx, log, schedule, next)
+	}
+}
+
+func (s *Scheduler) schedule(ctx context.Context, log *logger.Logger, schedule Schedule, at time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, scheduleTimeout)
+	defer cancel()
+
+	run, err := s.store.ScheduleRun(ctx, &CreateScheduledRun{
+		Schedule:     schedule.Name,
+		JobType:      schedule.JobType,
+		ScheduledFor: at,
+	})
+	switch {
+	case err == nil:
+		log.Info("Scheduled run queued", lctx.Str("run", run.ID.String()), lctx.Time("at", at))
+	case errors.Is(err, ErrAlreadyExists):
+		log.Debug("Scheduled run already queued", lctx.Time("at", at))
+	default:
+		log.Error("Failed to queue scheduled run", lctx.Time("at", at), lctx.Error("error", err))
+	}
+}
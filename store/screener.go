@@ -0,0 +1,295 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ScreenOperator compares the latest value of a metric with a screen condition value.
+type ScreenOperator string
+
+// ScreenOperator constants.
+const (
+	OpLess         ScreenOperator = "<"
+	OpLessEqual    ScreenOperator = "<="
+	OpGreater      ScreenOperator = ">"
+	OpGreaterEqual ScreenOperator = ">="
+	OpEqual        ScreenOperator = "="
+	OpNotEqual     ScreenOperator = "!="
+)
+
+// ScreenOperators lists the operators of the screen conditions, the longest first
+// so that an expression is matched against "<=" before "<".
+var ScreenOperators = []ScreenOperator{OpLessEqual, OpGreaterEqual, OpNotEqual, OpLess, OpGreater, OpEqual}
+
+// Sort fields of a screen, besides the metric names.
+const (
+	ScreenSortSymbol = "symbol"
+	ScreenSortScore  = "score"
+)
+
+// ScreenCondition requires the latest value of a metric to compare to a value.
+// Stocks missing the metric never match.
+type ScreenCondition struct {
+	Metric   string
+	Operator ScreenOperator
+	Value    float64
+}
+
+// ScreenCursor is the position of the last screened stock of a page, the next page starting after it.
+// The value is the sort value of the stock, unused when sorting by symbol.
+type ScreenCursor struct {
+	Value  float64
+	Symbol string
+}
+
+// StockScreen filters the active stocks by their latest metric values and their latest analysis.
+// Stocks are sorted by symbol, by latest score or by the latest value of a metric, the symbol breaking ties.
+// Stocks missing the sort value are left out.
+type StockScreen struct {
+	Conditions []ScreenCondition
+	MinScore   *float64
+	MaxScore   *float64
+	Action     Action
+	Sector     string
+
+	Sort string
+	Desc bool
+
+	After *ScreenCursor
+	Limit int
+}
+
+// Validate validates a StockScreen configuration.
+func (s *StockScreen) Validate() error {
+	var err error
+
+	for _, c := range s.Conditions {
+		if c.Metric == "" {
+			err = errors.Join(err, ValidationError{Err: "condition metric is required"})
+		}
+		if !isScreenOperator(c.Operator) {
+			err = errors.Join(err, ValidationError{Err: "condition operator is invalid"})
+		}
+	}
+
+	if s.MinScore != nil && s.MaxScore != nil && *s.MinScore > *s.MaxScore {
+		err = errors.Join(err, ValidationError{Err: "min score must not exceed max score"})
+	}
+
+	switch s.Action {
+	case "", ActionStrongBuy, ActionBuy, ActionHold, ActionSell, ActionStrongSell:
+	default:
+		err = errors.Join(err, ValidationError{Err: "action is invalid"})
+	}
+
+	if s.Sort == "" {
+		err = errors.Join(err, ValidationError{Err: "sort is required"})
+	}
+
+	if s.After != nil && s.After.Symbol == "" {
+		err = errors.Join(err, ValidationError{Err: "cursor symbol is required"})
+	}
+
+	if s.Limit <= 0 {
+		err = errors.Join(err, ValidationError{Err: "limit must be positive"})
+	}
+
+	return err
+}
+
+// metrics returns the metrics the screen refers to, without duplicates.
+func (s *StockScreen) metrics() []string {
+	var names []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, c := range s.Conditions {
+		add(c.Metric)
+	}
+	if s.Sort != ScreenSortSymbol && s.Sort != ScreenSortScore {
+		add(s.Sort)
+	}
+
+	return names
+}
+
+func isScreenOperator(op ScreenOperator) bool {
+	for _, o := range ScreenOperators {
+		if op == o {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ScreenedStock represents a stock matching a screen, with its latest score and recommended action, if analyzed,
+// and the latest values of the metrics the screen refers to.
+type ScreenedStock struct {
+	Stock
+
+	Score   *float64
+	Action  Action
+	Metrics map[string]float64
+}
+
+// Screen returns a page of the active stocks matching a screen.
+// The latest metric values are found with a DISTINCT ON query across all stocks,
+// the latest scores and recommended actions are read from latest_score.
+func (s *stockService) Screen(ctx context.Context, screen *StockScreen) ([]ScreenedStock, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	metrics := screen.metrics()
+	metricAlias := make(map[string]string, len(metrics))
+
+	var sql string
+	if len(metrics) > 0 {
+		sql += `
+		WITH latest_metric AS (
+			SELECT
+				DISTINCT ON (sm.stock_id, sm.metric_id)
+				sm.stock_id,
+				m.name AS metric_name,
+				sm.value::float8 AS value
+			FROM stock_metric sm
+			INNER JOIN metric m ON sm.metric_id = m.id
+			WHERE m.name = ANY(` + arg(metrics) + `)
+			ORDER BY sm.stock_id, sm.metric_id, COALESCE(sm.period_end, sm.reported_at::date) DESC, sm.reported_at DESC
+		)`
+	}
+
+	sql += `
+		SELECT ` + stockColumns + `, ls.score::float8, lr.action`
+	for i, name := range metrics {
+		metricAlias[name] = "lm" + strconv.Itoa(i)
+		sql += ", " + metricAlias[name] + ".value"
+	}
+
+	sql += `
+		FROM stock
+		LEFT JOIN latest_score ls ON ls.stock_id = stock.id
+		LEFT JOIN LATERAL (
+			SELECT r.action FROM recommendation r WHERE r.analysis_id = ls.analysis_id LIMIT 1
+		) lr ON true`
+	for _, name := range metrics {
+		alias := metricAlias[name]
+		sql += `
+		LEFT JOIN latest_metric ` + alias + ` ON ` + alias + `.stock_id = stock.id AND ` +
+			alias + `.metric_name = ` + arg(name)
+	}
+
+	conds := []string{"stock.active"}
+	for _, c := range screen.Conditions {
+		conds = append(conds, metricAlias[c.Metric]+".value "+string(c.Operator)+" "+arg(c.Value))
+	}
+	if screen.MinScore != nil {
+		conds = append(conds, "ls.score >= "+arg(*screen.MinScore))
+	}
+	if screen.MaxScore != nil {
+		conds = append(conds, "ls.score <= "+arg(*screen.MaxScore))
+	}
+	if screen.Action != "" {
+		conds = append(conds, "lr.action = "+arg(screen.Action))
+	}
+	if screen.Sector != "" {
+		conds = append(conds, "stock.sector = "+arg(screen.Sector))
+	}
+
+	cmp, dir := ">", "ASC"
+	if screen.Desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	var order string
+	switch screen.Sort {
+	case ScreenSortSymbol:
+		order = "stock.symbol " + dir
+		if screen.After != nil {
+			conds = append(conds, "stock.symbol "+cmp+" "+arg(screen.After.Symbol))
+		}
+	default:
+		key := "ls.score::float8"
+		if screen.Sort != ScreenSortScore {
+			key = metricAlias[screen.Sort] + ".value"
+		}
+
+		order = key + " " + dir + ", stock.symbol ASC"
+		conds = append(conds, key+" IS NOT NULL")
+		if screen.After != nil {
+			value, symbol := arg(screen.After.Value), arg(screen.After.Symbol)
+			conds = append(conds, "("+key+" "+cmp+" "+value+" OR ("+key+" = "+value+" AND stock.symbol > "+symbol+"))")
+		}
+	}
+
+	sql += `
+		WHERE ` + strings.Join(conds, " AND ") + `
+		ORDER BY ` + order + `
+		LIMIT ` + arg(screen.Limit)
+
+	rows, err := s.db.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stocks []ScreenedStock
+	for rows.Next() {
+		var (
+			stock  ScreenedStock
+			action *string
+			values = make([]*float64, len(metrics))
+		)
+
+		dest := []any{
+			&stock.ID,
+			&stock.Symbol,
+			&stock.Company,
+			&stock.Exchange,
+			&stock.Currency,
+			&stock.Sector,
+			&stock.Industry,
+			&stock.Active,
+			&stock.CreatedAt,
+			&stock.UpdatedAt,
+			&stock.Score,
+			&action,
+		}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		if action != nil {
+			stock.Action = Action(*action)
+		}
+		stock.Metrics = make(map[string]float64, len(metrics))
+		for i, name := range metrics {
+			if values[i] != nil {
+				stock.Metrics[name] = *values[i]
+			}
+		}
+
+		stocks = append(stocks, stock)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return stocks, nil
+}
@@ -3,49 +3,352 @@ package store
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/huy125/finscope/pkg/symbol"
 	"github.com/jackc/pgx/v5"
 )
 
 // Stock represents the stock schema in database.
+// Inactive stocks are kept for their history but are no longer refreshed.
 type Stock struct {
 	Model
 
-	Symbol  string
-	Company string
+	Symbol   string
+	Company  string
+	Exchange string
+	Currency string
+	Sector   string
+	Industry string
+	Active   bool
+}
+
+// StockMatch represents a stock matching a search.
+// The rank measures the relevance of the match, from 0 to 1 for an exact symbol.
+type StockMatch struct {
+	Stock
+
+	Rank float64
 }
 
 // StockMetric represents the join table between stock and metric schema in database.
+// A nil value means the metric was missing from the provider data when it was recorded.
+// The period end is the end of the fiscal period the value refers to, if known,
+// and the reported time is when the value was published.
 type StockMetric struct {
 	Model
 
-	StockID  uuid.UUID
-	MetricID uuid.UUID
-	Value    float64
+	StockID    uuid.UUID
+	MetricID   uuid.UUID
+	Value      *float64
+	PeriodEnd  *time.Time
+	ReportedAt time.Time
+}
+
+// PeerGroup represents a stock classification used to compare a stock with its peers.
+type PeerGroup string
+
+const (
+	PeerGroupSector   PeerGroup = "sector"
+	PeerGroupIndustry PeerGroup = "industry"
+)
+
+// column returns the stock column holding the peer group.
+func (g PeerGroup) column() (string, error) {
+	switch g {
+	case PeerGroupSector:
+		return "sector", nil
+	case PeerGroupIndustry:
+		return "industry", nil
+	default:
+		return "", ValidationError{Err: "peer group is invalid"}
+	}
+}
+
+// MetricDistribution represents the latest values of a metric across the stocks of a peer group.
+type MetricDistribution struct {
+	MetricName string
+	Values     []float64
 }
 
 // LatestStockMetric represents the most recent stock metric schema.
+// A nil value means the metric was missing from the latest provider data.
 type LatestStockMetric struct {
 	MetricName string
-	Value      float64
+	Value      *float64
+	PeriodEnd  *time.Time
+	ReportedAt time.Time
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
 
+// HistoricalStockMetric represents a stock metric value as published at a point in time.
+// A nil value means the metric was missing from the provider data when it was recorded.
+type HistoricalStockMetric struct {
+	StockID    uuid.UUID
+	MetricName string
+	Value      *float64
+	PeriodEnd  *time.Time
+	ReportedAt time.Time
+}
+
 type stockService struct {
 	db *DB
 }
 
+const stockColumns = `
+	id, symbol, company, exchange, currency, sector, industry, active, created_at, updated_at
+`
+
 func (s *stockService) Find(ctx context.Context, symbol string) (*Stock, error) {
-	sql := "SELECT id, symbol, company FROM stock WHERE symbol = $1"
-	var stock Stock
+	sql := "SELECT " + stockColumns + " FROM stock WHERE symbol = $1"
+
+	return scanStock(s.db.conn(ctx).QueryRow(ctx, sql, symbol))
+}
+
+func (s *stockService) List(ctx context.Context, filter *StockFilter) ([]Stock, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	where := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Query != "" {
+		// The symbol is matched by prefix and the company anywhere in its name.
+		args = append(args, escapeLike(filter.Query))
+		conds = append(conds, fmt.Sprintf(
+			"(symbol ILIKE $%[1]d || '%%' OR company ILIKE '%%' || $%[1]d || '%%')",
+			len(args),
+		))
+	}
+	if filter.Sector != "" {
+		where("sector = $%d", filter.Sector)
+	}
+	if filter.Industry != "" {
+		where("industry = $%d", filter.Industry)
+	}
+	if filter.Exchange != "" {
+		where("exchange = $%d", filter.Exchange)
+	}
+	if filter.Active != nil {
+		where("active = $%d", *filter.Active)
+	}
+	if filter.After != "" {
+		where("symbol > $%d", filter.After)
+	}
+
+	sql := "SELECT " + stockColumns + " FROM stock"
+	if len(conds) > 0 {
+		sql += " WHERE " + strings.Join(conds, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	sql += fmt.Sprintf(" ORDER BY symbol LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := s.db.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stocks []Stock
+	for rows.Next() {
+		stock, err := scanStock(rows)
+		if err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, *stock)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return stocks, nil
+}
+
+// ListAll returns every stock, ordered by symbol.
+func (s *stockService) ListAll(ctx context.Context) ([]Stock, error) {
+	rows, err := s.db.conn(ctx).Query(ctx, "SELECT "+stockColumns+" FROM stock ORDER BY symbol")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var stocks []Stock
+	for rows.Next() {
+		stock, err := scanStock(rows)
+		if err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, *stock)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return stocks, nil
+}
+
+// Search returns the stocks whose symbol or company name resembles the query, the best matches first.
+// The symbol matches exactly, by prefix or by trigram similarity,
+// and the company name by trigram similarity with any of its words or by substring.
+func (s *stockService) Search(ctx context.Context, query string, limit int) ([]StockMatch, error) {
+	sql := `
+		SELECT ` + stockColumns + `, rank
+		FROM (
+			SELECT *,
+				GREATEST(
+					CASE
+						WHEN symbol = $2 THEN 1
+						WHEN symbol LIKE $3 || '%' THEN 0.9
+						ELSE 0
+					END,
+					word_similarity($1, company),
+					similarity(symbol, $2)
+				)::FLOAT8 AS rank
+			FROM stock
+			WHERE symbol LIKE $3 || '%'
+				OR symbol % $2
+				OR $1 <% company
+				OR company ILIKE '%' || $4 || '%'
+		) AS matches
+		ORDER BY rank DESC, symbol
+		LIMIT $5`
+
+	sym := symbol.Normalize(strings.ToUpper(query))
+	rows, err := s.db.conn(ctx).Query(ctx, sql, query, sym, escapeLike(sym), escapeLike(query), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []StockMatch
+	for rows.Next() {
+		var match StockMatch
+		err = rows.Scan(
+			&match.ID,
+			&match.Symbol,
+			&match.Company,
+			&match.Exchange,
+			&match.Currency,
+			&match.Sector,
+			&match.Industry,
+			&match.Active,
+			&match.CreatedAt,
+			&match.UpdatedAt,
+			&match.Rank,
+		)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return matches, nil
+}
+
+// Create inserts a stock. It returns ErrAlreadyExists if the symbol is already known.
+func (s *stockService) Create(ctx context.Context, stock *Stock) (*Stock, error) {
+	sql := `
+		INSERT INTO stock (symbol, company, exchange, currency, sector, industry, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + stockColumns
 
-	err := s.db.pool.QueryRow(ctx, sql, symbol).Scan(
+	created, err := scanStock(s.db.conn(ctx).QueryRow(ctx, sql,
+		stock.Symbol,
+		stock.Company,
+		stock.Exchange,
+		stock.Currency,
+		stock.Sector,
+		stock.Industry,
+		stock.Active,
+	))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrAlreadyExists
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// Update updates the details of the stock with the symbol.
+func (s *stockService) Update(ctx context.Context, stock *Stock) (*Stock, error) {
+	sql := `
+		UPDATE stock
+		SET company = $1,
+			exchange = $2,
+			currency = $3,
+			sector = $4,
+			industry = $5,
+			active = $6
+		WHERE symbol = $7
+		RETURNING ` + stockColumns
+
+	return scanStock(s.db.conn(ctx).QueryRow(ctx, sql,
+		stock.Company,
+		stock.Exchange,
+		stock.Currency,
+		stock.Sector,
+		stock.Industry,
+		stock.Active,
+		stock.Symbol,
+	))
+}
+
+func (s *stockService) Deactivate(ctx context.Context, symbol string) error {
+	sql := "UPDATE stock SET active = FALSE WHERE symbol = $1"
+
+	res, err := s.db.conn(ctx).Exec(ctx, sql, symbol)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *stockService) UpdateClassification(ctx context.Context, stock *Stock) (*Stock, error) {
+	sql := `
+		UPDATE stock
+		SET sector = $1,
+			industry = $2
+		WHERE id = $3
+		RETURNING ` + stockColumns
+
+	return scanStock(s.db.conn(ctx).QueryRow(ctx, sql, stock.Sector, stock.Industry, stock.ID))
+}
+
+func scanStock(row pgx.Row) (*Stock, error) {
+	var stock Stock
+	err := row.Scan(
 		&stock.ID,
 		&stock.Symbol,
 		&stock.Company,
+		&stock.Exchange,
+		&stock.Currency,
+		&stock.Sector,
+		&stock.Industry,
+		&stock.Active,
+		&stock.CreatedAt,
+		&stock.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -60,16 +363,23 @@ func (s *stockService) Find(ctx context.Context, symbol string) (*Stock, error)
 
 func (s *stockService) CreateStockMetric(ctx context.Context, stockMetric StockMetric) (*StockMetric, error) {
 	sql := `
-		INSERT INTO stock_metric (stock_id, metric_id, value)
-		VALUES ($1, $2, $3)
-		RETURNING id, created_at, updated_at
+		INSERT INTO stock_metric (stock_id, metric_id, value, period_end, reported_at)
+		VALUES ($1, $2, $3, $4, COALESCE($5, CURRENT_TIMESTAMP))
+		RETURNING id, reported_at, created_at, updated_at
 	`
 
-	err := s.db.pool.QueryRow(ctx, sql,
+	var reportedAt *time.Time
+	if !stockMetric.ReportedAt.IsZero() {
+		reportedAt = &stockMetric.ReportedAt
+	}
+
+	err := s.db.conn(ctx).QueryRow(ctx, sql,
 		stockMetric.StockID,
 		stockMetric.MetricID,
 		stockMetric.Value,
-	).Scan(&stockMetric.ID, &stockMetric.CreatedAt, &stockMetric.UpdatedAt)
+		stockMetric.PeriodEnd,
+		reportedAt,
+	).Scan(&stockMetric.ID, &stockMetric.ReportedAt, &stockMetric.CreatedAt, &stockMetric.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -77,21 +387,29 @@ func (s *stockService) CreateStockMetric(ctx context.Context, stockMetric StockM
 	return &stockMetric, nil
 }
 
-func (s *stockService) FindLastestStockMetrics(ctx context.Context, stockID uuid.UUID) ([]LatestStockMetric, error) {
+// FindStockMetricsAsOf returns the latest value of each metric of a stock as known at the given time,
+// i.e. the value of the most recent fiscal period reported until then.
+func (s *stockService) FindStockMetricsAsOf(
+	ctx context.Context,
+	stockID uuid.UUID,
+	asOf time.Time,
+) ([]LatestStockMetric, error) {
 	sql := `
 			SELECT
-				DISTINCT ON (sm.metric_id) 
+				DISTINCT ON (sm.metric_id)
 				m.name AS metric_name,
 				sm.value,
+				sm.period_end,
+				sm.reported_at,
 				sm.created_at,
 				sm.updated_at
 			FROM stock_metric sm
 			INNER JOIN metric m ON sm.metric_id = m.id
-			WHERE sm.stock_id = $1
-			ORDER BY sm.metric_id, sm.created_at DESC
+			WHERE sm.stock_id = $1 AND sm.reported_at <= $2
+			ORDER BY sm.metric_id, COALESCE(sm.period_end, sm.reported_at::date) DESC, sm.reported_at DESC
 		`
 
-	rows, err := s.db.pool.Query(ctx, sql, stockID)
+	rows, err := s.db.conn(ctx).Query(ctx, sql, stockID, asOf)
 	if err != nil {
 		return nil, err
 	}
@@ -103,6 +421,8 @@ func (s *stockService) FindLastestStockMetrics(ctx context.Context, stockID uuid
 		if err := rows.Scan(
 			&stockMetric.MetricName,
 			&stockMetric.Value,
+			&stockMetric.PeriodEnd,
+			&stockMetric.ReportedAt,
 			&stockMetric.CreatedAt,
 			&stockMetric.UpdatedAt,
 		); err != nil {
@@ -117,3 +437,120 @@ func (s *stockService) FindLastestStockMetrics(ctx context.Context, stockID uuid
 
 	return stockMetrics, nil
 }
+
+func (s *stockService) FindMetricDistributions(
+	ctx context.Context,
+	group PeerGroup,
+	name string,
+	asOf time.Time,
+) ([]MetricDistribution, error) {
+	column, err := group.column()
+	if err != nil {
+		return nil, err
+	}
+
+	sql := `
+		SELECT
+			m.name AS metric_name,
+			array_agg(latest.value::float8 ORDER BY latest.value)
+		FROM (
+			SELECT
+				DISTINCT ON (sm.stock_id, sm.metric_id)
+				sm.metric_id,
+				sm.value
+			FROM stock_metric sm
+			INNER JOIN stock s ON sm.stock_id = s.id
+			WHERE s.` + column + ` = $1 AND s.active AND sm.reported_at <= $2
+			ORDER BY sm.stock_id, sm.metric_id, COALESCE(sm.period_end, sm.reported_at::date) DESC, sm.reported_at DESC
+		) latest
+		INNER JOIN metric m ON latest.metric_id = m.id
+		WHERE latest.value IS NOT NULL
+		GROUP BY m.name
+	`
+
+	rows, err := s.db.conn(ctx).Query(ctx, sql, name, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var distributions []MetricDistribution
+	for rows.Next() {
+		var distribution MetricDistribution
+		if err := rows.Scan(&distribution.MetricName, &distribution.Values); err != nil {
+			return nil, err
+		}
+		distributions = append(distributions, distribution)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return distributions, nil
+}
+
+func (s *stockService) FindStockMetricHistory(
+	ctx context.Context,
+	from, until time.Time,
+) ([]HistoricalStockMetric, error) {
+	sql := `
+		SELECT stock_id, metric_name, value, period_end, reported_at
+		FROM (
+			SELECT
+				DISTINCT ON (sm.stock_id, sm.metric_id)
+				sm.stock_id,
+				m.name AS metric_name,
+				sm.value::float8,
+				sm.period_end,
+				sm.reported_at
+			FROM stock_metric sm
+			INNER JOIN metric m ON sm.metric_id = m.id
+			WHERE sm.reported_at <= $1
+			ORDER BY sm.stock_id, sm.metric_id, COALESCE(sm.period_end, sm.reported_at::date) DESC, sm.reported_at DESC
+		) known
+		UNION ALL
+		SELECT
+			sm.stock_id,
+			m.name AS metric_name,
+			sm.value::float8,
+			sm.period_end,
+			sm.reported_at
+		FROM stock_metric sm
+		INNER JOIN metric m ON sm.metric_id = m.id
+		WHERE sm.reported_at > $1 AND sm.reported_at <= $2
+		ORDER BY reported_at
+	`
+
+	rows, err := s.db.conn(ctx).Query(ctx, sql, from, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stockMetrics []HistoricalStockMetric
+	for rows.Next() {
+		var stockMetric HistoricalStockMetric
+		if err := rows.Scan(
+			&stockMetric.StockID,
+			&stockMetric.MetricName,
+			&stockMetric.Value,
+			&stockMetric.PeriodEnd,
+			&stockMetric.ReportedAt,
+		); err != nil {
+			return nil, err
+		}
+		stockMetrics = append(stockMetrics, stockMetric)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return stockMetrics, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
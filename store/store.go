@@ -3,10 +3,14 @@ package store
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/huy125/finscope/pkg/listing"
+	"github.com/huy125/finscope/pkg/symbol"
 )
 
 // Store manages the database layer of applications.
@@ -14,10 +18,18 @@ type Store struct {
 	db *DB
 
 	users           *userService
+	identities      *identityService
 	stocks          *stockService
 	metrics         *metricService
 	analyses        *analysisService
 	recommendations *recommendationService
+	prices          *priceService
+	analysisJobs    *analysisJobService
+	jobs            *jobService
+	scheduledRuns   *scheduledRunService
+	savedScreens    *savedScreenService
+	watchlists      *watchlistService
+	providerQuotas  *providerQuotaService
 }
 
 // Model represents common entity fields.
@@ -33,10 +45,18 @@ func New(db *DB) *Store {
 	}
 
 	store.users = &userService{db: db}
+	store.identities = &identityService{db: db}
 	store.stocks = &stockService{db: db}
 	store.metrics = &metricService{db: db}
 	store.analyses = &analysisService{db: db}
 	store.recommendations = &recommendationService{db: db}
+	store.prices = &priceService{db: db}
+	store.analysisJobs = &analysisJobService{db: db}
+	store.jobs = &jobService{db: db}
+	store.scheduledRuns = &scheduledRunService{db: db}
+	store.savedScreens = &savedScreenService{db: db}
+	store.watchlists = &watchlistService{db: db}
+	store.providerQuotas = &providerQuotaService{db: db}
 
 	return store
 }
@@ -48,6 +68,54 @@ type CreateUser struct {
 	Lastname  string
 }
 
+// CreateStockMetric contains stock metric creation information.
+// A nil value records the metric as missing. A zero reported time defaults to now.
+type CreateStockMetric struct {
+	StockID    uuid.UUID
+	MetricID   uuid.UUID
+	Value      *float64
+	PeriodEnd  *time.Time
+	ReportedAt time.Time
+}
+
+// ProvisionUser contains the profile of a user signing in through an identity provider.
+type ProvisionUser struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Firstname     string
+	Lastname      string
+}
+
+// CreateAnalysisJob contains analysis job creation information.
+type CreateAnalysisJob struct {
+	UserID  uuid.UUID
+	Symbols []string
+	Profile string
+}
+
+// CreateSavedScreen contains saved screen creation information.
+// The stocks are the current result of the screen, from which its changes are told.
+type CreateSavedScreen struct {
+	UserID uuid.UUID
+	Name   string
+	Query  string
+	Stocks []Stock
+}
+
+// CreateWatchlist contains watchlist creation information.
+type CreateWatchlist struct {
+	UserID uuid.UUID
+	Name   string
+}
+
+// RenameWatchlist contains watchlist renaming information.
+type RenameWatchlist struct {
+	ID   uuid.UUID
+	Name string
+}
+
 // UpdateUser contains user updating information.
 type UpdateUser struct {
 	CreateUser
@@ -85,12 +153,347 @@ func (u *UpdateUser) Validate() error {
 	return u.CreateUser.Validate()
 }
 
+// Validate validates a ProvisionUser configuration.
+func (u *ProvisionUser) Validate() error {
+	var err error
+
+	if u.Provider == "" {
+		err = errors.Join(err, ValidationError{Err: "provider is required"})
+	}
+
+	if u.Subject == "" {
+		err = errors.Join(err, ValidationError{Err: "subject is required"})
+	}
+
+	if u.Email != "" && !isValidEmail(u.Email) {
+		err = errors.Join(err, ValidationError{Err: "email is invalid"})
+	}
+
+	return err
+}
+
+// StockFilter filters the listed stocks. Zero values are ignored.
+// The query matches the beginning of the symbol or any part of the company name.
+type StockFilter struct {
+	Query    string
+	Sector   string
+	Industry string
+	Exchange string
+	Active   *bool
+	// After restricts the stocks to the symbols sorting after it.
+	After  string
+	Limit  int
+	Offset int
+}
+
+// Validate validates a StockFilter configuration.
+func (f *StockFilter) Validate() error {
+	var err error
+
+	if f.Limit <= 0 {
+		err = errors.Join(err, ValidationError{Err: "limit must be positive"})
+	}
+
+	if f.Offset < 0 {
+		err = errors.Join(err, ValidationError{Err: "offset must not be negative"})
+	}
+
+	return err
+}
+
+// StockSearch contains stock search information.
+// The query is matched fuzzily against the symbols and the company names.
+type StockSearch struct {
+	Query string
+	Limit int
+}
+
+// Validate validates a StockSearch configuration.
+func (s *StockSearch) Validate() error {
+	var err error
+
+	if strings.TrimSpace(s.Query) == "" {
+		err = errors.Join(err, ValidationError{Err: "query is required"})
+	}
+
+	if s.Limit <= 0 {
+		err = errors.Join(err, ValidationError{Err: "limit must be positive"})
+	}
+
+	return err
+}
+
+// CreateStock contains stock creation information.
+type CreateStock struct {
+	Symbol   string
+	Company  string
+	Exchange string
+	Currency string
+	Sector   string
+	Industry string
+}
+
+// Validate validates a CreateStock configuration.
+func (c *CreateStock) Validate() error {
+	var err error
+
+	switch _, symErr := symbol.Parse(c.Symbol); {
+	case c.Symbol == "":
+		err = errors.Join(err, ValidationError{Err: "symbol is required"})
+	case symErr != nil:
+		err = errors.Join(err, ValidationError{Err: "symbol is invalid"})
+	}
+
+	if c.Company == "" {
+		err = errors.Join(err, ValidationError{Err: "company is required"})
+	}
+
+	if c.Currency != "" && !isCurrencyCode(c.Currency) {
+		err = errors.Join(err, ValidationError{Err: "currency must be a 3 letter code"})
+	}
+
+	return err
+}
+
+// UpdateStock contains stock updating information, the stock being identified by its symbol.
+type UpdateStock struct {
+	CreateStock
+
+	Active bool
+}
+
+// Validate validates an UpdateStock configuration.
+func (u *UpdateStock) Validate() error {
+	return u.CreateStock.Validate()
+}
+
+// ImportStocks contains the listings of a file to import into the stock universe.
+type ImportStocks struct {
+	Listings []listing.Listing
+	// Currency is the currency of the added stocks.
+	Currency string
+	// AssetTypes are the asset types of the added stocks, every type if empty.
+	// The listings of the other types still cover their exchanges.
+	AssetTypes []string
+	// DryRun computes the changes without applying them.
+	DryRun bool
+}
+
+// Validate validates an ImportStocks configuration.
+func (i *ImportStocks) Validate() error {
+	var err error
+
+	if len(i.Listings) == 0 {
+		err = errors.Join(err, ValidationError{Err: "listings are required"})
+	}
+
+	if i.Currency != "" && !isCurrencyCode(i.Currency) {
+		err = errors.Join(err, ValidationError{Err: "currency must be a 3 letter code"})
+	}
+
+	return err
+}
+
+// Validate validates an AnalysisFilter configuration.
+func (f *AnalysisFilter) Validate() error {
+	var err error
+
+	if f.Limit <= 0 {
+		err = errors.Join(err, ValidationError{Err: "limit must be positive"})
+	}
+
+	if f.Offset < 0 {
+		err = errors.Join(err, ValidationError{Err: "offset must not be negative"})
+	}
+
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		err = errors.Join(err, ValidationError{Err: "from must be before to"})
+	}
+
+	switch f.Action {
+	case "", ActionStrongBuy, ActionBuy, ActionHold, ActionSell, ActionStrongSell:
+	default:
+		err = errors.Join(err, ValidationError{Err: "action is invalid"})
+	}
+
+	return err
+}
+
+// EnqueueJob contains background job information.
+// The payload is encoded as JSON. A zero run at time runs the job as soon as possible
+// and zero max attempts defaults to DefaultMaxAttempts.
+type EnqueueJob struct {
+	Type        string
+	Payload     any
+	RunAt       time.Time
+	MaxAttempts int
+}
+
+// DefaultMaxAttempts is the number of times a job is run before it is moved to the dead letters.
+const DefaultMaxAttempts = 5
+
+// Validate validates an EnqueueJob configuration.
+func (e *EnqueueJob) Validate() error {
+	var err error
+
+	if e.Type == "" {
+		err = errors.Join(err, ValidationError{Err: "type is required"})
+	}
+
+	if e.MaxAttempts < 0 {
+		err = errors.Join(err, ValidationError{Err: "max attempts must not be negative"})
+	}
+
+	return err
+}
+
+// CreateScheduledRun contains scheduled run creation information.
+type CreateScheduledRun struct {
+	Schedule     string
+	JobType      string
+	ScheduledFor time.Time
+}
+
+// Validate validates a CreateScheduledRun configuration.
+func (c *CreateScheduledRun) Validate() error {
+	var err error
+
+	if c.Schedule == "" {
+		err = errors.Join(err, ValidationError{Err: "schedule is required"})
+	}
+
+	if c.JobType == "" {
+		err = errors.Join(err, ValidationError{Err: "job type is required"})
+	}
+
+	if c.ScheduledFor.IsZero() {
+		err = errors.Join(err, ValidationError{Err: "scheduled for is required"})
+	}
+
+	return err
+}
+
+// ReserveProviderCall contains the quota of an external provider call.
+// A zero interval or daily limit leaves the calls unlimited by it.
+type ReserveProviderCall struct {
+	Provider   string
+	Interval   time.Duration
+	DailyLimit int
+	// Wait takes the next slot even if it is not due yet, instead of failing.
+	Wait bool
+}
+
+// Validate validates a ReserveProviderCall configuration.
+func (r *ReserveProviderCall) Validate() error {
+	var err error
+
+	if r.Provider == "" {
+		err = errors.Join(err, ValidationError{Err: "provider is required"})
+	}
+
+	if r.Interval < 0 {
+		err = errors.Join(err, ValidationError{Err: "interval must not be negative"})
+	}
+
+	if r.DailyLimit < 0 {
+		err = errors.Join(err, ValidationError{Err: "daily limit must not be negative"})
+	}
+
+	return err
+}
+
+// Validate validates a CreateAnalysisJob configuration.
+func (c *CreateAnalysisJob) Validate() error {
+	var err error
+
+	if c.UserID == uuid.Nil {
+		err = errors.Join(err, ValidationError{Err: "user id is required"})
+	}
+
+	if len(c.Symbols) == 0 {
+		err = errors.Join(err, ValidationError{Err: "symbols are required"})
+	}
+
+	return err
+}
+
+// Validate validates a CreateSavedScreen configuration.
+func (c *CreateSavedScreen) Validate() error {
+	var err error
+
+	if c.UserID == uuid.Nil {
+		err = errors.Join(err, ValidationError{Err: "user id is required"})
+	}
+
+	if strings.TrimSpace(c.Name) == "" {
+		err = errors.Join(err, ValidationError{Err: "name is required"})
+	} else if len(c.Name) > maxScreenNameLength {
+		err = errors.Join(err, ValidationError{Err: fmt.Sprintf("name must not exceed %d characters", maxScreenNameLength)})
+	}
+
+	return err
+}
+
+// Validate validates a CreateWatchlist configuration.
+func (c *CreateWatchlist) Validate() error {
+	var err error
+
+	if c.UserID == uuid.Nil {
+		err = errors.Join(err, ValidationError{Err: "user id is required"})
+	}
+
+	return errors.Join(err, validateWatchlistName(c.Name))
+}
+
+// Validate validates a RenameWatchlist configuration.
+func (r *RenameWatchlist) Validate() error {
+	var err error
+
+	if r.ID == uuid.Nil {
+		err = errors.Join(err, ValidationError{Err: "id is required"})
+	}
+
+	return errors.Join(err, validateWatchlistName(r.Name))
+}
+
+func validateWatchlistName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return ValidationError{Err: "name is required"}
+	case len(name) > maxWatchlistNameLength:
+		return ValidationError{Err: fmt.Sprintf("name must not exceed %d characters", maxWatchlistNameLength)}
+	default:
+		return nil
+	}
+}
+
+// isCurrencyCode reports whether the string is an ISO 4217 like code, e.g. USD.
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
 func isValidEmail(email string) bool {
 	_, err := mail.ParseAddress(email)
 
 	return err == nil
 }
 
+// WithTx runs the function in a transaction, committed if it returns no error and rolled back otherwise.
+// Every store call made with the context given to the function is part of the transaction.
+func (s *Store) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.db.withTx(ctx, fn)
+}
+
 func (s *Store) CreateUser(ctx context.Context, u *CreateUser) (*User, error) {
 	if errs := u.Validate(); errs != nil {
 		return nil, errs
@@ -110,6 +513,32 @@ func (s *Store) CreateUser(ctx context.Context, u *CreateUser) (*User, error) {
 	return s.users.Create(ctx, user)
 }
 
+// ProvisionUser returns the local user linked to an identity, creating or linking it on first sign in,
+// and keeps its profile in sync with the identity provider.
+func (s *Store) ProvisionUser(ctx context.Context, u *ProvisionUser) (*User, error) {
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Provider: u.Provider,
+		Subject:  u.Subject,
+		Email:    u.Email,
+	}
+	user := &User{
+		Email:     u.Email,
+		Firstname: u.Firstname,
+		Lastname:  u.Lastname,
+	}
+
+	return s.identities.Provision(ctx, identity, user, u.EmailVerified)
+}
+
+// ListUserIdentities returns the identities linked to a user.
+func (s *Store) ListUserIdentities(ctx context.Context, userID uuid.UUID) ([]Identity, error) {
+	return s.identities.ListByUser(ctx, userID)
+}
+
 func (s *Store) ListUsers(ctx context.Context, limit, offset int) ([]User, error) {
 	return s.users.List(ctx, limit, offset)
 }
@@ -137,23 +566,229 @@ func (s *Store) UpdateUser(ctx context.Context, u *UpdateUser) (*User, error) {
 	return s.users.Update(ctx, user)
 }
 
-func (s *Store) FindStockBySymbol(ctx context.Context, symbol string) (*Stock, error) {
-	return s.stocks.Find(ctx, symbol)
+// FindStockBySymbol finds a stock by its symbol, in any form of it, e.g. "brk-a" for "BRK.A".
+func (s *Store) FindStockBySymbol(ctx context.Context, sym string) (*Stock, error) {
+	return s.stocks.Find(ctx, symbol.Normalize(sym))
+}
+
+// ListStocks lists the stocks matching the filter, ordered by symbol.
+func (s *Store) ListStocks(ctx context.Context, filter *StockFilter) ([]Stock, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.stocks.List(ctx, filter)
+}
+
+// SearchStocks searches the stocks by symbol and company name, tolerating typos,
+// e.g. "Coca-Cola" or "coca cola" for KO. Matches are ordered by decreasing rank, exact symbols first.
+func (s *Store) SearchStocks(ctx context.Context, search *StockSearch) ([]StockMatch, error) {
+	if err := search.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.stocks.Search(ctx, strings.TrimSpace(search.Query), search.Limit)
+}
+
+// ScreenStocks returns a page of the active stocks matching a screen on their latest metric values,
+// score, recommended action and sector.
+func (s *Store) ScreenStocks(ctx context.Context, screen *StockScreen) ([]ScreenedStock, error) {
+	if err := screen.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.stocks.Screen(ctx, screen)
+}
+
+// CompareStocks returns the snapshots of the stocks with the given symbols, with their latest score,
+// recommended action and metric values, ordered by symbol. Unknown symbols are left out.
+func (s *Store) CompareStocks(ctx context.Context, symbols []string) ([]StockSnapshot, error) {
+	normalized := make([]string, 0, len(symbols))
+	for _, sym := range symbols {
+		normalized = append(normalized, symbol.Normalize(sym))
+	}
+
+	return s.stocks.FindSnapshots(ctx, normalized)
+}
+
+// ListPeers returns a page of the snapshots of the active stocks sharing a sector or an industry,
+// apart from the compared stock, ordered by decreasing latest score, the unscored ones last, then by symbol.
+func (s *Store) ListPeers(ctx context.Context, filter *PeerFilter) ([]StockSnapshot, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.stocks.FindPeerSnapshots(ctx, filter)
+}
+
+// CreateStock adds a stock to the universe. It returns ErrAlreadyExists if the symbol is already known.
+func (s *Store) CreateStock(ctx context.Context, c *CreateStock) (*Stock, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	stock := &Stock{
+		Symbol:   symbol.Normalize(c.Symbol),
+		Company:  c.Company,
+		Exchange: c.Exchange,
+		Currency: c.Currency,
+		Sector:   c.Sector,
+		Industry: c.Industry,
+		Active:   true,
+	}
+
+	return s.stocks.Create(ctx, stock)
+}
+
+// UpdateStock updates the details of a stock, including whether it is active.
+func (s *Store) UpdateStock(ctx context.Context, u *UpdateStock) (*Stock, error) {
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+
+	stock := &Stock{
+		Symbol:   symbol.Normalize(u.Symbol),
+		Company:  u.Company,
+		Exchange: u.Exchange,
+		Currency: u.Currency,
+		Sector:   u.Sector,
+		Industry: u.Industry,
+		Active:   u.Active,
+	}
+
+	return s.stocks.Update(ctx, stock)
+}
+
+// DeactivateStock removes a stock from the active universe, keeping its history.
+// The symbol can be in any form of it.
+func (s *Store) DeactivateStock(ctx context.Context, sym string) error {
+	return s.stocks.Deactivate(ctx, symbol.Normalize(sym))
+}
+
+// ImportStocks brings the stock universe in line with the listings of a file.
+// Listed stocks are added or have their company and exchange updated, relisted stocks are reactivated
+// and delisted stocks are deactivated, keeping their history. The changes are applied in a single transaction,
+// unless it is a dry run, and returned ordered by symbol.
+func (s *Store) ImportStocks(ctx context.Context, imp *ImportStocks) ([]listing.Change, error) {
+	if err := imp.Validate(); err != nil {
+		return nil, err
+	}
+
+	var changes []listing.Change
+	err := s.db.withTx(ctx, func(ctx context.Context) error {
+		stocks, err := s.stocks.ListAll(ctx)
+		if err != nil {
+			return fmt.Errorf("listing stocks: %w", err)
+		}
+
+		current := make([]listing.Listing, 0, len(stocks))
+		bySymbol := make(map[string]Stock, len(stocks))
+		for _, stock := range stocks {
+			bySymbol[stock.Symbol] = stock
+			current = append(current, listing.Listing{
+				Symbol:   stock.Symbol,
+				Name:     stock.Company,
+				Exchange: stock.Exchange,
+				Delisted: !stock.Active,
+			})
+		}
+
+		changes = listing.FilterAddedAssetTypes(listing.Diff(current, imp.Listings), imp.AssetTypes...)
+		if imp.DryRun {
+			return nil
+		}
+
+		for _, change := range changes {
+			if err = s.applyListingChange(ctx, change, bySymbol[change.Symbol()], imp.Currency); err != nil {
+				return fmt.Errorf("stock %s: %w", change.Symbol(), err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (s *Store) applyListingChange(ctx context.Context, change listing.Change, stock Stock, currency string) error {
+	switch change.Kind {
+	case listing.Added:
+		c := &CreateStock{
+			Symbol:   change.After.Symbol,
+			Company:  change.After.Name,
+			Exchange: change.After.Exchange,
+			Currency: currency,
+		}
+		if err := c.Validate(); err != nil {
+			return err
+		}
+
+		_, err := s.stocks.Create(ctx, &Stock{
+			Symbol:   c.Symbol,
+			Company:  c.Company,
+			Exchange: c.Exchange,
+			Currency: c.Currency,
+			Active:   true,
+		})
+		return err
+	case listing.Updated, listing.Relisted:
+		stock.Company = change.After.Name
+		stock.Exchange = change.After.Exchange
+		stock.Active = true
+
+		_, err := s.stocks.Update(ctx, &stock)
+		return err
+	case listing.Delisted:
+		return s.stocks.Deactivate(ctx, stock.Symbol)
+	default:
+		return fmt.Errorf("unknown change %q", change.Kind)
+	}
+}
+
+// UpdateStockClassification sets the sector and industry of a stock.
+func (s *Store) UpdateStockClassification(
+	ctx context.Context,
+	stockID uuid.UUID,
+	sector, industry string,
+) (*Stock, error) {
+	stock := &Stock{
+		Model: Model{
+			ID: stockID,
+		},
+		Sector:   sector,
+		Industry: industry,
+	}
+
+	return s.stocks.UpdateClassification(ctx, stock)
 }
 
-func (s *Store) CreateStockMetric(
+// FindMetricDistributions returns the latest values of every metric, as known at the given time,
+// across the active stocks belonging to the given sector or industry.
+func (s *Store) FindMetricDistributions(
 	ctx context.Context,
-	stockID, metricID uuid.UUID,
-	value float64,
-) (*StockMetric, error) {
+	group PeerGroup,
+	name string,
+	asOf time.Time,
+) ([]MetricDistribution, error) {
+	return s.stocks.FindMetricDistributions(ctx, group, name, asOf)
+}
+
+// CreateStockMetric records a stock metric value. Without report time, it is reported at the time of the
+// transaction, the analyses recorded in the same transaction being created at the same time.
+func (s *Store) CreateStockMetric(ctx context.Context, m *CreateStockMetric) (*StockMetric, error) {
 	stockMetric := &StockMetric{
 		Model: Model{
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		},
-		StockID:  stockID,
-		MetricID: metricID,
-		Value:    value,
+		StockID:    m.StockID,
+		MetricID:   m.MetricID,
+		Value:      m.Value,
+		PeriodEnd:  m.PeriodEnd,
+		ReportedAt: m.ReportedAt,
 	}
 	return s.stocks.CreateStockMetric(ctx, *stockMetric)
 }
@@ -163,7 +798,16 @@ func (s *Store) ListMetrics(ctx context.Context, limit, offset int) ([]Metric, e
 }
 
 func (s *Store) FindLatestStockMetrics(ctx context.Context, stockID uuid.UUID) ([]LatestStockMetric, error) {
-	return s.stocks.FindLastestStockMetrics(ctx, stockID)
+	return s.stocks.FindStockMetricsAsOf(ctx, stockID, time.Now())
+}
+
+// FindStockMetricsAsOf returns the latest value of each metric of a stock as it was known at the given time.
+func (s *Store) FindStockMetricsAsOf(
+	ctx context.Context,
+	stockID uuid.UUID,
+	asOf time.Time,
+) ([]LatestStockMetric, error) {
+	return s.stocks.FindStockMetricsAsOf(ctx, stockID, asOf)
 }
 
 func (s *Store) CreateAnalysis(ctx context.Context, userID, stockID uuid.UUID, score float64) (*Analysis, error) {
@@ -180,6 +824,43 @@ func (s *Store) CreateAnalysis(ctx context.Context, userID, stockID uuid.UUID, s
 	return s.analyses.Create(ctx, analysis)
 }
 
+// FindAnalysis returns an analysis along with its recommendation.
+func (s *Store) FindAnalysis(ctx context.Context, id uuid.UUID) (*AnalysisRecommendation, error) {
+	return s.analyses.Find(ctx, id)
+}
+
+// ListAnalyses returns the analyses matching the filter, the most recent first.
+func (s *Store) ListAnalyses(ctx context.Context, filter *AnalysisFilter) ([]AnalysisRecommendation, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.analyses.List(ctx, filter, false)
+}
+
+// ListRecommendationHistory returns the analyses matching the filter in chronological order.
+func (s *Store) ListRecommendationHistory(
+	ctx context.Context,
+	filter *AnalysisFilter,
+) ([]AnalysisRecommendation, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.analyses.List(ctx, filter, true)
+}
+
+// ListRankings returns the active stocks ranked by their latest analysis score, the highest first,
+// along with their latest scores a week and a month ago.
+func (s *Store) ListRankings(ctx context.Context, filter *RankingFilter) ([]StockRanking, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return s.analyses.ListRankings(ctx, filter, now.AddDate(0, 0, -7), now.AddDate(0, -1, 0))
+}
+
 func (s *Store) CreateRecommendation(
 	ctx context.Context,
 	analysisID uuid.UUID,
@@ -200,3 +881,359 @@ func (s *Store) CreateRecommendation(
 
 	return s.recommendations.Create(ctx, recommendation)
 }
+
+// FindStockMetricHistory returns the stock metric values known at from and the ones reported until the given time,
+// in reporting order.
+func (s *Store) FindStockMetricHistory(ctx context.Context, from, until time.Time) ([]HistoricalStockMetric, error) {
+	return s.stocks.FindStockMetricHistory(ctx, from, until)
+}
+
+// SaveStockPrices creates or updates the daily price bars of stocks.
+func (s *Store) SaveStockPrices(ctx context.Context, prices []StockPrice) error {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	return s.prices.Upsert(ctx, prices)
+}
+
+// ListStockPrices returns the daily price bars of every stock between two dates, ordered by stock and date.
+func (s *Store) ListStockPrices(ctx context.Context, from, to time.Time) ([]StockPrice, error) {
+	return s.prices.List(ctx, from, to)
+}
+
+// CreateAnalysisJob queues the analysis of symbols on behalf of a user.
+func (s *Store) CreateAnalysisJob(ctx context.Context, j *CreateAnalysisJob) (*AnalysisJob, error) {
+	if err := j.Validate(); err != nil {
+		return nil, err
+	}
+
+	job := &AnalysisJob{
+		UserID:  j.UserID,
+		Symbols: j.Symbols,
+		Profile: j.Profile,
+	}
+
+	return s.analysisJobs.Create(ctx, job)
+}
+
+// FindAnalysisJob returns an analysis job.
+func (s *Store) FindAnalysisJob(ctx context.Context, id uuid.UUID) (*AnalysisJob, error) {
+	return s.analysisJobs.Find(ctx, id)
+}
+
+// StartAnalysisJob marks a queued or interrupted analysis job as running and returns it.
+// It returns ErrNotFound if the job does not exist or is already finished.
+func (s *Store) StartAnalysisJob(ctx context.Context, id uuid.UUID) (*AnalysisJob, error) {
+	return s.analysisJobs.Start(ctx, id)
+}
+
+// UpdateAnalysisJobResults records the results of the symbols analyzed so far.
+func (s *Store) UpdateAnalysisJobResults(ctx context.Context, id uuid.UUID, results []AnalysisJobResult) error {
+	return s.analysisJobs.UpdateResults(ctx, id, results)
+}
+
+// FinishAnalysisJob marks an analysis job as succeeded or failed.
+func (s *Store) FinishAnalysisJob(ctx context.Context, id uuid.UUID, status JobStatus, errMsg string) error {
+	return s.analysisJobs.Finish(ctx, id, status, errMsg)
+}
+
+// EnqueueJob adds a job to the background job queue.
+func (s *Store) EnqueueJob(ctx context.Context, j *EnqueueJob) (*Job, error) {
+	if err := j.Validate(); err != nil {
+		return nil, err
+	}
+
+	runAt := j.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+
+	maxAttempts := j.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	payload := j.Payload
+	if payload == nil {
+		payload = struct{}{}
+	}
+
+	return s.jobs.Enqueue(ctx, j.Type, payload, maxAttempts, runAt)
+}
+
+// ClaimJob marks the next due job of the given types as running, locked for the visibility timeout, and returns it.
+// Jobs whose lock expired are claimed again. It returns ErrNotFound if no job is due.
+func (s *Store) ClaimJob(ctx context.Context, types []string, visibilityTimeout time.Duration) (*Job, error) {
+	return s.jobs.Claim(ctx, types, time.Now().Add(visibilityTimeout))
+}
+
+// ExtendJob extends the lock of a running job by the visibility timeout.
+func (s *Store) ExtendJob(ctx context.Context, id uuid.UUID, visibilityTimeout time.Duration) error {
+	return s.jobs.Extend(ctx, id, time.Now().Add(visibilityTimeout))
+}
+
+// CompleteJob marks a job as succeeded.
+func (s *Store) CompleteJob(ctx context.Context, id uuid.UUID) error {
+	return s.jobs.Complete(ctx, id)
+}
+
+// FailJob records the failure of a job, retrying it after the given delay
+// or moving it to the dead letters once it ran out of attempts. It returns the resulting status.
+func (s *Store) FailJob(ctx context.Context, id uuid.UUID, errMsg string, retryAfter time.Duration) (JobStatus, error) {
+	return s.jobs.Fail(ctx, id, errMsg, time.Now().Add(retryAfter))
+}
+
+// ReleaseJob puts a running job back in the queue without counting the interrupted attempt.
+func (s *Store) ReleaseJob(ctx context.Context, id uuid.UUID) error {
+	return s.jobs.Release(ctx, id)
+}
+
+// ScheduleRun records a run of a schedule and queues its job, with the run ID as payload.
+// It returns ErrAlreadyExists if the run was already recorded, e.g. by another process.
+func (s *Store) ScheduleRun(ctx context.Context, r *CreateScheduledRun) (*ScheduledRun, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	var run *ScheduledRun
+	err := s.db.withTx(ctx, func(ctx context.Context) error {
+		var err error
+		run, err = s.scheduledRuns.Create(ctx, r.Schedule, r.JobType, r.ScheduledFor)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.EnqueueJob(ctx, &EnqueueJob{
+			Type:    r.JobType,
+			Payload: ScheduledRunPayload{RunID: run.ID},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// StartScheduledRun marks a queued, interrupted or partial scheduled run as running and returns it.
+// It returns ErrNotFound if the run does not exist or is already finished.
+func (s *Store) StartScheduledRun(ctx context.Context, id uuid.UUID) (*ScheduledRun, error) {
+	return s.scheduledRuns.Start(ctx, id)
+}
+
+// UpdateScheduledRunProgress records the items processed so far by a scheduled run,
+// along with the symbol of the last processed stock.
+func (s *Store) UpdateScheduledRunProgress(
+	ctx context.Context,
+	id uuid.UUID,
+	total, succeeded, failed int,
+	lastSymbol string,
+) error {
+	return s.scheduledRuns.UpdateProgress(ctx, id, total, succeeded, failed, lastSymbol)
+}
+
+// FinishScheduledRun marks a scheduled run as succeeded or failed.
+func (s *Store) FinishScheduledRun(ctx context.Context, id uuid.UUID, status JobStatus, errMsg string) error {
+	return s.scheduledRuns.Finish(ctx, id, status, errMsg)
+}
+
+// PauseScheduledRun marks a running scheduled run as partial and queues a job resuming it at the given time.
+// It returns ErrNotFound if the run does not exist or is not running.
+func (s *Store) PauseScheduledRun(ctx context.Context, run *ScheduledRun, errMsg string, resumeAt time.Time) error {
+	return s.db.withTx(ctx, func(ctx context.Context) error {
+		if err := s.scheduledRuns.Pause(ctx, run.ID, errMsg); err != nil {
+			return err
+		}
+
+		_, err := s.EnqueueJob(ctx, &EnqueueJob{
+			Type:    run.JobType,
+			Payload: ScheduledRunPayload{RunID: run.ID},
+			RunAt:   resumeAt,
+		})
+		return err
+	})
+}
+
+// ReserveProviderCall takes the next call slot of an external provider shared by every server and worker,
+// and returns the time the call is allowed at. It returns ErrQuotaExceeded if the daily limit of the provider
+// is reached or, without waiting, if no call is allowed right away.
+func (s *Store) ReserveProviderCall(ctx context.Context, r *ReserveProviderCall) (time.Time, error) {
+	if err := r.Validate(); err != nil {
+		return time.Time{}, err
+	}
+
+	return s.providerQuotas.Reserve(ctx, r.Provider, r.Interval, r.DailyLimit, r.Wait)
+}
+
+// CreateSavedScreen saves a screener query of a user along with its current result.
+// It returns ErrAlreadyExists if the user already has a screen of the same name.
+func (s *Store) CreateSavedScreen(ctx context.Context, c *CreateSavedScreen) (*SavedScreen, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	var screen *SavedScreen
+	err := s.db.withTx(ctx, func(ctx context.Context) error {
+		var err error
+		screen, err = s.savedScreens.Create(ctx, &SavedScreen{
+			UserID: c.UserID,
+			Name:   strings.TrimSpace(c.Name),
+			Query:  c.Query,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.savedScreens.SetMembers(ctx, screen.ID, stockIDs(c.Stocks))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return screen, nil
+}
+
+// FindSavedScreen returns a saved screen.
+func (s *Store) FindSavedScreen(ctx context.Context, id uuid.UUID) (*SavedScreen, error) {
+	return s.savedScreens.Find(ctx, id)
+}
+
+// ListSavedScreens returns the saved screens of a user, or of every user if the user ID is nil, ordered by name.
+func (s *Store) ListSavedScreens(ctx context.Context, userID uuid.UUID) ([]SavedScreen, error) {
+	return s.savedScreens.List(ctx, userID)
+}
+
+// MarkSavedScreenSeen marks the changes of a saved screen recorded so far as read by its user.
+func (s *Store) MarkSavedScreenSeen(ctx context.Context, id uuid.UUID) (*SavedScreen, error) {
+	return s.savedScreens.MarkSeen(ctx, id)
+}
+
+// DeleteSavedScreen deletes a saved screen along with its changes.
+func (s *Store) DeleteSavedScreen(ctx context.Context, id uuid.UUID) error {
+	return s.savedScreens.Delete(ctx, id)
+}
+
+// UpdateSavedScreenStocks replaces the result of a saved screen with the stocks currently matching it
+// and records the stocks entering and leaving it, if any, on behalf of a scheduled run.
+// The returned change is only recorded if it Changed.
+func (s *Store) UpdateSavedScreenStocks(
+	ctx context.Context,
+	screenID uuid.UUID,
+	runID *uuid.UUID,
+	stocks []Stock,
+) (*ScreenChange, error) {
+	change := &ScreenChange{ScreenID: screenID, RunID: runID, Entered: []string{}, Exited: []string{}}
+	err := s.db.withTx(ctx, func(ctx context.Context) error {
+		members, err := s.savedScreens.ListMembers(ctx, screenID)
+		if err != nil {
+			return fmt.Errorf("listing members: %w", err)
+		}
+
+		previous := make(map[uuid.UUID]bool, len(members))
+		for _, member := range members {
+			previous[member.ID] = true
+		}
+
+		current := make(map[uuid.UUID]bool, len(stocks))
+		for _, stock := range stocks {
+			current[stock.ID] = true
+			if !previous[stock.ID] {
+				change.Entered = append(change.Entered, stock.Symbol)
+			}
+		}
+		for _, member := range members {
+			if !current[member.ID] {
+				change.Exited = append(change.Exited, member.Symbol)
+			}
+		}
+
+		if !change.Changed() {
+			return nil
+		}
+
+		if err = s.savedScreens.SetMembers(ctx, screenID, stockIDs(stocks)); err != nil {
+			return fmt.Errorf("setting members: %w", err)
+		}
+
+		change, err = s.savedScreens.CreateChange(ctx, change)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+// ListSavedScreenChanges returns the changes of a saved screen, the most recent first.
+func (s *Store) ListSavedScreenChanges(
+	ctx context.Context,
+	screenID uuid.UUID,
+	limit, offset int,
+) ([]ScreenChange, error) {
+	return s.savedScreens.ListChanges(ctx, screenID, limit, offset)
+}
+
+// CreateWatchlist creates a watchlist of a user.
+// It returns ErrAlreadyExists if the user already has a watchlist of the same name.
+func (s *Store) CreateWatchlist(ctx context.Context, c *CreateWatchlist) (*Watchlist, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.watchlists.Create(ctx, &Watchlist{UserID: c.UserID, Name: strings.TrimSpace(c.Name)})
+}
+
+// FindWatchlist returns a watchlist.
+func (s *Store) FindWatchlist(ctx context.Context, id uuid.UUID) (*Watchlist, error) {
+	return s.watchlists.Find(ctx, id)
+}
+
+// ListWatchlists returns the watchlists of a user, ordered by name.
+func (s *Store) ListWatchlists(ctx context.Context, userID uuid.UUID) ([]Watchlist, error) {
+	return s.watchlists.List(ctx, userID)
+}
+
+// RenameWatchlist renames a watchlist.
+// It returns ErrAlreadyExists if the user already has a watchlist of the new name.
+func (s *Store) RenameWatchlist(ctx context.Context, r *RenameWatchlist) (*Watchlist, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.watchlists.Rename(ctx, r.ID, strings.TrimSpace(r.Name))
+}
+
+// DeleteWatchlist deletes a watchlist along with its items.
+func (s *Store) DeleteWatchlist(ctx context.Context, id uuid.UUID) error {
+	return s.watchlists.Delete(ctx, id)
+}
+
+// AddWatchlistItem adds a stock to a watchlist.
+// It returns ErrAlreadyExists if the stock is already in the watchlist.
+func (s *Store) AddWatchlistItem(ctx context.Context, watchlistID, stockID uuid.UUID) error {
+	return s.watchlists.AddItem(ctx, watchlistID, stockID)
+}
+
+// RemoveWatchlistItem removes a stock from a watchlist.
+// It returns ErrNotFound if the stock is not in the watchlist.
+func (s *Store) RemoveWatchlistItem(ctx context.Context, watchlistID, stockID uuid.UUID) error {
+	return s.watchlists.RemoveItem(ctx, watchlistID, stockID)
+}
+
+// ListWatchlistItems returns the stocks of a watchlist in the order they were added,
+// along with their latest price, score and recommended action.
+func (s *Store) ListWatchlistItems(ctx context.Context, watchlistID uuid.UUID) ([]WatchlistItem, error) {
+	return s.watchlists.ListItems(ctx, watchlistID)
+}
+
+func stockIDs(stocks []Stock) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(stocks))
+	for _, stock := range stocks {
+		ids = append(ids, stock.ID)
+	}
+
+	return ids
+}
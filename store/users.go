@@ -27,7 +27,7 @@ func (s *userService) Create(ctx context.Context, user *User) (*User, error) {
 		RETURNING id, created_at, updated_at
 	`
 
-	err := s.db.pool.QueryRow(ctx, sql,
+	err := s.db.conn(ctx).QueryRow(ctx, sql,
 		user.Email,
 		user.Firstname,
 		user.Lastname,
@@ -40,8 +40,12 @@ func (s *userService) Create(ctx context.Context, user *User) (*User, error) {
 }
 
 func (s *userService) List(ctx context.Context, limit, offset int) ([]User, error) {
-	sql := "SELECT id, email, firstname, lastname FROM users LIMIT $1 OFFSET $2"
-	rows, err := s.db.pool.Query(ctx, sql, limit, offset)
+	sql := `
+		SELECT id, COALESCE(email, ''), COALESCE(firstname, ''), COALESCE(lastname, '')
+		FROM users
+		LIMIT $1 OFFSET $2
+	`
+	rows, err := s.db.conn(ctx).Query(ctx, sql, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -64,9 +68,13 @@ func (s *userService) List(ctx context.Context, limit, offset int) ([]User, erro
 }
 
 func (s *userService) Find(ctx context.Context, id uuid.UUID) (*User, error) {
-	sql := "SELECT id, email, firstname, lastname, created_at, updated_at FROM users WHERE id = $1"
+	sql := `
+		SELECT id, COALESCE(email, ''), COALESCE(firstname, ''), COALESCE(lastname, ''), created_at, updated_at
+		FROM users
+		WHERE id = $1
+	`
 	var user User
-	err := s.db.pool.QueryRow(ctx, sql, id).Scan(
+	err := s.db.conn(ctx).QueryRow(ctx, sql, id).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Firstname,
@@ -95,7 +103,7 @@ func (s *userService) Update(ctx context.Context, user *User) (*User, error) {
 			WHERE id = $4
 	`
 
-	res, err := s.db.pool.Exec(ctx, sql, user.Email, user.Firstname, user.Lastname, user.ID)
+	res, err := s.db.conn(ctx).Exec(ctx, sql, user.Email, user.Firstname, user.Lastname, user.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,48 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/huy125/finscope/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_FindUserWithoutEmail(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+	prefix := uuid.NewString()
+	user, err := s.ProvisionUser(t.Context(), &store.ProvisionUser{
+		Provider:  testProvider,
+		Subject:   prefix,
+		Email:     prefix + "@example.com",
+		Firstname: "Jane",
+	})
+	require.NoError(t, err)
+
+	got, err := s.FindUser(t.Context(), user.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+	assert.Empty(t, got.Email)
+	assert.Equal(t, "Jane", got.Firstname)
+}
+
+func TestStore_ListUsersWithoutEmail(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+	prefix := uuid.NewString()
+	_, err := s.ProvisionUser(t.Context(), &store.ProvisionUser{
+		Provider: testProvider,
+		Subject:  prefix,
+		Email:    prefix + "@example.com",
+	})
+	require.NoError(t, err)
+
+	_, err = s.ListUsers(t.Context(), 1000, 0)
+
+	require.NoError(t, err)
+}
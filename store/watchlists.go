@@ -0,0 +1,251 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxWatchlistNameLength bounds the length of the watchlist names.
+const maxWatchlistNameLength = 100
+
+// Watchlist represents the watchlist schema in database, a named list of stocks followed by a user.
+type Watchlist struct {
+	Model
+
+	UserID uuid.UUID
+	Name   string
+}
+
+// WatchlistItem represents a stock of a watchlist along with its latest close price, if priced,
+// and its latest score and recommended action, if analyzed.
+type WatchlistItem struct {
+	Stock
+
+	AddedAt  time.Time
+	Price    *float64
+	PricedAt *time.Time
+	Score    *float64
+	ScoredAt *time.Time
+	Action   Action
+}
+
+type watchlistService struct {
+	db *DB
+}
+
+const watchlistColumns = `
+	id, user_id, name, created_at, updated_at
+`
+
+// Create records a watchlist. It returns ErrAlreadyExists if the user already has a watchlist of the same name.
+func (s *watchlistService) Create(ctx context.Context, watchlist *Watchlist) (*Watchlist, error) {
+	sql := `
+		INSERT INTO watchlist (user_id, name)
+		VALUES ($1, $2)
+		RETURNING ` + watchlistColumns
+
+	created, err := scanWatchlist(s.db.conn(ctx).QueryRow(ctx, sql, watchlist.UserID, watchlist.Name))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrAlreadyExists
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *watchlistService) Find(ctx context.Context, id uuid.UUID) (*Watchlist, error) {
+	sql := "SELECT " + watchlistColumns + " FROM watchlist WHERE id = $1"
+
+	return scanWatchlist(s.db.conn(ctx).QueryRow(ctx, sql, id))
+}
+
+// List returns the watchlists of a user, ordered by name.
+func (s *watchlistService) List(ctx context.Context, userID uuid.UUID) ([]Watchlist, error) {
+	sql := "SELECT " + watchlistColumns + " FROM watchlist WHERE user_id = $1 ORDER BY name, id"
+
+	rows, err := s.db.conn(ctx).Query(ctx, sql, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var watchlists []Watchlist
+	for rows.Next() {
+		watchlist, err := scanWatchlist(rows)
+		if err != nil {
+			return nil, err
+		}
+		watchlists = append(watchlists, *watchlist)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return watchlists, nil
+}
+
+// Rename renames a watchlist. It returns ErrAlreadyExists if the user already has a watchlist of the new name.
+func (s *watchlistService) Rename(ctx context.Context, id uuid.UUID, name string) (*Watchlist, error) {
+	sql := `
+		UPDATE watchlist
+		SET name = $2
+		WHERE id = $1
+		RETURNING ` + watchlistColumns
+
+	watchlist, err := scanWatchlist(s.db.conn(ctx).QueryRow(ctx, sql, id, name))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrAlreadyExists
+		}
+
+		return nil, err
+	}
+
+	return watchlist, nil
+}
+
+func (s *watchlistService) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := s.db.conn(ctx).Exec(ctx, "DELETE FROM watchlist WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// AddItem adds a stock to a watchlist. It returns ErrAlreadyExists if the stock is already in the watchlist.
+func (s *watchlistService) AddItem(ctx context.Context, watchlistID, stockID uuid.UUID) error {
+	sql := "INSERT INTO watchlist_item (watchlist_id, stock_id) VALUES ($1, $2)"
+
+	if _, err := s.db.conn(ctx).Exec(ctx, sql, watchlistID, stockID); err != nil {
+		if isUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// RemoveItem removes a stock from a watchlist. It returns ErrNotFound if the stock is not in the watchlist.
+func (s *watchlistService) RemoveItem(ctx context.Context, watchlistID, stockID uuid.UUID) error {
+	sql := "DELETE FROM watchlist_item WHERE watchlist_id = $1 AND stock_id = $2"
+
+	res, err := s.db.conn(ctx).Exec(ctx, sql, watchlistID, stockID)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ListItems returns the stocks of a watchlist in the order they were added.
+func (s *watchlistService) ListItems(ctx context.Context, watchlistID uuid.UUID) ([]WatchlistItem, error) {
+	sql := `
+		SELECT
+			` + stockColumns + `,
+			wi.added_at,
+			lp.close,
+			lp.date,
+			ls.score::float8,
+			ls.scored_at,
+			(SELECT r.action FROM recommendation r WHERE r.analysis_id = ls.analysis_id LIMIT 1)
+		FROM stock
+		INNER JOIN (
+			SELECT stock_id, created_at AS added_at FROM watchlist_item WHERE watchlist_id = $1
+		) wi ON wi.stock_id = stock.id
+		LEFT JOIN latest_score ls ON ls.stock_id = stock.id
+		LEFT JOIN LATERAL (
+			SELECT sp.close::float8 AS close, sp.date
+			FROM stock_price sp
+			WHERE sp.stock_id = stock.id
+			ORDER BY sp.date DESC
+			LIMIT 1
+		) lp ON true
+		ORDER BY wi.added_at, stock.symbol
+	`
+
+	rows, err := s.db.conn(ctx).Query(ctx, sql, watchlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []WatchlistItem
+	for rows.Next() {
+		var (
+			item   WatchlistItem
+			action *string
+		)
+		err = rows.Scan(
+			&item.ID,
+			&item.Symbol,
+			&item.Company,
+			&item.Exchange,
+			&item.Currency,
+			&item.Sector,
+			&item.Industry,
+			&item.Active,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+			&item.AddedAt,
+			&item.Price,
+			&item.PricedAt,
+			&item.Score,
+			&item.ScoredAt,
+			&action,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if action != nil {
+			item.Action = Action(*action)
+		}
+
+		items = append(items, item)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return items, nil
+}
+
+func scanWatchlist(row pgx.Row) (*Watchlist, error) {
+	var watchlist Watchlist
+	err := row.Scan(
+		&watchlist.ID,
+		&watchlist.UserID,
+		&watchlist.Name,
+		&watchlist.CreatedAt,
+		&watchlist.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return &watchlist, nil
+}
@@ -0,0 +1,229 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hamba/logger/v2"
+	lctx "github.com/hamba/logger/v2/ctx"
+)
+
+const (
+	defaultPollInterval      = 2 * time.Second
+	defaultVisibilityTimeout = 5 * time.Minute
+	// releaseTimeout bounds the release of the jobs interrupted by the shutdown of the worker.
+	releaseTimeout = 5 * time.Second
+
+	minRetryDelay = 30 * time.Second
+	maxRetryDelay = time.Hour
+)
+
+// JobHandler runs a job. A returned error fails the attempt, retried later with backoff.
+// The context is cancelled when the worker shuts down, interrupted jobs being released to run again.
+type JobHandler func(ctx context.Context, job *Job) error
+
+// Worker runs the queued jobs with the handlers registered for their type.
+// Several workers, in the same or different processes, can share the queue.
+type Worker struct {
+	store    *Store
+	handlers map[string]JobHandler
+
+	concurrency       int
+	pollInterval      time.Duration
+	visibilityTimeout time.Duration
+
+	log *logger.Logger
+}
+
+// WorkerOption configures a worker.
+type WorkerOption func(*Worker)
+
+// WithConcurrency sets the number of jobs run concurrently.
+func WithConcurrency(n int) WorkerOption {
+	return func(w *Worker) {
+		w.concurrency = n
+	}
+}
+
+// WithPollInterval sets the interval at which the queue is polled when no job is due.
+func WithPollInterval(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.pollInterval = d
+	}
+}
+
+// WithVisibilityTimeout sets the time a claimed job is hidden from other workers.
+// The lock is extended while the job runs, so it only expires if the worker stops without releasing the job.
+func WithVisibilityTimeout(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.visibilityTimeout = d
+	}
+}
+
+// NewWorker returns a worker running the jobs of the store.
+func NewWorker(s *Store, log *logger.Logger, opts ...WorkerOption) *Worker {
+	w := &Worker{
+		store:             s,
+		handlers:          map[string]JobHandler{},
+		concurrency:       1,
+		pollInterval:      defaultPollInterval,
+		visibilityTimeout: defaultVisibilityTimeout,
+		log:               log.With(lctx.Str("component", "worker")),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if w.visibilityTimeout <= 0 {
+		w.visibilityTimeout = defaultVisibilityTimeout
+	}
+
+	return w
+}
+
+// Handle registers the handler of a job type.
+func (w *Worker) Handle(typ string, h JobHandler) {
+	w.handlers[typ] = h
+}
+
+// Run runs the jobs of the registered types until the context is done.
+func (w *Worker) Run(ctx context.Context) error {
+	if len(w.handlers) == 0 {
+		return errors.New("no job handlers registered")
+	}
+
+	types := slices.Sorted(maps.Keys(w.handlers))
+
+	var wg sync.WaitGroup
+	for range max(w.concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			w.poll(ctx, types)
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func (w *Worker) poll(ctx context.Context, types []string) {
+	for {
+		job, err := w.store.ClaimJob(ctx, types, w.visibilityTimeout)
+		switch {
+		case err == nil:
+			w.run(ctx, job)
+			continue
+		case errors.Is(err, ErrNotFound):
+		case ctx.Err() != nil:
+			return
+		default:
+			w.log.Error("Failed to claim job", lctx.Error("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.pollInterval):
+		}
+	}
+}
+
+func (w *Worker) run(ctx context.Context, job *Job) {
+	log := w.log.With(lctx.Str("job", job.ID.String()), lctx.Str("type", job.Type))
+
+	// The context of the worker may be done, the outcome of the job is recorded regardless.
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancel()
+
+	// A job claimed again after its lock expired has been abandoned by its last attempt,
+	// which may have crashed the worker running it.
+	if job.Attempts > job.MaxAttempts {
+		w.fail(recordCtx, log, job, errors.New("visibility timeout exceeded"))
+		return
+	}
+
+	err := w.handle(ctx, job)
+	switch {
+	case err == nil:
+		if err = w.store.CompleteJob(recordCtx, job.ID); err != nil {
+			log.Error("Failed to complete job", lctx.Error("error", err))
+		}
+	case ctx.Err() != nil:
+		if err = w.store.ReleaseJob(recordCtx, job.ID); err != nil {
+			log.Error("Failed to release job", lctx.Error("error", err))
+		}
+	default:
+		w.fail(recordCtx, log, job, err)
+	}
+}
+
+// handle runs the handler of the job, extending its lock until it returns.
+func (w *Worker) handle(ctx context.Context, job *Job) (err error) {
+	h, ok := w.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("no handler for job type %q", job.Type)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(w.visibilityTimeout / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := w.store.ExtendJob(ctx, job.ID, w.visibilityTimeout); err != nil && ctx.Err() == nil {
+					w.log.Error("Failed to extend job lock", lctx.Str("job", job.ID.String()), lctx.Error("error", err))
+				}
+			}
+		}
+	}()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panicked: %v", r)
+		}
+	}()
+
+	return h(ctx, job)
+}
+
+func (w *Worker) fail(ctx context.Context, log *logger.Logger, job *Job, jobErr error) {
+	status, err := w.store.FailJob(ctx, job.ID, jobErr.Error(), retryDelay(job.Attempts))
+	if err != nil {
+		log.Error("Failed to record job failure", lctx.Error("error", err))
+		return
+	}
+
+	if status == JobStatusDead {
+		log.Error("Job moved to dead letters", lctx.Int("attempts", job.Attempts), lctx.Error("error", jobErr))
+		return
+	}
+
+	log.Info("Job failed, retrying later", lctx.Int("attempts", job.Attempts), lctx.Error("error", jobErr))
+}
+
+// retryDelay returns the exponential backoff before the next attempt of a job.
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for range max(attempts-1, 0) {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+
+	return delay
+}